@@ -1,5 +1,18 @@
 import { NextResponse } from 'next/server';
+import { runQueueStatus } from '@/lib/qa-gates/run-queue';
 
+/**
+ * GET /api/health
+ * Liveness plus QA run load; 503 once shutdown has begun, so load
+ * balancers stop sending runs
+ */
 export async function GET() {
-  return NextResponse.json({ status: 'ok' });
+  const runs = runQueueStatus();
+  if (!runs.accepting) {
+    return NextResponse.json(
+      { status: 'shutting_down', runs },
+      { status: 503 }
+    );
+  }
+  return NextResponse.json({ status: 'ok', runs });
 }
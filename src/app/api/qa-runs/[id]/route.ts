@@ -0,0 +1,43 @@
+import { NextResponse } from 'next/server';
+import { loadRepositoryConfig } from '@/lib/qa-gates/config-loader';
+import { buildRunResult, storedRunSummary } from '@/lib/qa-gates/run-report';
+import { withRunHooks } from '@/lib/qa-gates/run-hooks';
+import {
+  getGateExecutions,
+  getQARun,
+  getRepository,
+} from '@/lib/qa-gates/status-service';
+
+/**
+ * GET /api/qa-runs/:id
+ * Any repository run as a RunResult document, including one still going
+ * (`status: "running"`) or waiting its turn
+ */
+export async function GET(
+  _request: Request,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+
+    const run = await getQARun(id);
+    if (!run) {
+      return NextResponse.json({ error: 'QA run not found' }, { status: 404 });
+    }
+
+    const gates = await getGateExecutions(run.id);
+    // Severities come from the repository's current config
+    const repo = await getRepository(run.repositoryId);
+    const config = repo ? await loadRepositoryConfig(repo.path) : undefined;
+    const configGates = config && withRunHooks(config.qaGates, config);
+    return NextResponse.json(
+      buildRunResult(storedRunSummary(run), gates, configGates)
+    );
+  } catch (error) {
+    console.error('Error building QA run report:', error);
+    return NextResponse.json(
+      { error: 'Failed to build QA run report' },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,183 @@
+import { NextResponse } from 'next/server';
+import { db } from '@/db';
+import { qaRuns } from '@/db/schema';
+import {
+  loadRunConfig,
+  parseRunConfigSource,
+  type ForgeConfig,
+  type QAGateConfig,
+  type RunConfigSource,
+} from '@/lib/qa-gates/config-loader';
+import {
+  filterGates,
+  parseGateFilter,
+  type GateFilter,
+} from '@/lib/qa-gates/gate-filter';
+import { Runner } from '@/lib/qa-gates/repository-runner';
+import { EXIT_CODES } from '@/lib/qa-gates/exit-codes';
+import { enqueueRun, runQueueStatus } from '@/lib/qa-gates/run-queue';
+import { databaseRunStore } from '@/lib/qa-gates/run-store';
+import { getRepository } from '@/lib/qa-gates/status-service';
+import { getContainerPath } from '@/lib/qa-gates/command-executor';
+import {
+  parseRunDirs,
+  runDirsError,
+  type RunDirs,
+} from '@/lib/qa-gates/run-dirs';
+import { parseLogLevel } from '@/lib/qa-gates/status-board';
+
+interface RunRequest extends RunConfigSource {
+  repositoryId: string;
+}
+
+function parseRunRequest(body: unknown): RunRequest | string {
+  if (!body || typeof body !== 'object') return 'Expected a JSON object';
+  const { repositoryId } = body as Record<string, unknown>;
+  if (typeof repositoryId !== 'string' || !repositoryId) {
+    return 'repositoryId is required';
+  }
+  const source = parseRunConfigSource(body);
+  if (typeof source === 'string') return source;
+  return { repositoryId, ...source };
+}
+
+async function loadConfig(
+  repoPath: string,
+  source: RunConfigSource
+): Promise<{ config: ForgeConfig } | { error: string }> {
+  try {
+    return { config: await loadRunConfig(repoPath, source) };
+  } catch (error) {
+    return { error: error instanceof Error ? error.message : String(error) };
+  }
+}
+
+function selectGates(
+  gates: QAGateConfig[],
+  filter: GateFilter
+): { selected: QAGateConfig[] } | { error: string } {
+  try {
+    const selected = filterGates(gates, filter);
+    if (selected.length === 0) return { error: 'No QA gates to run' };
+    return { selected };
+  } catch (error) {
+    return { error: error instanceof Error ? error.message : String(error) };
+  }
+}
+
+interface PreparedRun {
+  repoPath: string;
+  config: ForgeConfig;
+  filter: GateFilter;
+}
+
+async function prepareRun(
+  body: RunRequest,
+  filter: GateFilter,
+  dirs: RunDirs
+): Promise<PreparedRun | { error: string; status: number }> {
+  const repo = await getRepository(body.repositoryId);
+  if (!repo) return { error: 'Repository not found', status: 404 };
+
+  const dirsError = runDirsError(dirs, getContainerPath(repo.path));
+  if (dirsError) return { error: dirsError, status: 400 };
+
+  const loaded = await loadConfig(repo.path, body);
+  if ('error' in loaded) return { error: loaded.error, status: 400 };
+
+  const gates = selectGates(loaded.config.qaGates, filter);
+  if ('error' in gates) return { error: gates.error, status: 400 };
+  return { repoPath: repo.path, config: loaded.config, filter };
+}
+
+async function createQARun(repositoryId: string) {
+  return (
+    await db
+      .insert(qaRuns)
+      .values({ repositoryId, status: 'running' })
+      .returning()
+  )[0];
+}
+
+async function runToCompletion(
+  repositoryId: string,
+  { repoPath, config, filter }: PreparedRun,
+  searchParams: URLSearchParams
+) {
+  const run = await createQARun(repositoryId);
+  if (!run) throw new Error('Failed to create QA run');
+
+  const runner = new Runner(config, {
+    repoPath,
+    filter,
+    runId: run.id,
+    store: databaseRunStore,
+    since: searchParams.get('since') || undefined,
+    strict: searchParams.get('strict') === 'true',
+    continueOnFailure: searchParams.get('continue') === 'true' || undefined,
+    noCache: searchParams.get('noCache') === 'true',
+    notify: searchParams.get('notify') !== 'false',
+    ...parseRunDirs(searchParams),
+    logLevel: parseLogLevel(searchParams.get('logLevel')),
+    profile: searchParams.get('profile') === 'true',
+  });
+  return enqueueRun(() => runner.run());
+}
+
+/**
+ * Reject a run that can't start; a bad request or config carries the
+ * config error exit code
+ */
+function invalidRun(error: string, status: number) {
+  const exitCode = status === 400 ? EXIT_CODES.configError : undefined;
+  return NextResponse.json({ error, exitCode }, { status });
+}
+
+/**
+ * POST /api/qa-runs[?only=a,b&skip=c&tag=lint]
+ * Run a repository's gates and respond with the finished RunResult, for
+ * CI controllers triggering runs remotely. The JSON body names the
+ * repository and optionally `configPath` files (relative to the
+ * repository, merged in order) and an inline `config` to use instead of
+ * its config file; `-` in `configPath` places `config`. Takes the run
+ * endpoint's query parameters. Runs beyond FORGE_MAX_CONCURRENT_RUNS wait
+ * their turn; while shutting down, responds 503. The result's `exitCode`
+ * tells CI why a run failed; an invalid request or config responds 400
+ * with `exitCode` 2.
+ */
+export async function POST(request: Request) {
+  try {
+    if (!runQueueStatus().accepting) {
+      return NextResponse.json(
+        { error: 'Forge is shutting down' },
+        { status: 503 }
+      );
+    }
+
+    const body = parseRunRequest(await request.json().catch(() => null));
+    if (typeof body === 'string') return invalidRun(body, 400);
+
+    const { searchParams } = new URL(request.url);
+    const prepared = await prepareRun(
+      body,
+      parseGateFilter(searchParams),
+      parseRunDirs(searchParams)
+    );
+    if ('error' in prepared) {
+      return invalidRun(prepared.error, prepared.status);
+    }
+
+    const result = await runToCompletion(
+      body.repositoryId,
+      prepared,
+      searchParams
+    );
+    return NextResponse.json(result);
+  } catch (error) {
+    console.error('Error running QA gates:', error);
+    return NextResponse.json(
+      { error: 'Failed to run QA gates' },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,71 @@
+import { NextResponse } from 'next/server';
+import {
+  formatRepositoryConfig,
+  type FormatResult,
+} from '@/lib/qa-gates/config-format';
+import { EXIT_CODES } from '@/lib/qa-gates/exit-codes';
+import { getRepository } from '@/lib/qa-gates/status-service';
+
+function formatResponse(result: FormatResult, check: boolean) {
+  switch (result.status) {
+    case 'invalid':
+      return NextResponse.json(
+        {
+          error: 'Invalid .forge.json',
+          problems: result.problems,
+          exitCode: EXIT_CODES.configError,
+        },
+        { status: 400 }
+      );
+    case 'unsupported':
+      return NextResponse.json({ error: result.reason }, { status: 409 });
+    default: {
+      const changed = result.status === 'changed';
+      // A check fails when formatting would change the file
+      const failed = check && changed;
+      return NextResponse.json(
+        {
+          path: result.configPath,
+          changed,
+          exitCode: failed ? EXIT_CODES.failed : EXIT_CODES.passed,
+        },
+        { status: failed ? 409 : 200 }
+      );
+    }
+  }
+}
+
+/**
+ * POST /api/repositories/:id/qa-gates/format[?check=true]
+ * Rewrite .forge.json in its normalized form: gates sorted by `order`
+ * then name, default timeouts filled in, fields in a fixed order and
+ * two-space indentation. `check=true` changes nothing and responds 409,
+ * with `exitCode` 1, when the file isn't formatted. Invalid configs
+ * respond 400 and YAML configs 409, untouched.
+ */
+export async function POST(
+  request: Request,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+    const check = new URL(request.url).searchParams.get('check') === 'true';
+
+    const repo = await getRepository(id);
+    if (!repo) {
+      return NextResponse.json(
+        { error: 'Repository not found' },
+        { status: 404 }
+      );
+    }
+
+    const result = await formatRepositoryConfig(repo.path, check);
+    return formatResponse(result, check);
+  } catch (error) {
+    console.error('Error formatting QA gate config:', error);
+    return NextResponse.json(
+      { error: 'Failed to format QA gate config' },
+      { status: 500 }
+    );
+  }
+}
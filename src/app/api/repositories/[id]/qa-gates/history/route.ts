@@ -0,0 +1,69 @@
+import { NextResponse } from 'next/server';
+import { getRepository } from '@/lib/qa-gates/status-service';
+import { getContainerPath } from '@/lib/qa-gates/command-executor';
+import { isInsideRoot } from '@/lib/qa-gates/run-dirs';
+import {
+  DEFAULT_HISTORY_RUNS,
+  formatHistory,
+  readRunHistory,
+  summarizeHistory,
+} from '@/lib/qa-gates/history';
+
+/**
+ * GET /api/repositories/:id/qa-gates/history?dir=<historyDir>[&last=N]
+ * Per-gate pass rates and average durations over the last N runs (20 by
+ * default) appended to `dir` with the run endpoints' `historyDir`, gates
+ * that both passed and failed flagged as flaky. `dir` must be inside the
+ * repository. `?format=text` for a readable table.
+ */
+export async function GET(
+  request: Request,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+    const { searchParams } = new URL(request.url);
+    const dir = searchParams.get('dir');
+    const last = Number(searchParams.get('last') ?? DEFAULT_HISTORY_RUNS);
+    if (!dir) {
+      return NextResponse.json({ error: 'dir is required' }, { status: 400 });
+    }
+    if (!Number.isInteger(last) || last < 1) {
+      return NextResponse.json(
+        { error: 'last must be a positive integer' },
+        { status: 400 }
+      );
+    }
+
+    const repo = await getRepository(id);
+    if (!repo) {
+      return NextResponse.json(
+        { error: 'Repository not found' },
+        { status: 404 }
+      );
+    }
+
+    const root = getContainerPath(repo.path);
+    if (!isInsideRoot(dir, root)) {
+      return NextResponse.json(
+        { error: 'dir must be inside the repository' },
+        { status: 400 }
+      );
+    }
+
+    const runs = await readRunHistory(dir, root, last);
+    const summary = summarizeHistory(runs);
+    if (searchParams.get('format') === 'text') {
+      return new NextResponse(formatHistory(summary), {
+        headers: { 'Content-Type': 'text/plain; charset=utf-8' },
+      });
+    }
+    return NextResponse.json(summary);
+  } catch (error) {
+    console.error('Error summarizing QA run history:', error);
+    return NextResponse.json(
+      { error: 'Failed to summarize QA run history' },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,86 @@
+import { NextResponse } from 'next/server';
+import {
+  initRepositoryConfig,
+  type InitResult,
+} from '@/lib/qa-gates/config-init';
+import { getRepository } from '@/lib/qa-gates/status-service';
+
+function initResponse(result: InitResult) {
+  switch (result.status) {
+    case 'exists':
+      return NextResponse.json(
+        {
+          error:
+            `${result.configPath} already exists; ` +
+            'pass force=true to replace it',
+        },
+        { status: 409 }
+      );
+    case 'undetected':
+      return NextResponse.json(
+        {
+          error:
+            'No go.mod, Makefile, package.json, Cargo.toml or Python ' +
+            'project found to base a config on',
+        },
+        { status: 422 }
+      );
+    default:
+      return NextResponse.json(
+        {
+          path: result.configPath,
+          project: result.project,
+          gates: result.gates,
+        },
+        { status: 201 }
+      );
+  }
+}
+
+/**
+ * POST /api/repositories/:id/qa-gates/init[?force=true][&format=yaml]
+ * Write a starter .forge.json for the project the repository holds,
+ * detected from go.mod, a Makefile, package.json and the like: the
+ * toolchain's standard gates, checks before tests, with timeouts. For Go
+ * that is the Go template: Go Fmt, Go Lint, Go Vet and Go Test;
+ * `minimal=true` keeps only Go Fmt and Go Test. `format=yaml` writes
+ * .forge.yaml instead. An existing config responds 409, untouched, unless
+ * `force=true`; a project Forge can't tell responds 422.
+ */
+export async function POST(
+  request: Request,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+    const { searchParams } = new URL(request.url);
+    const format = searchParams.get('format') ?? 'json';
+    if (format !== 'json' && format !== 'yaml') {
+      return NextResponse.json(
+        { error: 'format must be json or yaml' },
+        { status: 400 }
+      );
+    }
+
+    const repo = await getRepository(id);
+    if (!repo) {
+      return NextResponse.json(
+        { error: 'Repository not found' },
+        { status: 404 }
+      );
+    }
+
+    const result = await initRepositoryConfig(repo.path, {
+      force: searchParams.get('force') === 'true',
+      format,
+      minimal: searchParams.get('minimal') === 'true',
+    });
+    return initResponse(result);
+  } catch (error) {
+    console.error('Error creating QA gate config:', error);
+    return NextResponse.json(
+      { error: 'Failed to create QA gate config' },
+      { status: 500 }
+    );
+  }
+}
@@ -0,0 +1,60 @@
+import { NextResponse } from 'next/server';
+import {
+  getRepository,
+  getGateExecutions,
+  getLatestQARun,
+  getQARun,
+} from '@/lib/qa-gates/status-service';
+import { loadRepositoryConfig } from '@/lib/qa-gates/config-loader';
+import { formatJUnitReport, junitSuiteOf } from '@/lib/qa-gates/junit-report';
+import { buildRunResult, storedRunSummary } from '@/lib/qa-gates/run-report';
+import { withRunHooks } from '@/lib/qa-gates/run-hooks';
+
+/**
+ * GET /api/repositories/:id/qa-gates/junit[?runId=<id>]
+ * Latest QA run, or the run `runId`, as a JUnit XML report, one testcase
+ * per gate in config order
+ */
+export async function GET(
+  request: Request,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+
+    const repo = await getRepository(id);
+    if (!repo) {
+      return NextResponse.json(
+        { error: 'Repository not found' },
+        { status: 404 }
+      );
+    }
+
+    const runId = new URL(request.url).searchParams.get('runId');
+    const run = runId ? await getQARun(runId) : await getLatestQARun(id);
+    if (!run || run.repositoryId !== id) {
+      return NextResponse.json({ error: 'No QA run found' }, { status: 404 });
+    }
+
+    const gates = await getGateExecutions(run.id);
+    // Gate order comes from the current config
+    const config = await loadRepositoryConfig(repo.path);
+    const configGates = withRunHooks(config.qaGates, config);
+    const result = buildRunResult(
+      storedRunSummary(run),
+      gates,
+      configGates
+    );
+    const xml = formatJUnitReport(junitSuiteOf(repo.name, result));
+
+    return new NextResponse(xml, {
+      headers: { 'Content-Type': 'application/xml; charset=utf-8' },
+    });
+  } catch (error) {
+    console.error('Error building JUnit report:', error);
+    return NextResponse.json(
+      { error: 'Failed to build JUnit report' },
+      { status: 500 }
+    );
+  }
+}
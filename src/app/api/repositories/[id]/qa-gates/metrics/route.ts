@@ -0,0 +1,55 @@
+import { NextResponse } from 'next/server';
+import {
+  getRepository,
+  getGateExecutions,
+  getLatestQARun,
+} from '@/lib/qa-gates/status-service';
+import { loadRepositoryConfig } from '@/lib/qa-gates/config-loader';
+import { METRICS_CONTENT_TYPE, formatMetrics } from '@/lib/qa-gates/metrics';
+import { buildRunResult, storedRunSummary } from '@/lib/qa-gates/run-report';
+import { withRunHooks } from '@/lib/qa-gates/run-hooks';
+
+/**
+ * GET /api/repositories/:id/qa-gates/metrics
+ * Latest QA run as Prometheus text-format metrics
+ */
+export async function GET(
+  _request: Request,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+
+    const repo = await getRepository(id);
+    if (!repo) {
+      return NextResponse.json(
+        { error: 'Repository not found' },
+        { status: 404 }
+      );
+    }
+
+    const run = await getLatestQARun(id);
+    if (!run) {
+      return NextResponse.json({ error: 'No QA run found' }, { status: 404 });
+    }
+
+    const gates = await getGateExecutions(run.id);
+    const config = await loadRepositoryConfig(repo.path);
+    const configGates = withRunHooks(config.qaGates, config);
+    const result = buildRunResult(
+      storedRunSummary(run),
+      gates,
+      configGates
+    );
+
+    return new NextResponse(formatMetrics(result), {
+      headers: { 'Content-Type': METRICS_CONTENT_TYPE },
+    });
+  } catch (error) {
+    console.error('Error building QA metrics:', error);
+    return NextResponse.json(
+      { error: 'Failed to build QA metrics' },
+      { status: 500 }
+    );
+  }
+}
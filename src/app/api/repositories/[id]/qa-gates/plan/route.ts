@@ -0,0 +1,109 @@
+import { NextResponse } from 'next/server';
+import { getRepository } from '@/lib/qa-gates/status-service';
+import {
+  loadRunConfig,
+  parseRunConfigSource,
+  validateRunConfig,
+  type RunConfigSource,
+} from '@/lib/qa-gates/config-loader';
+import {
+  formatPlan,
+  planQAGates,
+  planToRunResult,
+} from '@/lib/qa-gates/plan';
+import { parseGateFilter } from '@/lib/qa-gates/gate-filter';
+
+async function respondWithPlan(
+  id: string,
+  searchParams: URLSearchParams,
+  source: RunConfigSource
+) {
+  const format = searchParams.get('format');
+
+  const repo = await getRepository(id);
+  if (!repo) {
+    return NextResponse.json(
+      { error: 'Repository not found' },
+      { status: 404 }
+    );
+  }
+
+  const problems = await validateRunConfig(repo.path, source);
+  if (problems.length > 0) {
+    return NextResponse.json(
+      { error: 'Invalid .forge.json', problems },
+      { status: 400 }
+    );
+  }
+
+  const config = await loadRunConfig(repo.path, source);
+  if (format === 'config') return NextResponse.json(config);
+
+  const filter = parseGateFilter(searchParams);
+  const plan = planQAGates(config, repo.path, filter);
+  const status = plan.errors.length > 0 ? 400 : 200;
+
+  if (format === 'text') {
+    return new NextResponse(formatPlan(plan), {
+      status,
+      headers: { 'Content-Type': 'text/plain; charset=utf-8' },
+    });
+  }
+  const body = format === 'report' ? planToRunResult(plan) : plan;
+  return NextResponse.json(body, { status });
+}
+
+function planningFailed(error: unknown) {
+  console.error('Error planning QA gates:', error);
+  return NextResponse.json(
+    { error: 'Failed to plan QA gates' },
+    { status: 500 }
+  );
+}
+
+/**
+ * GET /api/repositories/:id/qa-gates/plan
+ * Dry run: validate the config and describe what a run would execute,
+ * without running anything. `?format=text` for a readable plan,
+ * `?format=report` for a RunResult with every gate `planned`,
+ * `?format=config` for the effective config after `extends` is merged.
+ * Accepts the same gate filters as the run endpoint.
+ * Responds 400 when the config or a command placeholder is invalid.
+ */
+export async function GET(
+  request: Request,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+    const { searchParams } = new URL(request.url);
+    return await respondWithPlan(id, searchParams, {});
+  } catch (error) {
+    return planningFailed(error);
+  }
+}
+
+/**
+ * POST /api/repositories/:id/qa-gates/plan
+ * Dry run of the config a run would be given instead: the body takes
+ * `configPath` and `config` as POST /api/qa-runs does. Otherwise the same
+ * as GET.
+ */
+export async function POST(
+  request: Request,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+    const source = parseRunConfigSource(
+      await request.json().catch(() => null)
+    );
+    if (typeof source === 'string') {
+      return NextResponse.json({ error: source }, { status: 400 });
+    }
+    const { searchParams } = new URL(request.url);
+    return await respondWithPlan(id, searchParams, source);
+  } catch (error) {
+    return planningFailed(error);
+  }
+}
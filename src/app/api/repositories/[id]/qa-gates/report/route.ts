@@ -0,0 +1,49 @@
+import { NextResponse } from 'next/server';
+import {
+  getRepository,
+  getGateExecutions,
+  getLatestQARun,
+} from '@/lib/qa-gates/status-service';
+import { loadRepositoryConfig } from '@/lib/qa-gates/config-loader';
+import { buildRunResult, storedRunSummary } from '@/lib/qa-gates/run-report';
+import { withRunHooks } from '@/lib/qa-gates/run-hooks';
+
+/**
+ * GET /api/repositories/:id/qa-gates/report
+ * Latest QA run as a versioned RunResult document
+ */
+export async function GET(
+  _request: Request,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+
+    const repo = await getRepository(id);
+    if (!repo) {
+      return NextResponse.json(
+        { error: 'Repository not found' },
+        { status: 404 }
+      );
+    }
+
+    const run = await getLatestQARun(id);
+    if (!run) {
+      return NextResponse.json({ error: 'No QA run found' }, { status: 404 });
+    }
+
+    const gates = await getGateExecutions(run.id);
+    // Severities come from the current config
+    const config = await loadRepositoryConfig(repo.path);
+    const configGates = withRunHooks(config.qaGates, config);
+    return NextResponse.json(
+      buildRunResult(storedRunSummary(run), gates, configGates)
+    );
+  } catch (error) {
+    console.error('Error building QA run report:', error);
+    return NextResponse.json(
+      { error: 'Failed to build QA run report' },
+      { status: 500 }
+    );
+  }
+}
@@ -2,8 +2,20 @@ import { NextResponse } from 'next/server';
 import { db } from '@/db';
 import { repositories, qaRuns } from '@/db/schema';
 import { eq } from 'drizzle-orm';
-import { loadRepositoryConfig } from '@/lib/qa-gates/config-loader';
+import {
+  loadRepositoryConfig,
+  type QAGateConfig,
+} from '@/lib/qa-gates/config-loader';
 import { orchestrateQAGates } from '@/lib/qa-gates/run-orchestrator';
+import { enqueueRun, runQueueStatus } from '@/lib/qa-gates/run-queue';
+import { parseLogLevel, type LogLevel } from '@/lib/qa-gates/status-board';
+import {
+  filterGates,
+  parseGateFilter,
+  type GateFilter,
+} from '@/lib/qa-gates/gate-filter';
+import { getContainerPath } from '@/lib/qa-gates/command-executor';
+import { parseRunDirs, runDirsError } from '@/lib/qa-gates/run-dirs';
 
 async function getRepository(id: string) {
   return (
@@ -24,29 +36,100 @@ async function createQARun(repositoryId: string) {
   )[0];
 }
 
-async function startRun(id: string) {
+function selectGates(gates: QAGateConfig[], filter: GateFilter) {
+  try {
+    const selected = filterGates(gates, filter);
+    const filtered = [filter.only, filter.skip, filter.tags].some(
+      (names) => names && names.length > 0
+    );
+    if (filtered && selected.length === 0) {
+      return { error: 'No QA gates match the filter' };
+    }
+    return { selected };
+  } catch (error) {
+    return { error: error instanceof Error ? error.message : String(error) };
+  }
+}
+
+interface RunOptions {
+  since?: string;
+  strict?: boolean;
+  continueOnFailure?: boolean;
+  noCache?: boolean;
+  notify?: boolean;
+  artifactsDir?: string;
+  historyDir?: string;
+  logLevel?: LogLevel;
+  profile?: boolean;
+}
+
+async function startRun(id: string, filter: GateFilter, options: RunOptions) {
+  if (!runQueueStatus().accepting) {
+    return { error: 'Forge is shutting down', status: 503 } as const;
+  }
   const repo = await getRepository(id);
   if (!repo) return { error: 'Repository not found', status: 404 } as const;
 
+  const dirsError = runDirsError(options, getContainerPath(repo.path));
+  if (dirsError) return { error: dirsError, status: 400 } as const;
+
   const config = await loadRepositoryConfig(repo.path);
   if (!config || config.qaGates.length === 0) {
     return { error: 'No QA gates configured', status: 400 } as const;
   }
 
+  const gates = selectGates(config.qaGates, filter);
+  if ('error' in gates) return { error: gates.error, status: 400 } as const;
+
   const run = await createQARun(id);
   if (!run) return { error: 'Failed to create QA run', status: 500 } as const;
 
-  orchestrateQAGates({ runId: run.id, repoPath: repo.path, gates: config.qaGates });
+  enqueueRun(() =>
+    orchestrateQAGates({
+      runId: run.id,
+      repoPath: repo.path,
+      gates: gates.selected,
+      settings: config,
+      ...options,
+    })
+  ).catch((error) => console.error('Error running QA gates:', error));
   return { runId: run.id };
 }
 
+/**
+ * POST /api/repositories/:id/qa-gates/run[?only=a,b&skip=c&tag=lint]
+ * Start a run of the enabled gates, optionally filtered; `skipDeps=true`
+ * also skips gates whose dependencies were filtered out. `since=<ref>`
+ * sets the base for `changedFilesGlob` and `filesFromDiff`; `strict=true`
+ * treats warning gates as errors; `continue=true` keeps running later gates
+ * after a failure (the config's `continueOnFailure`); `noCache=true` runs
+ * `cacheInputs` gates despite a cache hit; `notify=false` skips the
+ * `notify` webhook; `artifactsDir=<dir>` copies gates' `artifacts` there;
+ * `historyDir=<dir>` appends the finished run to the history there (both
+ * directories inside the repository);
+ * `logLevel=quiet` (or `normal`, `verbose`, `debug`) overrides the config's
+ * `logLevel`; `profile=true` prints a timing profile after the summary.
+ * The run waits its turn when FORGE_MAX_CONCURRENT_RUNS runs are already
+ * going.
+ */
 export async function POST(
-  _request: Request,
+  request: Request,
   { params }: { params: Promise<{ id: string }> }
 ) {
   try {
     const { id } = await params;
-    const result = await startRun(id);
+    const { searchParams } = new URL(request.url);
+    const filter = parseGateFilter(searchParams);
+    const result = await startRun(id, filter, {
+      since: searchParams.get('since') || undefined,
+      strict: searchParams.get('strict') === 'true',
+      continueOnFailure: searchParams.get('continue') === 'true' || undefined,
+      noCache: searchParams.get('noCache') === 'true',
+      notify: searchParams.get('notify') !== 'false',
+      ...parseRunDirs(searchParams),
+      logLevel: parseLogLevel(searchParams.get('logLevel')),
+      profile: searchParams.get('profile') === 'true',
+    });
 
     if ('error' in result) {
       return NextResponse.json({ error: result.error }, { status: result.status });
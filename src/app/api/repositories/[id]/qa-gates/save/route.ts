@@ -2,14 +2,15 @@ import { NextResponse } from 'next/server';
 import { db } from '@/db';
 import { repositories } from '@/db/schema';
 import { eq } from 'drizzle-orm';
-import { writeFile } from 'fs/promises';
-import { join } from 'path';
+import { readFile, writeFile } from 'fs/promises';
+import { locateConfigFile } from '@/lib/qa-gates/config-loader';
 
 interface QAGateInput {
+  [key: string]: unknown;
   name: string;
   enabled?: boolean;
-  command: string;
-  timeout?: number;
+  command: string | string[];
+  timeout?: number | string;
   failOnError?: boolean;
   order?: number;
 }
@@ -26,15 +27,29 @@ async function getRepository(id: string) {
 
 function normalizeGate(gate: QAGateInput) {
   return {
+    // Keep fields the editor does not manage (e.g. env) intact
+    ...gate,
     name: gate.name,
     enabled: gate.enabled ?? true,
     command: gate.command,
-    timeout: gate.timeout ?? 60000,
     failOnError: gate.failOnError ?? true,
     order: gate.order ?? 1,
   };
 }
 
+/**
+ * Read the existing config so top-level fields the editor does not manage
+ * (e.g. env) survive a save
+ */
+async function readExistingConfig(path: string): Promise<object> {
+  try {
+    const parsed = JSON.parse(await readFile(path, 'utf-8'));
+    return parsed && typeof parsed === 'object' ? parsed : {};
+  } catch {
+    return {};
+  }
+}
+
 async function writeConfig(path: string, config: object) {
   await writeFile(path, JSON.stringify(config, null, 2), 'utf-8');
 }
@@ -47,12 +62,28 @@ async function saveGatesConfig(
   const repo = await getRepository(id);
   if (!repo) return { error: 'Repository not found', status: 404 };
 
+  const configPath = await locateConfigFile(repo.path);
+  if (!configPath.endsWith('.json')) {
+    return {
+      error: 'This repository uses a YAML config; edit it directly',
+      status: 409,
+    };
+  }
+
+  const existing = await readExistingConfig(configPath);
+  if ('extends' in existing) {
+    return {
+      error: 'This config extends a base config; edit it directly',
+      status: 409,
+    };
+  }
+
   const config = {
+    ...existing,
     version,
     maxRetries: maxRetries ?? 3,
     qaGates: qaGates.map(normalizeGate),
   };
-  const configPath = join(repo.path, '.forge.json');
   await writeConfig(configPath, config);
   return { repoName: repo.name, configPath };
 }
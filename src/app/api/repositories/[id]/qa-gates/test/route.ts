@@ -2,7 +2,11 @@ import { NextResponse } from 'next/server';
 import { db } from '@/db';
 import { repositories } from '@/db/schema';
 import { eq } from 'drizzle-orm';
-import { execAsync, type CommandError } from '@/lib/qa-gates/command-executor';
+import {
+  execAsync,
+  type CommandError,
+  type GateCommand,
+} from '@/lib/qa-gates/command-executor';
 
 async function getRepository(id: string) {
   return (
@@ -14,7 +18,16 @@ async function getRepository(id: string) {
   )[0];
 }
 
-async function executeGateCommand(command: string, cwd: string) {
+function isValidCommand(command: unknown): command is GateCommand {
+  if (typeof command === 'string') return command.length > 0;
+  return (
+    Array.isArray(command) &&
+    command.length > 0 &&
+    command.every((arg) => typeof arg === 'string')
+  );
+}
+
+async function executeGateCommand(command: GateCommand, cwd: string) {
   let exitCode = 0;
   let output = '';
   let error: string | null = null;
@@ -35,7 +48,7 @@ async function executeGateCommand(command: string, cwd: string) {
   return { exitCode, output, error };
 }
 
-async function runGateTest(id: string, command: string, gateName: string) {
+async function runGateTest(id: string, command: GateCommand, gateName: string) {
   const repo = await getRepository(id);
   if (!repo) return { notFound: true } as const;
   console.log(`[QA Gate Test] Testing gate "${gateName}" for repository ${repo.name}`);
@@ -58,9 +71,9 @@ export async function POST(
     const body = await request.json();
     const { command, gateName } = body;
 
-    if (!command || typeof command !== 'string') {
+    if (!isValidCommand(command)) {
       return NextResponse.json(
-        { error: 'Command is required and must be a string' },
+        { error: 'Command is required and must be a string or argv array' },
         { status: 400 }
       );
     }
@@ -0,0 +1,77 @@
+import { NextResponse } from 'next/server';
+import { validationExitCode } from '@/lib/qa-gates/exit-codes';
+import { getRepository } from '@/lib/qa-gates/status-service';
+import {
+  parseRunConfigSource,
+  validateRunConfig,
+  type RunConfigSource,
+} from '@/lib/qa-gates/config-loader';
+
+async function validate(id: string, source: RunConfigSource) {
+  const repo = await getRepository(id);
+  if (!repo) {
+    return NextResponse.json(
+      { error: 'Repository not found' },
+      { status: 404 }
+    );
+  }
+
+  const problems = await validateRunConfig(repo.path, source);
+  return NextResponse.json(
+    {
+      valid: problems.length === 0,
+      problems,
+      exitCode: validationExitCode(problems),
+    },
+    { status: problems.length === 0 ? 200 : 400 }
+  );
+}
+
+function validationFailed(error: unknown) {
+  console.error('Error validating QA gate config:', error);
+  return NextResponse.json(
+    { error: 'Failed to validate QA gate config' },
+    { status: 500 }
+  );
+}
+
+/**
+ * GET /api/repositories/:id/qa-gates/validate
+ * Check .forge.json without running gates. Lists every problem found;
+ * responds 400, with `exitCode` 2, when there is at least one.
+ */
+export async function GET(
+  _request: Request,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+    return await validate(id, {});
+  } catch (error) {
+    return validationFailed(error);
+  }
+}
+
+/**
+ * POST /api/repositories/:id/qa-gates/validate
+ * Check the config a run would be given instead: the body takes
+ * `configPath` and `config` as POST /api/qa-runs does. Problems name the
+ * file, or `<stdin>` for the inline config, they come from.
+ */
+export async function POST(
+  request: Request,
+  { params }: { params: Promise<{ id: string }> }
+) {
+  try {
+    const { id } = await params;
+    const source = parseRunConfigSource(
+      await request.json().catch(() => null)
+    );
+    if (typeof source === 'string') {
+      return NextResponse.json({ error: source }, { status: 400 });
+    }
+    return await validate(id, source);
+  } catch (error) {
+    return validationFailed(error);
+  }
+}
@@ -0,0 +1,83 @@
+import { NextResponse } from 'next/server';
+import { getRepository } from '@/lib/qa-gates/status-service';
+import { getContainerPath } from '@/lib/qa-gates/command-executor';
+import { parseGateFilter } from '@/lib/qa-gates/gate-filter';
+import { parseRunDirs, runDirsError } from '@/lib/qa-gates/run-dirs';
+import {
+  getWatchStatus,
+  startWatching,
+  stopWatching,
+} from '@/lib/qa-gates/watcher';
+
+type RouteContext = { params: Promise<{ id: string }> };
+
+/**
+ * GET /api/repositories/:id/qa-gates/watch
+ * Whether the repository is being watched, and how many cycles have run
+ */
+export async function GET(_request: Request, { params }: RouteContext) {
+  const { id } = await params;
+  return NextResponse.json({ watching: getWatchStatus(id) });
+}
+
+/**
+ * POST /api/repositories/:id/qa-gates/watch[?only=Lint,Tests]
+ * Start watching the repository: gates run now and again after every
+ * change. Takes the run endpoint's gate filters, `strict`, `notify`,
+ * `artifactsDir` and `historyDir`. Restarts the watcher if one is already
+ * running.
+ */
+export async function POST(request: Request, { params }: RouteContext) {
+  try {
+    const { id } = await params;
+
+    const repo = await getRepository(id);
+    if (!repo) {
+      return NextResponse.json(
+        { error: 'Repository not found' },
+        { status: 404 }
+      );
+    }
+
+    const { searchParams } = new URL(request.url);
+    const dirs = parseRunDirs(searchParams);
+    const dirsError = runDirsError(dirs, getContainerPath(repo.path));
+    if (dirsError) {
+      return NextResponse.json({ error: dirsError }, { status: 400 });
+    }
+
+    try {
+      const status = await startWatching({
+        repositoryId: id,
+        repoPath: repo.path,
+        filter: parseGateFilter(searchParams),
+        strict: searchParams.get('strict') === 'true',
+        notify: searchParams.get('notify') !== 'false',
+        ...dirs,
+      });
+      return NextResponse.json({ watching: status });
+    } catch (error) {
+      // Invalid config or an unknown gate in the filter
+      const message = error instanceof Error ? error.message : String(error);
+      return NextResponse.json({ error: message }, { status: 400 });
+    }
+  } catch (error) {
+    console.error('Error starting QA gate watcher:', error);
+    return NextResponse.json(
+      { error: 'Failed to start QA gate watcher' },
+      { status: 500 }
+    );
+  }
+}
+
+/**
+ * DELETE /api/repositories/:id/qa-gates/watch
+ * Stop watching; a cycle already running finishes first
+ */
+export async function DELETE(_request: Request, { params }: RouteContext) {
+  const { id } = await params;
+  if (!stopWatching(id)) {
+    return NextResponse.json({ error: 'Not watching' }, { status: 404 });
+  }
+  return NextResponse.json({ watching: null });
+}
@@ -119,11 +119,26 @@ async function init() {
       error TEXT,
       exit_code INTEGER,
       duration INTEGER,
+      timeout INTEGER,
       started_at TIMESTAMP NOT NULL,
       completed_at TIMESTAMP,
-      "order" INTEGER NOT NULL
+      "order" INTEGER NOT NULL,
+      previous_attempts JSONB,
+      artifacts JSONB
     )
   `;
+  await sql`
+    ALTER TABLE qa_gate_executions
+    ADD COLUMN IF NOT EXISTS previous_attempts JSONB
+  `;
+  await sql`
+    ALTER TABLE qa_gate_executions
+    ADD COLUMN IF NOT EXISTS artifacts JSONB
+  `;
+  await sql`
+    ALTER TABLE qa_gate_executions
+    ADD COLUMN IF NOT EXISTS timeout INTEGER
+  `;
 
   await sql`
     CREATE TABLE IF NOT EXISTS plans (
@@ -0,0 +1 @@
+ALTER TABLE qa_gate_executions ADD `previous_attempts` text;
\ No newline at end of file
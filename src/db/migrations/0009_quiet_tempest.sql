@@ -0,0 +1 @@
+ALTER TABLE qa_gate_executions ADD `timeout` integer;
\ No newline at end of file
@@ -1,4 +1,4 @@
-import { pgTable, text, integer, timestamp } from 'drizzle-orm/pg-core';
+import { pgTable, text, integer, jsonb, timestamp } from 'drizzle-orm/pg-core';
 import { relations } from 'drizzle-orm';
 import { repositories } from './repositories';
 
@@ -8,7 +8,39 @@ export type QAGateExecutionStatus =
   | 'running'
   | 'passed'
   | 'failed'
-  | 'skipped';
+  | 'skipped'
+  // Passed by replaying a cached result
+  | 'cached'
+  // Failed because its timeout stopped it
+  | 'timedout'
+  // Stopped while running, as the run was cancelled or failed
+  | 'cancelled';
+
+/**
+ * One failed attempt of a retried gate; the execution row itself records
+ * the final attempt
+ */
+export interface GateAttempt {
+  attempt: number;
+  exitCode: number | null;
+  durationMs: number;
+  stdout: string | null;
+  stderr: string | null;
+  /** Set when the attempt was stopped by the gate's timeout */
+  timedOut?: boolean;
+  /** Set on retried attempts: the `retryBackoff` delay waited afterwards */
+  backoffMs?: number;
+}
+
+/**
+ * A file a gate declared in `artifacts`, as found after it ran
+ */
+export interface GateArtifact {
+  /** Relative to the repository root, `/`-separated */
+  path: string;
+  /** Bytes */
+  size: number;
+}
 
 export const qaRuns = pgTable('qa_runs', {
   id: text('id')
@@ -39,11 +71,17 @@ export const qaGateExecutions = pgTable('qa_gate_executions', {
   error: text('error'),
   exitCode: integer('exit_code'),
   duration: integer('duration'),
+  // The gate's timeout in milliseconds; null when it has none
+  timeout: integer('timeout'),
   startedAt: timestamp('started_at', { mode: 'date' })
     .notNull()
     .$defaultFn(() => new Date()),
   completedAt: timestamp('completed_at', { mode: 'date' }),
   order: integer('order').notNull(),
+  // null unless the gate was retried
+  previousAttempts: jsonb('previous_attempts').$type<GateAttempt[]>(),
+  // null unless the gate declares `artifacts`
+  artifacts: jsonb('artifacts').$type<GateArtifact[]>(),
 });
 
 export const qaRunsRelations = relations(qaRuns, ({ one, many }) => ({
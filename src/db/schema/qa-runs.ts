@@ -8,7 +8,39 @@ export type QAGateExecutionStatus =
   | 'running'
   | 'passed'
   | 'failed'
-  | 'skipped';
+  | 'skipped'
+  // Passed by replaying a cached result
+  | 'cached'
+  // Failed because its timeout stopped it
+  | 'timedout'
+  // Stopped while running, as the run was cancelled or failed
+  | 'cancelled';
+
+/**
+ * One failed attempt of a retried gate; the execution row itself records
+ * the final attempt
+ */
+export interface GateAttempt {
+  attempt: number;
+  exitCode: number | null;
+  durationMs: number;
+  stdout: string | null;
+  stderr: string | null;
+  /** Set when the attempt was stopped by the gate's timeout */
+  timedOut?: boolean;
+  /** Set on retried attempts: the `retryBackoff` delay waited afterwards */
+  backoffMs?: number;
+}
+
+/**
+ * A file a gate declared in `artifacts`, as found after it ran
+ */
+export interface GateArtifact {
+  /** Relative to the repository root, `/`-separated */
+  path: string;
+  /** Bytes */
+  size: number;
+}
 
 export const qaRuns = sqliteTable('qa_runs', {
   id: text('id')
@@ -39,11 +71,19 @@ export const qaGateExecutions = sqliteTable('qa_gate_executions', {
   error: text('error'),
   exitCode: integer('exit_code'),
   duration: integer('duration'), // milliseconds
+  // The gate's timeout in milliseconds; null when it has none
+  timeout: integer('timeout'),
   startedAt: integer('started_at', { mode: 'timestamp' })
     .notNull()
     .$defaultFn(() => new Date()),
   completedAt: integer('completed_at', { mode: 'timestamp' }),
   order: integer('order').notNull(),
+  // null unless the gate was retried
+  previousAttempts: text('previous_attempts', { mode: 'json' }).$type<
+    GateAttempt[]
+  >(),
+  // null unless the gate declares `artifacts`
+  artifacts: text('artifacts', { mode: 'json' }).$type<GateArtifact[]>(),
 });
 
 export const qaRunsRelations = relations(qaRuns, ({ one, many }) => ({
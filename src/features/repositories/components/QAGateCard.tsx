@@ -48,10 +48,24 @@ function ExecutionStatusBadge({ status }: { status: string }) {
       className:
         'h-6 border border-red-500/30 bg-red-500/15 px-3 text-xs font-semibold text-red-700 dark:text-red-400',
     },
+    timedout: {
+      label: 'Timed out',
+      className:
+        'h-6 border border-amber-500/30 bg-amber-500/15 px-3 text-xs font-semibold text-amber-700 dark:text-amber-400',
+    },
+    cancelled: {
+      label: 'Cancelled',
+      className: 'h-6 px-2.5 text-xs font-semibold',
+    },
     skipped: {
       label: 'Skipped',
       className: 'h-6 px-2.5 text-xs font-semibold',
     },
+    cached: {
+      label: 'Cached',
+      className:
+        'h-6 border border-green-500/30 bg-green-500/10 px-3 text-xs font-semibold text-green-700 dark:text-green-400',
+    },
   };
 
   const info = config[status];
@@ -59,7 +73,9 @@ function ExecutionStatusBadge({ status }: { status: string }) {
 
   return (
     <Badge
-      variant={status === 'skipped' ? 'outline' : undefined}
+      variant={
+        status === 'skipped' || status === 'cancelled' ? 'outline' : undefined
+      }
       className={info.className}
     >
       {info.label}
@@ -116,6 +132,9 @@ export function QAGateCard({
   const [expanded, setExpanded] = useState(false);
   const [confirmDelete, setConfirmDelete] = useState(false);
   const hasOutput = execution && (execution.output || execution.error);
+  const commandText = Array.isArray(gate.command)
+    ? gate.command.join(' ')
+    : gate.command;
 
   return (
     <Card
@@ -179,10 +198,10 @@ export function QAGateCard({
             </div>
             <code
               className="block truncate text-xs text-muted-foreground"
-              title={gate.command}
+              title={commandText}
             >
               <Terminal className="mr-1 inline h-3 w-3 opacity-50" />
-              {gate.command}
+              {commandText}
             </code>
           </div>
 
@@ -190,7 +209,9 @@ export function QAGateCard({
           <div className="flex items-center gap-1.5 rounded-md bg-muted/50 px-2.5 py-1 text-xs text-muted-foreground">
             <Clock className="h-3 w-3" />
             <span className="font-medium">
-              {(gate.timeout / 1000).toFixed(0)}s
+              {gate.timeout
+                ? `${(gate.timeout / 1000).toFixed(0)}s`
+                : 'No timeout'}
             </span>
           </div>
 
@@ -15,7 +15,7 @@ import { Play, CheckCircle2, XCircle, Loader2, Clock } from 'lucide-react';
 interface TestGateButtonProps {
   repositoryId: string;
   gateName: string;
-  command: string;
+  command: string | string[];
 }
 
 interface TestResult {
@@ -125,7 +125,7 @@ export function TestGateButton({
               )}
             </DialogTitle>
             <DialogDescription className="rounded-md bg-muted/50 p-2 font-mono text-xs">
-              {command}
+              {Array.isArray(command) ? command.join(' ') : command}
             </DialogDescription>
           </DialogHeader>
           <div className="space-y-4">
@@ -62,6 +62,12 @@ describe('QAGateCard', () => {
       expect(screen.getByText('60s')).toBeInTheDocument();
     });
 
+    it('renders "No timeout" when the gate has no timeout', () => {
+      const gate = { ...mockGate, timeout: undefined };
+      render(<QAGateCard {...defaultProps} gate={gate} />);
+      expect(screen.getByText('No timeout')).toBeInTheDocument();
+    });
+
     it('displays order number from gate.order if provided', () => {
       render(<QAGateCard {...defaultProps} index={5} />);
       expect(screen.getByText('1')).toBeInTheDocument();
@@ -1,8 +1,9 @@
 export interface QAGate {
   name: string;
   enabled: boolean;
-  command: string;
-  timeout: number;
+  command: string | string[];
+  // Milliseconds; absent means no timeout
+  timeout?: number;
   failOnError: boolean;
   order?: number;
 }
@@ -32,7 +33,13 @@ export type QAGateExecutionStatus =
   | 'running'
   | 'passed'
   | 'failed'
-  | 'skipped';
+  | 'skipped'
+  // Passed by replaying a cached result
+  | 'cached'
+  // Failed because its timeout stopped it
+  | 'timedout'
+  // Stopped while running, as the run was cancelled or failed
+  | 'cancelled';
 
 export interface QAGateExecutionResult {
   id: string;
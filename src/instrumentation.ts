@@ -15,6 +15,11 @@ export async function register() {
     // // Clean up any stuck tasks/plans from server restarts
     // await planExecutor.cleanupStuckExecutions();
 
+    // SIGTERM lets running QA runs finish before the server exits
+    const { drainOnShutdown } = await import('@/lib/qa-gates/process-group');
+    const { drainRuns } = await import('@/lib/qa-gates/run-queue');
+    drainOnShutdown(drainRuns);
+
     console.log('[Instrumentation] Startup complete');
   }
 }
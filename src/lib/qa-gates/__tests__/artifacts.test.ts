@@ -0,0 +1,86 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import {
+  copyArtifacts,
+  findArtifacts,
+  findMissingArtifacts,
+  gateArtifactsDir,
+} from '../artifacts';
+
+describe('Gate artifacts', () => {
+  let root: string;
+
+  const write = (file: string, content: string) => {
+    fs.mkdirSync(path.dirname(path.join(root, file)), { recursive: true });
+    fs.writeFileSync(path.join(root, file), content);
+  };
+
+  beforeEach(() => {
+    root = fs.mkdtempSync(path.join(os.tmpdir(), 'forge-artifacts-'));
+    write('coverage.out', 'mode: set\n');
+    write('reports/lint.sarif', '{}');
+    write('reports/old/lint.sarif', '{ }');
+    write('src/index.ts', 'export {};');
+  });
+
+  afterEach(() => {
+    fs.rmSync(root, { recursive: true, force: true });
+  });
+
+  describe('findArtifacts', () => {
+    it('should list matching files with their sizes', async () => {
+      const gate = { artifacts: ['coverage.out', 'reports/*.sarif'] };
+
+      expect(await findArtifacts(gate, root)).toEqual([
+        { path: 'coverage.out', size: 10 },
+        { path: 'reports/lint.sarif', size: 2 },
+      ]);
+    });
+
+    it('should leave out files under the excluded directory', async () => {
+      const gate = { artifacts: '*.sarif' };
+
+      const found = await findArtifacts(gate, root, 'reports/old');
+
+      expect(found.map((artifact) => artifact.path)).toEqual([
+        'reports/lint.sarif',
+      ]);
+    });
+
+    it('should find nothing in a missing directory', async () => {
+      const gate = { artifacts: '*' };
+
+      expect(await findArtifacts(gate, path.join(root, 'nope'))).toEqual([]);
+    });
+  });
+
+  describe('findMissingArtifacts', () => {
+    it('should return the globs that matched nothing', () => {
+      const gate = { artifacts: ['coverage.out', 'junit.xml'] };
+      const found = [{ path: 'coverage.out', size: 10 }];
+
+      expect(findMissingArtifacts(gate, found)).toEqual(['junit.xml']);
+    });
+  });
+
+  describe('copyArtifacts', () => {
+    it('should copy artifacts keeping their paths and drop stale ones', async () => {
+      const target = gateArtifactsDir(path.join(root, 'out'), 'Lint: go/vet');
+      write('out/Lint_go_vet/stale.txt', 'old');
+
+      await copyArtifacts(
+        [{ path: 'reports/lint.sarif', size: 2 }],
+        root,
+        target
+      );
+
+      expect(target).toBe(path.join(root, 'out', 'Lint_go_vet'));
+      expect(fs.readdirSync(target)).toEqual(['reports']);
+      expect(
+        fs.readFileSync(path.join(target, 'reports/lint.sarif'), 'utf-8')
+      ).toBe('{}');
+    });
+  });
+});
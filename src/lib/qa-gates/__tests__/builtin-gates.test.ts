@@ -0,0 +1,127 @@
+import { describe, it, expect, afterEach } from 'vitest';
+import {
+  isGateRegistered,
+  registerGate,
+  runBuiltinGate,
+  unregisterGate,
+  type BuiltinGateContext,
+} from '../builtin-gates';
+import type { CommandError } from '../command-executor';
+import { execGateCommand } from '../output-rules';
+import { createRedactor } from '../redaction';
+
+const options = { cwd: '/repo', env: { FOO: 'bar' } };
+
+describe('builtin gates', () => {
+  afterEach(() => {
+    unregisterGate('check');
+  });
+
+  it('should pass the options and context to the registered gate', async () => {
+    let seen: { options: unknown; context?: BuiltinGateContext } = {
+      options: null,
+    };
+    registerGate('check', (gateOptions) => ({
+      async run(context) {
+        seen = { options: gateOptions, context };
+        return { passed: true, stdout: 'all good\n' };
+      },
+    }));
+
+    const result = await runBuiltinGate(
+      { name: 'AST scan', builtin: 'check', options: { pattern: 'TODO' } },
+      options
+    );
+
+    expect(result).toEqual({ stdout: 'all good\n', stderr: '' });
+    expect(seen.options).toEqual({ pattern: 'TODO' });
+    expect(seen.context).toMatchObject({
+      gateName: 'AST scan',
+      cwd: '/repo',
+      env: { FOO: 'bar' },
+    });
+    expect(isGateRegistered('check')).toBe(true);
+  });
+
+  it('should reject a failed check like a failed command', async () => {
+    registerGate('check', () => ({
+      run: async () => ({ passed: false, stdout: 'a.go:3 TODO\n' }),
+    }));
+
+    const error = (await runBuiltinGate(
+      { name: 'AST scan', builtin: 'check' },
+      options
+    ).catch((e) => e)) as CommandError;
+
+    expect(error.message).toBe('Builtin gate "check" failed');
+    expect(error.code).toBe(1);
+    expect(error.stdout).toBe('a.go:3 TODO\n');
+  });
+
+  it('should reject unregistered names and factory errors', async () => {
+    await expect(
+      runBuiltinGate({ name: 'X', builtin: 'missing' }, options)
+    ).rejects.toThrow('Builtin gate "missing" is not registered');
+
+    registerGate('check', () => {
+      throw new Error('pattern is required');
+    });
+    await expect(
+      runBuiltinGate({ name: 'X', builtin: 'check' }, options)
+    ).rejects.toThrow('pattern is required');
+  });
+
+  it('should time out and abort the gate signal', async () => {
+    let signal: AbortSignal | undefined;
+    registerGate('check', () => ({
+      run: (context) => {
+        signal = context.signal;
+        return new Promise(() => {});
+      },
+    }));
+
+    await expect(
+      runBuiltinGate(
+        { name: 'X', builtin: 'check' },
+        { ...options, timeout: 20 }
+      )
+    ).rejects.toMatchObject({
+      message: 'Gate timed out after 20ms',
+      code: null,
+      timedOut: true,
+    });
+    expect(signal?.aborted).toBe(true);
+  });
+
+  it('should redact and cap output like command output', async () => {
+    registerGate('check', () => ({
+      run: async () => ({ passed: true, stdout: 'token s3cr3t\nmore\n' }),
+    }));
+
+    const result = await runBuiltinGate(
+      { name: 'X', builtin: 'check' },
+      { ...options, redact: createRedactor([], ['s3cr3t']), maxOutputBytes: 5 }
+    );
+
+    expect(result.stdout).toBe('[... 10 bytes truncated ...]\nmore\n');
+  });
+
+  it('should go through output rules when run as a gate', async () => {
+    registerGate('check', () => ({
+      run: async () => ({ passed: false, stdout: 'no files to scan' }),
+    }));
+
+    await expect(
+      execGateCommand(
+        {
+          type: 'builtin',
+          builtin: 'check',
+          name: 'X',
+          passIfOutputMatches: 'no files',
+        },
+        'builtin:check',
+        options
+      )
+    ).resolves.toEqual({ stdout: 'no files to scan', stderr: '', exitCode: 1 });
+  });
+});
@@ -0,0 +1,157 @@
+import { describe, it, expect, vi, beforeEach } from 'vitest';
+import { execAsync } from '../command-executor';
+import {
+  listChangedFiles,
+  listTrackedFiles,
+  unchangedSkipReason,
+  usesChangedFiles,
+} from '../changed-files';
+
+vi.mock('../command-executor', () => ({
+  execAsync: vi.fn(),
+  getContainerPath: vi.fn((p: string) => p),
+}));
+
+type GitResponder = (args: string[]) => string;
+
+function mockGit(respond: GitResponder) {
+  vi.mocked(execAsync).mockImplementation(async (command) => {
+    const args = (command as string[]).slice(1);
+    return { stdout: respond(args), stderr: '' };
+  });
+}
+
+describe('listChangedFiles', () => {
+  beforeEach(() => {
+    vi.mocked(execAsync).mockReset();
+    vi.spyOn(console, 'warn').mockImplementation(() => {});
+  });
+
+  it('should diff against the merge-base with the default branch', async () => {
+    mockGit((args) => {
+      if (args[0] === 'symbolic-ref') return 'origin/main\n';
+      if (args[0] === 'merge-base') return 'abc123\n';
+      if (args[0] === 'diff') return 'pkg/server.go\nREADME.md\n';
+      if (args[0] === 'ls-files') return 'new.go\n';
+      return 'true\n';
+    });
+
+    const files = await listChangedFiles('/repo');
+    const calls = vi.mocked(execAsync).mock.calls.map(([cmd]) => cmd);
+
+    expect(files).toEqual(['pkg/server.go', 'README.md', 'new.go']);
+    expect(calls).toContainEqual(['git', 'merge-base', 'HEAD', 'origin/main']);
+    expect(calls).toContainEqual([
+      'git',
+      'diff',
+      '--name-only',
+      '--relative',
+      'abc123',
+      '--',
+    ]);
+  });
+
+  it('should use the given base ref', async () => {
+    mockGit((args) => (args[0] === 'diff' ? 'a.go\n' : ''));
+
+    expect(await listChangedFiles('/repo', 'v1.2.0')).toEqual(['a.go']);
+    const calls = vi.mocked(execAsync).mock.calls.map(([cmd]) => cmd);
+    expect(calls).toContainEqual([
+      'git',
+      'diff',
+      '--name-only',
+      '--relative',
+      'v1.2.0',
+      '--',
+    ]);
+  });
+
+  it('should reject refs that look like options', async () => {
+    mockGit(() => 'true');
+
+    await expect(listChangedFiles('/repo', '--output=x')).rejects.toThrow(
+      'Invalid base ref "--output=x"'
+    );
+  });
+
+  it('should return null and warn once outside a git repository', async () => {
+    vi.mocked(execAsync).mockRejectedValue(new Error('not a git repository'));
+
+    expect(await listChangedFiles('/plain')).toBeNull();
+    expect(await listChangedFiles('/plain')).toBeNull();
+    expect(console.warn).toHaveBeenCalledTimes(1);
+  });
+});
+
+describe('listTrackedFiles', () => {
+  beforeEach(() => {
+    vi.mocked(execAsync).mockReset();
+  });
+
+  it('should list tracked and new files, less deleted ones', async () => {
+    mockGit((args) => {
+      if (args.includes('--deleted')) return 'gone.go\0';
+      if (args[0] === 'ls-files') return 'main.go\0gone.go\0new file.go\0';
+      return 'true\n';
+    });
+
+    const files = await listTrackedFiles('/repo');
+    const calls = vi.mocked(execAsync).mock.calls.map(([cmd]) => cmd);
+
+    expect(files).toEqual(['main.go', 'new file.go']);
+    expect(calls).toContainEqual([
+      'git',
+      'ls-files',
+      '-z',
+      '--cached',
+      '--others',
+      '--exclude-standard',
+    ]);
+  });
+
+  it('should return null outside a git repository', async () => {
+    vi.mocked(execAsync).mockRejectedValue(new Error('not a git repository'));
+
+    expect(await listTrackedFiles('/plain')).toBeNull();
+  });
+});
+
+describe('unchangedSkipReason', () => {
+  const changed = ['cmd/main.go', 'docs/guide.md'];
+
+  it('should run gates without a glob or outside git', () => {
+    expect(unchangedSkipReason({}, [])).toBeNull();
+    expect(unchangedSkipReason({ changedFilesGlob: '*.go' }, null)).toBeNull();
+  });
+
+  it('should run the gate when a matching file changed', () => {
+    expect(
+      unchangedSkipReason({ changedFilesGlob: '**/*.go' }, changed)
+    ).toBeNull();
+    expect(
+      unchangedSkipReason({ changedFilesGlob: '*.md' }, changed)
+    ).toBeNull();
+  });
+
+  it('should give a reason when nothing matching changed', () => {
+    expect(
+      unchangedSkipReason({ changedFilesGlob: ['web/**', '*.ts'] }, changed)
+    ).toBe('No changes matching web/**, *.ts');
+  });
+});
+
+describe('usesChangedFiles', () => {
+  it('should detect gates with a changedFilesGlob', () => {
+    const gate = {
+      name: 'A',
+      enabled: true,
+      command: 'true',
+      failOnError: true,
+    };
+
+    expect(usesChangedFiles([gate])).toBe(false);
+    expect(usesChangedFiles([{ ...gate, changedFilesGlob: '*.go' }])).toBe(
+      true
+    );
+  });
+});
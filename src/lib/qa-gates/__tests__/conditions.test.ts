@@ -0,0 +1,64 @@
+import { describe, it, expect } from 'vitest';
+import {
+  conditionHolds,
+  conditionSkipReason,
+  parseCondition,
+} from '../conditions';
+
+describe('conditions', () => {
+  const env = { CI: 'true', BRANCH: 'main', EMPTY: '' };
+
+  describe('parseCondition', () => {
+    it('should compare variables with strings', () => {
+      expect(parseCondition("env.CI == 'true'")(env)).toBe(true);
+      expect(parseCondition('env.BRANCH != "main"')(env)).toBe(false);
+      expect(parseCondition("env.MISSING == ''")(env)).toBe(true);
+    });
+
+    it('should combine comparisons with !, && and ||', () => {
+      const holds = (source: string) => parseCondition(source)(env);
+
+      expect(holds("env.CI == 'true' && env.BRANCH == 'dev'")).toBe(false);
+      expect(holds("env.CI == 'false' || env.BRANCH == 'main'")).toBe(true);
+      expect(holds("!(env.BRANCH == 'main')")).toBe(false);
+      // && binds tighter than ||
+      expect(holds("env.CI == 'x' && env.CI == 'y' || env.CI")).toBe(true);
+      expect(holds("env.CI == 'x' && (env.CI == 'y' || env.CI)")).toBe(false);
+    });
+
+    it('should hold for a bare variable that is set and not empty', () => {
+      expect(parseCondition('env.CI')(env)).toBe(true);
+      expect(parseCondition('env.EMPTY')(env)).toBe(false);
+      expect(parseCondition('!env.MISSING')(env)).toBe(true);
+    });
+
+    it('should reject malformed expressions', () => {
+      expect(() => parseCondition("env.CI = 'true'")).toThrow(/Unexpected/);
+      expect(() => parseCondition("env.CI == 'true' &&")).toThrow(
+        'Condition ends unexpectedly'
+      );
+      expect(() => parseCondition("(env.CI == 'true'")).toThrow(/Missing \)/);
+      expect(() => parseCondition("'true'")).toThrow(/Compare 'true'/);
+      expect(() => parseCondition("CI == 'true'")).toThrow(/Unexpected/);
+    });
+  });
+
+  describe('conditionHolds', () => {
+    it('should hold without a condition', () => {
+      expect(conditionHolds(undefined, env)).toBe(true);
+    });
+
+    it('should check envSet against set, non-empty variables', () => {
+      expect(conditionHolds({ envSet: 'CI' }, env)).toBe(true);
+      expect(conditionHolds({ envSet: 'EMPTY' }, env)).toBe(false);
+      expect(conditionHolds({ envSet: 'MISSING' }, env)).toBe(false);
+    });
+  });
+
+  it('should give a false condition as the skip reason', () => {
+    expect(conditionSkipReason({ enabledWhen: 'env.MISSING' }, env)).toBe(
+      'disabled by condition'
+    );
+    expect(conditionSkipReason({ enabledWhen: 'env.CI' }, env)).toBeNull();
+  });
+});
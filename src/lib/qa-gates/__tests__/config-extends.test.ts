@@ -0,0 +1,101 @@
+import { describe, it, expect, vi } from 'vitest';
+import { mergeConfigs, resolveExtends } from '../config-extends';
+
+describe('mergeConfigs', () => {
+  const base = {
+    maxRetries: 3,
+    env: { CI: 'true', GOFLAGS: '-mod=mod' },
+    qaGates: [
+      { name: 'gofmt', command: 'gofmt -l .' },
+      { name: 'vet', command: 'go vet ./...', timeout: '1m' },
+      { name: 'test', command: 'go test ./...' },
+    ],
+  };
+
+  it('should override gates by name and append new ones', () => {
+    const merged = mergeConfigs(base, {
+      qaGates: [
+        { name: 'vet', timeout: '5m' },
+        { name: 'test', enabled: false },
+        { name: 'lint', command: 'golangci-lint run' },
+      ],
+    });
+
+    expect(merged.qaGates).toEqual([
+      { name: 'gofmt', command: 'gofmt -l .' },
+      { name: 'vet', command: 'go vet ./...', timeout: '5m' },
+      { name: 'test', command: 'go test ./...', enabled: false },
+      { name: 'lint', command: 'golangci-lint run' },
+    ]);
+  });
+
+  it('should let child scalars win and merge env by key', () => {
+    const merged = mergeConfigs(base, {
+      extends: '../base.json',
+      maxRetries: 1,
+      env: { GOFLAGS: '-race' },
+    });
+
+    expect(merged.maxRetries).toBe(1);
+    expect(merged.env).toEqual({ CI: 'true', GOFLAGS: '-race' });
+    expect(merged.qaGates).toEqual(base.qaGates);
+    expect(merged).not.toHaveProperty('extends');
+  });
+});
+
+describe('resolveExtends', () => {
+  it('should return configs without extends unchanged', async () => {
+    const raw = { qaGates: [] };
+    const read = vi.fn();
+
+    expect(await resolveExtends(raw, '/repo/.forge.json', read)).toBe(raw);
+    expect(read).not.toHaveBeenCalled();
+  });
+
+  it('should resolve chains relative to each file', async () => {
+    const files: Record<string, unknown> = {
+      '/shared/go.json': {
+        extends: './base.json',
+        qaGates: [{ name: 'test', command: 'go test ./...' }],
+      },
+      '/shared/base.json': { maxRetries: 5 },
+    };
+    const read = vi.fn(async (p: string) => files[p]);
+
+    const merged = await resolveExtends(
+      { extends: '../shared/go.json', maxRetries: 2 },
+      '/repo/.forge.json',
+      read
+    );
+
+    expect(read).toHaveBeenCalledWith('/shared/base.json');
+    expect(merged).toEqual({
+      maxRetries: 2,
+      qaGates: [{ name: 'test', command: 'go test ./...' }],
+    });
+  });
+
+  it('should reject circular chains', async () => {
+    const files: Record<string, unknown> = {
+      '/repo/base.json': { extends: './.forge.json' },
+    };
+
+    await expect(
+      resolveExtends(
+        { extends: './base.json' },
+        '/repo/.forge.json',
+        async (p) => files[p]
+      )
+    ).rejects.toThrow('Circular extends: .forge.json -> base.json -> .forge.json');
+  });
+
+  it('should name the missing base config', async () => {
+    await expect(
+      resolveExtends({ extends: 'missing.json' }, '/repo/.forge.json', () =>
+        Promise.reject(new Error('ENOENT'))
+      )
+    ).rejects.toThrow(
+      'Cannot load base config /repo/missing.json extended by /repo/.forge.json: ENOENT'
+    );
+  });
+});
@@ -0,0 +1,173 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import {
+  formatConfigText,
+  formatRepositoryConfig,
+  normalizeConfig,
+} from '../config-format';
+
+describe('normalizeConfig', () => {
+  it('should sort gates by order, then name, with unordered gates last', () => {
+    const config = normalizeConfig({
+      qaGates: [
+        { name: 'Docs', command: 'make docs' },
+        { name: 'Types', command: 'tsc', order: 1 },
+        { name: 'Lint', command: 'eslint .', order: 2 },
+        { name: 'Format', command: 'prettier -c .', order: 1 },
+      ],
+    });
+
+    expect((config.qaGates as { name: string }[]).map((g) => g.name)).toEqual(
+      ['Format', 'Types', 'Lint', 'Docs']
+    );
+  });
+
+  it('should write fields in schema order, unknown ones last', () => {
+    const config = normalizeConfig({
+      qaGates: [
+        {
+          order: 1,
+          env: { B: '2', A: '1' },
+          command: 'npm test',
+          zeta: true,
+          name: 'Tests',
+          retryIf: { outputMatches: 'ECONNRESET', exitCode: 75 },
+        },
+      ],
+      maxParallel: 2,
+      version: '1.0',
+      env: { Z: '1', M: '2' },
+    });
+
+    expect(Object.keys(config)).toEqual([
+      'version',
+      'maxParallel',
+      'env',
+      'qaGates',
+    ]);
+    const [gate] = config.qaGates as Record<string, unknown>[];
+    expect(Object.keys(gate!)).toEqual([
+      'name',
+      'command',
+      'order',
+      'env',
+      'retryIf',
+      'zeta',
+    ]);
+    expect(Object.keys(gate!.env as object)).toEqual(['A', 'B']);
+    expect(Object.keys(gate!.retryIf as object)).toEqual([
+      'exitCode',
+      'outputMatches',
+    ]);
+    expect(Object.keys(config.env as object)).toEqual(['M', 'Z']);
+  });
+
+  it('should give gates without a timeout the default timeout', () => {
+    const config = normalizeConfig({
+      defaultTimeout: '5m',
+      qaGates: [
+        { name: 'Lint', command: 'eslint .' },
+        { name: 'E2E', command: 'npm run e2e', timeout: 0 },
+      ],
+    });
+
+    expect(config.qaGates).toEqual([
+      { name: 'E2E', command: 'npm run e2e', timeout: 0 },
+      { name: 'Lint', command: 'eslint .', timeout: '5m' },
+    ]);
+  });
+
+  it('should leave timeouts to the base config under extends', () => {
+    const config = normalizeConfig({
+      extends: './base.json',
+      defaultTimeout: '5m',
+      qaGates: [{ name: 'Lint', command: 'eslint .' }],
+    });
+
+    expect(config.qaGates).toEqual([{ name: 'Lint', command: 'eslint .' }]);
+  });
+
+  it('should be idempotent', () => {
+    const text = formatConfigText({
+      qaGates: [
+        { command: 'b', name: 'B', order: 2 },
+        { name: 'A', env: { Y: '1', X: '2' }, command: 'a' },
+      ],
+      defaultTimeout: 1000,
+      notify: { when: 'always', webhookURL: 'https://example.com' },
+    });
+
+    expect(formatConfigText(JSON.parse(text))).toBe(text);
+  });
+});
+
+describe('formatRepositoryConfig', () => {
+  let repoPath: string;
+  const configPath = () => path.join(repoPath, '.forge.json');
+  const unformatted = `{"qaGates":[{"command":"eslint .","name":"Lint"}]}`;
+
+  beforeEach(() => {
+    repoPath = fs.mkdtempSync(path.join(os.tmpdir(), 'forge-fmt-'));
+  });
+
+  afterEach(() => {
+    fs.rmSync(repoPath, { recursive: true, force: true });
+  });
+
+  it('should rewrite the file with two-space indentation', async () => {
+    fs.writeFileSync(configPath(), unformatted);
+
+    const result = await formatRepositoryConfig(repoPath);
+
+    expect(result).toEqual({ status: 'changed', configPath: configPath() });
+    expect(fs.readFileSync(configPath(), 'utf-8')).toBe(
+      [
+        '{',
+        '  "qaGates": [',
+        '    {',
+        '      "name": "Lint",',
+        '      "command": "eslint ."',
+        '    }',
+        '  ]',
+        '}',
+        '',
+      ].join('\n')
+    );
+    expect((await formatRepositoryConfig(repoPath)).status).toBe('unchanged');
+  });
+
+  it('should only report an unformatted file in check mode', async () => {
+    fs.writeFileSync(configPath(), unformatted);
+
+    expect((await formatRepositoryConfig(repoPath, true)).status).toBe(
+      'changed'
+    );
+    expect(fs.readFileSync(configPath(), 'utf-8')).toBe(unformatted);
+  });
+
+  it('should leave an invalid config alone', async () => {
+    const invalid = `{"qaGates":[{"name":"Lint"}]}`;
+    fs.writeFileSync(configPath(), invalid);
+
+    const result = await formatRepositoryConfig(repoPath);
+
+    expect(result.status).toBe('invalid');
+    expect(fs.readFileSync(configPath(), 'utf-8')).toBe(invalid);
+  });
+
+  it('should refuse to rewrite a YAML config', async () => {
+    fs.writeFileSync(
+      path.join(repoPath, '.forge.yaml'),
+      '# Lint first\nqaGates:\n  - name: Lint\n    command: eslint .\n'
+    );
+
+    const result = await formatRepositoryConfig(repoPath);
+
+    expect(result).toEqual({
+      status: 'unsupported',
+      reason: ".forge.yaml can't be formatted without losing its comments",
+    });
+  });
+});
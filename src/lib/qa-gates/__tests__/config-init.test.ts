@@ -0,0 +1,108 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import { detectProject, initRepositoryConfig } from '../config-init';
+import { loadRepositoryConfig } from '../config-loader';
+
+describe('config init', () => {
+  let repoPath: string;
+  const write = (name: string, text = '') =>
+    fs.writeFileSync(path.join(repoPath, name), text);
+  const read = (name: string) =>
+    fs.readFileSync(path.join(repoPath, name), 'utf-8');
+
+  beforeEach(() => {
+    repoPath = fs.mkdtempSync(path.join(os.tmpdir(), 'forge-init-'));
+  });
+
+  afterEach(() => {
+    fs.rmSync(repoPath, { recursive: true, force: true });
+  });
+
+  it('should detect the project by its marker files', async () => {
+    expect(await detectProject(repoPath)).toBeNull();
+    write('Makefile', 'build:\n\tgo build\n');
+    expect(await detectProject(repoPath)).toBeNull();
+    write('Makefile', 'VERSION := 1\ntest: build\n\tgo test\n');
+    expect(await detectProject(repoPath)).toBe('make');
+    write('package.json', '{}');
+    expect(await detectProject(repoPath)).toBe('javascript');
+    write('go.mod', 'module example.com/app\n');
+    expect(await detectProject(repoPath)).toBe('go');
+  });
+
+  it('should write the standard Go gates, checks before tests', async () => {
+    write('go.mod', 'module example.com/app\n');
+
+    const result = await initRepositoryConfig(repoPath);
+
+    expect(result).toEqual({
+      status: 'created',
+      configPath: path.join(repoPath, '.forge.json'),
+      project: 'go',
+      gates: ['Go Fmt', 'Go Lint', 'Go Vet', 'Go Test'],
+    });
+    const config = await loadRepositoryConfig(repoPath);
+    expect(
+      config.qaGates.map((gate) => [gate.name, gate.order, gate.timeout])
+    ).toEqual([
+      ['Go Fmt', 1, 60000],
+      ['Go Lint', 1, 600000],
+      ['Go Vet', 1, 300000],
+      ['Go Test', 2, 900000],
+    ]);
+    expect(read('.forge.json').endsWith('}\n')).toBe(true);
+  });
+
+  it('should keep only gofmt and the tests when minimal', async () => {
+    write('go.mod', 'module example.com/app\n');
+
+    const result = await initRepositoryConfig(repoPath, {
+      minimal: true,
+      format: 'yaml',
+    });
+
+    expect(result).toMatchObject({
+      configPath: path.join(repoPath, '.forge.yaml'),
+      gates: ['Go Fmt', 'Go Test'],
+    });
+    expect(read('.forge.yaml')).toContain('command: gofmt -l .');
+    const config = await loadRepositoryConfig(repoPath);
+    expect(config.qaGates[0]?.failIfOutputMatches).toBe('\\S');
+  });
+
+  it('should not overwrite a config unless forced', async () => {
+    write('go.mod', 'module example.com/app\n');
+    write('.forge.json', '{ "qaGates": [] }');
+
+    expect(await initRepositoryConfig(repoPath)).toEqual({
+      status: 'exists',
+      configPath: path.join(repoPath, '.forge.json'),
+    });
+    expect(read('.forge.json')).toBe('{ "qaGates": [] }');
+
+    await initRepositoryConfig(repoPath, { force: true, format: 'yaml' });
+
+    expect(fs.existsSync(path.join(repoPath, '.forge.json'))).toBe(false);
+    expect(read('.forge.yaml')).toContain('golangci-lint');
+  });
+
+  it('should gate on the Makefile targets it finds', async () => {
+    write('Makefile', 'lint:\n\tgolint\ntest:\n\tgo test\n');
+
+    const result = await initRepositoryConfig(repoPath);
+
+    expect(result).toMatchObject({
+      project: 'make',
+      gates: ['make lint', 'make test'],
+    });
+  });
+
+  it('should write nothing when it cannot tell the project', async () => {
+    expect(await initRepositoryConfig(repoPath)).toEqual({
+      status: 'undetected',
+    });
+    expect(fs.readdirSync(repoPath)).toEqual([]);
+  });
+});
@@ -3,6 +3,13 @@ import fs from 'fs/promises';
 import path from 'path';
 import {
   loadRepositoryConfig,
+  readRepositoryConfig,
+  loadRunConfig,
+  parseRunConfigSource,
+  validateRepositoryConfig,
+  validateRunConfig,
+  parseConfigText,
+  findConfigProblems,
   getEnabledGates,
   validateConfig,
   createExampleConfig,
@@ -16,6 +23,8 @@ vi.mock('fs/promises', () => {
       access: vi.fn(),
       readFile: vi.fn(),
       writeFile: vi.fn(),
+      stat: vi.fn(),
+      readdir: vi.fn(),
     },
   };
 });
@@ -34,6 +43,7 @@ describe('QA Gate Config Loader', () => {
     mockAccess = vi.mocked(fs.access);
     mockReadFile = vi.mocked(fs.readFile);
     mockWriteFile = vi.mocked(fs.writeFile);
+    vi.mocked(fs.readdir).mockResolvedValue(['.forge.json'] as any);
   });
 
   afterEach(() => {
@@ -183,11 +193,62 @@ describe('QA Gate Config Loader', () => {
       const result = await loadRepositoryConfig(mockRepoPath);
 
       expect(result.qaGates[0]?.enabled).toBe(true); // Default
-      expect(result.qaGates[0]?.timeout).toBe(60000); // Default
+      expect(result.qaGates[0]?.timeout).toBe(0); // No timeout
       expect(result.qaGates[0]?.failOnError).toBe(true); // Default
     });
   });
 
+  describe('defaultTimeout', () => {
+    const load = async (config: unknown) => {
+      mockAccess.mockResolvedValue(undefined);
+      mockReadFile.mockResolvedValue(JSON.stringify(config));
+      return loadRepositoryConfig(mockRepoPath);
+    };
+
+    it('should give gates without a timeout the default', async () => {
+      const warn = vi.spyOn(console, 'warn').mockImplementation(() => {});
+
+      const result = await load({
+        defaultTimeout: '2m',
+        qaGates: [
+          { name: 'Inherits', command: 'a' },
+          { name: 'Own', command: 'b', timeout: 5000 },
+          { name: 'Unbounded', command: 'c', timeout: 0 },
+        ],
+      });
+
+      expect(result.qaGates.map((gate) => gate.timeout)).toEqual([
+        120000, 5000, 0,
+      ]);
+      expect(warn).not.toHaveBeenCalled();
+    });
+
+    it('should warn about gates left without any timeout', async () => {
+      const warn = vi.spyOn(console, 'warn').mockImplementation(() => {});
+
+      const result = await load({
+        qaGates: [
+          { name: 'Unset', command: 'a' },
+          { name: 'Unbounded', command: 'b', timeout: 0 },
+        ],
+      });
+
+      expect(result.qaGates.map((gate) => gate.timeout)).toEqual([0, 0]);
+      expect(warn).toHaveBeenCalledTimes(1);
+      expect(warn).toHaveBeenCalledWith(expect.stringContaining('"Unset"'));
+    });
+
+    it('should warn about a gate only once per config file', async () => {
+      const warn = vi.spyOn(console, 'warn').mockImplementation(() => {});
+      const config = { qaGates: [{ name: 'Loaded twice', command: 'a' }] };
+
+      await load(config);
+      await load(config);
+
+      expect(warn).toHaveBeenCalledTimes(1);
+    });
+  });
+
   describe('getEnabledGates', () => {
     it('should return only enabled gates', async () => {
       const config: ForgeConfig = {
@@ -252,6 +313,416 @@ describe('QA Gate Config Loader', () => {
     });
   });
 
+  describe('YAML configs', () => {
+    const yamlConfig = [
+      '# Gates every PR must pass',
+      'maxRetries: 2',
+      'qaGates:',
+      '  - name: Lint',
+      '    command: npm run lint',
+      '    timeout: 30s',
+      '  - name: Tests',
+      '    command: [npm, test]',
+    ].join('\n');
+
+    it('should load .forge.yaml like .forge.json', async () => {
+      vi.mocked(fs.readdir).mockResolvedValue(['.forge.yaml'] as any);
+      mockAccess.mockResolvedValue(undefined);
+      mockReadFile.mockResolvedValue(yamlConfig);
+
+      const result = await readRepositoryConfig(mockRepoPath);
+
+      expect(mockReadFile).toHaveBeenCalledWith(
+        path.join(mockRepoPath, '.forge.yaml'),
+        'utf-8'
+      );
+      expect(result.maxRetries).toBe(2);
+      expect(result.qaGates.map((gate) => gate.timeout)).toEqual([30000, 0]);
+      expect(result.qaGates[1]?.command).toEqual(['npm', 'test']);
+    });
+
+    it('should refuse to pick between several config files', async () => {
+      vi.mocked(fs.readdir).mockResolvedValue([
+        '.forge.json',
+        '.forge.yml',
+      ] as any);
+
+      await expect(readRepositoryConfig(mockRepoPath)).rejects.toThrow(
+        'Found .forge.json and .forge.yml in /test/repo; keep only one config file'
+      );
+      expect(await validateRepositoryConfig(mockRepoPath)).toHaveLength(1);
+    });
+
+    it('should sniff the format when none is given', () => {
+      expect(parseConfigText('{"qaGates": []}')).toEqual({ qaGates: [] });
+      expect(parseConfigText('qaGates: []')).toEqual({ qaGates: [] });
+    });
+
+    it('should report YAML syntax errors', async () => {
+      vi.mocked(fs.readdir).mockResolvedValue(['.forge.yml'] as any);
+      mockReadFile.mockResolvedValue('qaGates: [unclosed');
+
+      const problems = await validateRepositoryConfig(mockRepoPath);
+
+      expect(problems[0]).toMatch(/^Invalid YAML: /);
+    });
+  });
+
+  describe('extends', () => {
+    it('should load the merged config', async () => {
+      mockAccess.mockResolvedValue(undefined);
+      mockReadFile.mockImplementation(async (file: any) =>
+        file === '/test/base.yaml'
+          ? 'qaGates:\n  - name: Lint\n    command: npm run lint\n'
+          : JSON.stringify({
+              extends: '../base.yaml',
+              qaGates: [{ name: 'Tests', command: 'npm test' }],
+            })
+      );
+
+      const result = await readRepositoryConfig(mockRepoPath);
+
+      expect(result.qaGates.map((gate) => gate.name)).toEqual([
+        'Lint',
+        'Tests',
+      ]);
+    });
+  });
+
+  describe('readRepositoryConfig', () => {
+    it('should return the default config when the file is missing', async () => {
+      mockAccess.mockRejectedValue({ code: 'ENOENT' });
+
+      const result = await readRepositoryConfig(mockRepoPath);
+
+      expect(result.qaGates.length).toBeGreaterThan(0);
+    });
+
+    it('should accept gates whose workdir exists', async () => {
+      mockAccess.mockResolvedValue(undefined);
+      mockReadFile.mockResolvedValue(
+        JSON.stringify({
+          workdir: 'services',
+          qaGates: [{ name: 'API', command: 'go test ./...', workdir: 'api' }],
+        })
+      );
+      vi.mocked(fs.stat).mockResolvedValue({ isDirectory: () => true } as any);
+
+      const result = await readRepositoryConfig(mockRepoPath);
+
+      expect(result.qaGates[0]?.workdir).toBe('api');
+      expect(fs.stat).toHaveBeenCalledWith('/test/repo/api');
+    });
+
+    it('should name the gate whose workdir does not exist', async () => {
+      mockAccess.mockResolvedValue(undefined);
+      mockReadFile.mockResolvedValue(
+        JSON.stringify({
+          qaGates: [{ name: 'API', command: 'go test ./...', workdir: 'api' }],
+        })
+      );
+      vi.mocked(fs.stat).mockRejectedValue({ code: 'ENOENT' });
+
+      await expect(readRepositoryConfig(mockRepoPath)).rejects.toThrow(
+        'Working directory "/test/repo/api" for gate "API" does not exist'
+      );
+    });
+
+    it('should throw for an invalid config instead of falling back', async () => {
+      mockAccess.mockResolvedValue(undefined);
+      mockReadFile.mockResolvedValue(
+        JSON.stringify({ qaGates: [{ name: 'Missing command' }] })
+      );
+
+      await expect(readRepositoryConfig(mockRepoPath)).rejects.toThrow();
+    });
+  });
+
+  describe('loadRunConfig', () => {
+    it('should use an inline config without reading the config file', async () => {
+      const result = await loadRunConfig(mockRepoPath, {
+        config: { qaGates: [{ name: 'Lint', command: 'npm run lint' }] },
+      });
+
+      expect(result.qaGates.map((gate) => gate.name)).toEqual(['Lint']);
+      expect(mockReadFile).not.toHaveBeenCalled();
+    });
+
+    it('should parse an inline config given as YAML text', async () => {
+      const result = await loadRunConfig(mockRepoPath, {
+        config: 'qaGates:\n  - name: Tests\n    command: npm test\n',
+      });
+
+      expect(result.qaGates[0]?.command).toBe('npm test');
+    });
+
+    it('should load a config path relative to the repository', async () => {
+      mockAccess.mockResolvedValue(undefined);
+      mockReadFile.mockResolvedValue(
+        'qaGates:\n  - name: E2E\n    command: npm run e2e\n'
+      );
+
+      const result = await loadRunConfig(mockRepoPath, {
+        configPath: 'ci/forge.yaml',
+      });
+
+      expect(result.qaGates[0]?.name).toBe('E2E');
+      expect(mockReadFile).toHaveBeenCalledWith(
+        '/test/repo/ci/forge.yaml',
+        'utf-8'
+      );
+    });
+
+    it('should throw for an invalid inline config', async () => {
+      await expect(
+        loadRunConfig(mockRepoPath, { config: { qaGates: [{ name: 'X' }] } })
+      ).rejects.toThrow();
+    });
+
+    it('should throw when the config path does not exist', async () => {
+      mockAccess.mockRejectedValue({ code: 'ENOENT' });
+
+      await expect(
+        loadRunConfig(mockRepoPath, { configPath: 'missing.json' })
+      ).rejects.toThrow('missing.json: Config file not found');
+    });
+
+    describe('with several layers', () => {
+      const files: Record<string, string> = {
+        '/test/repo/base.yaml':
+          'maxRetries: 1\nqaGates:\n  - name: Lint\n    command: eslint .\n' +
+          '  - name: Tests\n    command: npm test\n',
+        '/test/repo/ci.json': JSON.stringify({
+          maxRetries: 5,
+          qaGates: [{ name: 'Tests', command: 'npm run test:ci' }],
+        }),
+      };
+
+      beforeEach(() => {
+        mockAccess.mockResolvedValue(undefined);
+        mockReadFile.mockImplementation(async (file: string) => files[file]);
+      });
+
+      it('should merge config paths left to right by gate name', async () => {
+        const result = await loadRunConfig(mockRepoPath, {
+          configPath: ['base.yaml', 'ci.json'],
+        });
+
+        expect(result.maxRetries).toBe(5);
+        expect(result.qaGates.map((gate) => gate.command)).toEqual([
+          'eslint .',
+          'npm run test:ci',
+        ]);
+      });
+
+      it('should merge the inline config where "-" stands', async () => {
+        const result = await loadRunConfig(mockRepoPath, {
+          configPath: ['-', 'ci.json'],
+          config: JSON.stringify({
+            maxRetries: 2,
+            qaGates: [{ name: 'E2E', command: 'e2e' }],
+          }),
+        });
+
+        expect(result.maxRetries).toBe(5);
+        expect(result.qaGates.map((gate) => gate.name)).toEqual([
+          'E2E',
+          'Tests',
+        ]);
+      });
+
+      it('should merge the inline config last by default', async () => {
+        const result = await loadRunConfig(mockRepoPath, {
+          configPath: 'base.yaml',
+          config: 'qaGates:\n  - name: Lint\n    command: eslint --fix .\n',
+        });
+
+        expect(result.qaGates[0]?.command).toBe('eslint --fix .');
+      });
+    });
+
+    it('should name <stdin> in problems with the inline config', async () => {
+      await expect(
+        loadRunConfig(mockRepoPath, { config: 'qaGates: [{ name: X }]' })
+      ).rejects.toThrow('<stdin>: qaGates[0]');
+      await expect(
+        loadRunConfig(mockRepoPath, { config: '{ "qaGates": [' })
+      ).rejects.toThrow(/^<stdin>: /);
+    });
+  });
+
+  describe('validateRunConfig', () => {
+    it('should label problems with the inline config as <stdin>', async () => {
+      const problems = await validateRunConfig(mockRepoPath, {
+        config: { qaGates: [{ name: 'Lint', command: 'x', colour: 'red' }] },
+      });
+
+      expect(problems).toHaveLength(1);
+      expect(problems[0]).toMatch(/^<stdin>: qaGates\[0\]/);
+    });
+
+    it('should name every merged layer', async () => {
+      mockAccess.mockResolvedValue(undefined);
+      mockReadFile.mockResolvedValue('qaGates: []\nbogus: 1\n');
+
+      const problems = await validateRunConfig(mockRepoPath, {
+        configPath: ['base.yaml', '-'],
+        config: { qaGates: [{ name: 'Lint', command: 'x' }] },
+      });
+
+      expect(problems[0]).toMatch(/^base\.yaml \+ <stdin>: /);
+    });
+
+    it('should accept a valid inline config', async () => {
+      const problems = await validateRunConfig(mockRepoPath, {
+        config: 'qaGates:\n  - name: Lint\n    command: eslint .\n',
+      });
+
+      expect(problems).toEqual([]);
+    });
+  });
+
+  describe('parseRunConfigSource', () => {
+    it('should take one config path or several', () => {
+      expect(parseRunConfigSource({ configPath: 'a.json' })).toEqual({
+        configPath: ['a.json'],
+        config: undefined,
+      });
+      expect(parseRunConfigSource({ configPath: ['a', 'b'] })).toEqual({
+        configPath: ['a', 'b'],
+        config: undefined,
+      });
+    });
+
+    it('should reject malformed sources', () => {
+      expect(parseRunConfigSource({ configPath: 3 })).toBe(
+        'configPath must be a string or an array of strings'
+      );
+      expect(parseRunConfigSource({ configPath: ['-'] })).toBe(
+        'configPath "-" needs an inline config'
+      );
+      expect(parseRunConfigSource(null)).toBe('Expected a JSON object');
+    });
+  });
+
+  describe('findConfigProblems', () => {
+    it('should return no problems for a valid config', () => {
+      expect(
+        findConfigProblems({ qaGates: [{ name: 'Lint', command: 'lint' }] })
+      ).toEqual([]);
+    });
+
+    it('should report every problem with its location', () => {
+      const problems = findConfigProblems({
+        maxParallel: 2,
+        parallel: true,
+        qaGates: [
+          { name: 'Lint', command: 'lint', timeout: -5 },
+          { name: 'Lint', command: 'lint --fix', comand: 'typo' },
+          { name: 'Tests', command: 'test', dependsOn: ['Build'] },
+        ],
+      });
+
+      expect(problems).toEqual([
+        'qaGates[0].timeout: Timeout must not be negative, got -5',
+        "qaGates[1]: Unrecognized key(s) in object: 'comand'",
+        "Unrecognized key(s) in object: 'parallel'",
+        'qaGates[1].name: Duplicate gate name "Lint"',
+        'qaGates[2].dependsOn: Gate "Tests" depends on unknown gate "Build"',
+      ]);
+    });
+
+    it('should report unknown retryIf fields', () => {
+      const problems = findConfigProblems({
+        qaGates: [
+          { name: 'E2E', command: 'e2e', retryIf: { exitCodes: [75] } },
+        ],
+      });
+
+      expect(problems).toEqual([
+        "qaGates[0].retryIf: Unrecognized key(s) in object: 'exitCodes'",
+        'qaGates[0].retryIf: retryIf needs exitCode or outputMatches',
+      ]);
+    });
+
+    it('should report dependency cycles', () => {
+      const problems = findConfigProblems({
+        qaGates: [
+          { name: 'A', command: 'a', dependsOn: ['B'] },
+          { name: 'B', command: 'b', dependsOn: ['A'] },
+        ],
+      });
+
+      expect(problems).toEqual([
+        'qaGates: Dependency cycle between gates: A -> B -> A',
+      ]);
+    });
+  });
+
+  describe('validateRepositoryConfig', () => {
+    it('should accept a repository without .forge.json', async () => {
+      mockReadFile.mockRejectedValue({ code: 'ENOENT' });
+
+      expect(await validateRepositoryConfig(mockRepoPath)).toEqual([]);
+    });
+
+    it('should report malformed JSON', async () => {
+      mockReadFile.mockResolvedValue('{ "qaGates": [');
+
+      const problems = await validateRepositoryConfig(mockRepoPath);
+
+      expect(problems).toHaveLength(1);
+      expect(problems[0]).toMatch(/^Invalid JSON: /);
+    });
+
+    it('should report missing working directories', async () => {
+      mockReadFile.mockResolvedValue(
+        JSON.stringify({
+          qaGates: [{ name: 'API', command: 'go test', workdir: 'api' }],
+        })
+      );
+      vi.mocked(fs.stat).mockRejectedValue({ code: 'ENOENT' });
+
+      expect(await validateRepositoryConfig(mockRepoPath)).toEqual([
+        'Working directory "/test/repo/api" for gate "API" does not exist',
+      ]);
+    });
+
+    it('should report shells that are not installed', async () => {
+      const originalPath = process.env.PATH;
+      process.env.PATH = '/nonexistent';
+      mockReadFile.mockResolvedValue(
+        JSON.stringify({
+          shell: 'pwsh',
+          qaGates: [
+            { name: 'Lint', command: 'npm run lint' },
+            { name: 'Tests', command: ['npm', 'test'] },
+          ],
+        })
+      );
+
+      try {
+        expect(await validateRepositoryConfig(mockRepoPath)).toEqual([
+          'Shell "pwsh" for gate "Lint" not found on PATH',
+        ]);
+      } finally {
+        process.env.PATH = originalPath;
+      }
+    });
+
+    it('should report commands that cannot be split under shell none', async () => {
+      mockReadFile.mockResolvedValue(
+        JSON.stringify({
+          qaGates: [{ name: 'Lint', command: 'eslint "src', shell: 'none' }],
+        })
+      );
+
+      expect(await validateRepositoryConfig(mockRepoPath)).toEqual([
+        'Unterminated " quote in command of gate "Lint"',
+      ]);
+    });
+  });
+
   describe('validateConfig', () => {
     it('should validate a correct config', () => {
       const validConfig = {
@@ -294,6 +765,229 @@ describe('QA Gate Config Loader', () => {
 
       expect(() => validateConfig(invalidConfig)).toThrow();
     });
+
+    it('should accept an argv array command', () => {
+      const config = {
+        qaGates: [
+          {
+            name: 'Go Test',
+            command: ['go', 'test', '-run', 'TestFoo Bar'],
+          },
+        ],
+      };
+
+      const result = validateConfig(config);
+
+      expect(result.qaGates[0]?.command).toEqual([
+        'go',
+        'test',
+        '-run',
+        'TestFoo Bar',
+      ]);
+    });
+
+    it('should reject an empty argv array command', () => {
+      const invalidConfig = {
+        qaGates: [{ name: 'Empty', command: [] }],
+      };
+
+      expect(() => validateConfig(invalidConfig)).toThrow();
+    });
+
+    it('should accept timeouts as milliseconds or duration strings', () => {
+      const config = {
+        qaGates: [
+          { name: 'Lint', command: 'npm run lint', timeout: '1500ms' },
+          { name: 'Tests', command: 'npm test', timeout: '2m' },
+          { name: 'Build', command: 'npm run build', timeout: 60000 },
+        ],
+      };
+
+      const result = validateConfig(config);
+
+      expect(result.qaGates.map((gate) => gate.timeout)).toEqual([
+        1500, 120000, 60000,
+      ]);
+    });
+
+    it('should reject negative and malformed timeouts', () => {
+      const gate = { name: 'Tests', command: 'npm test' };
+
+      expect(() =>
+        validateConfig({ qaGates: [{ ...gate, timeout: -1 }] })
+      ).toThrow(/must not be negative/);
+      expect(() =>
+        validateConfig({ qaGates: [{ ...gate, timeout: '5 minutes' }] })
+      ).toThrow(/Invalid duration/);
+    });
+
+    it('should accept allowed exit codes but reject negative ones', () => {
+      const gate = { name: 'Scan', command: 'scan' };
+
+      expect(
+        validateConfig({ qaGates: [{ ...gate, allowedExitCodes: [0, 1] }] })
+          .qaGates[0]?.allowedExitCodes
+      ).toEqual([0, 1]);
+      expect(() =>
+        validateConfig({ qaGates: [{ ...gate, allowedExitCodes: [-1] }] })
+      ).toThrow(/Exit codes must not be negative/);
+    });
+
+    it('should take retryIf with an exit code or an output pattern', () => {
+      const gate = { name: 'E2E', command: 'npm run e2e', retries: 3 };
+
+      expect(
+        validateConfig({ qaGates: [{ ...gate, retryIf: { exitCode: 75 } }] })
+          .qaGates[0]?.retryIf
+      ).toEqual({ exitCode: 75 });
+      expect(() =>
+        validateConfig({ qaGates: [{ ...gate, retryIf: {} }] })
+      ).toThrow(/retryIf needs exitCode or outputMatches/);
+      expect(() =>
+        validateConfig({
+          qaGates: [{ ...gate, retryIf: { outputMatches: '(' } }],
+        })
+      ).toThrow(/Invalid regular expression/);
+    });
+
+    it('should reject invalid output regexes', () => {
+      const gate = { name: 'Tests', command: 'npm test' };
+
+      expect(() =>
+        validateConfig({ qaGates: [{ ...gate, failIfOutputMatches: '(' }] })
+      ).toThrow(/Invalid regular expression/);
+      expect(
+        validateConfig({ qaGates: [{ ...gate, passIfOutputMatches: '^ok$' }] })
+          .qaGates[0]?.passIfOutputMatches
+      ).toBe('^ok$');
+    });
+
+    it('should reject malformed enabledWhen conditions', () => {
+      const gate = { name: 'Deploy', command: 'npm run deploy' };
+
+      expect(() =>
+        validateConfig({ qaGates: [{ ...gate, enabledWhen: 'env.CI ==' }] })
+      ).toThrow(/Condition ends unexpectedly/);
+      expect(() =>
+        validateConfig({ qaGates: [{ ...gate, enabledWhen: { envSet: '' } }] })
+      ).toThrow();
+      const enabledWhen = { envSet: 'CI' };
+      expect(
+        validateConfig({ qaGates: [{ ...gate, enabledWhen }] }).qaGates[0]
+          ?.enabledWhen
+      ).toEqual(enabledWhen);
+    });
+
+    it('should reject malformed output modes', () => {
+      const gate = { name: 'Tests', command: 'npm test' };
+
+      expect(() =>
+        validateConfig({ qaGates: [{ ...gate, outputMode: 'tail:0' }] })
+      ).toThrow(/Invalid outputMode/);
+      expect(
+        validateConfig({ qaGates: [{ ...gate, outputMode: 'head:50' }] })
+          .qaGates[0]?.outputMode
+      ).toBe('head:50');
+    });
+
+    it('should expand matrix gates and reject undefined variables', () => {
+      const gate = {
+        name: 'Test',
+        command: 'npm test -- --shard=${matrix.shard}',
+        order: 2,
+      };
+
+      expect(
+        validateConfig({ qaGates: [{ ...gate, matrix: { shard: [1, 2] } }] })
+          .qaGates
+      ).toMatchObject([
+        { name: 'Test [shard=1]', command: 'npm test -- --shard=1', order: 2 },
+        { name: 'Test [shard=2]', matrixOf: 'Test', order: 2 },
+      ]);
+      expect(() =>
+        validateConfig({ qaGates: [{ ...gate, matrix: { os: ['linux'] } }] })
+      ).toThrow(/matrix.shard/);
+      expect(() =>
+        validateConfig({ qaGates: [{ ...gate, matrix: {} }] })
+      ).toThrow(/at least one variable/);
+    });
+
+    it('should accept only the known log levels', () => {
+      const qaGates = [{ name: 'Tests', command: 'npm test' }];
+
+      expect(validateConfig({ qaGates, logLevel: 'quiet' }).logLevel).toBe(
+        'quiet'
+      );
+      expect(() => validateConfig({ qaGates, logLevel: 'loud' })).toThrow();
+    });
+
+    it('should default notify.when to failure and reject unknown values', () => {
+      const qaGates = [{ name: 'Tests', command: 'npm test' }];
+      const notify = { webhookURL: 'https://hooks.example.com/${TOKEN}' };
+
+      expect(validateConfig({ qaGates, notify }).notify).toEqual({
+        ...notify,
+        when: 'failure',
+      });
+      expect(() =>
+        validateConfig({ qaGates, notify: { ...notify, when: 'sometimes' } })
+      ).toThrow();
+    });
+
+    it('should give builtin gates a display command', () => {
+      const config = validateConfig({
+        qaGates: [
+          { name: 'AST', type: 'builtin', builtin: 'ast-scan', options: {} },
+          { name: 'Own', type: 'builtin', builtin: 'x', command: 'x --v' },
+        ],
+      });
+
+      expect(config.qaGates.map((gate) => gate.command)).toEqual([
+        'builtin:ast-scan',
+        'x --v',
+      ]);
+    });
+
+    it('should reject builtin gates without a builtin name', () => {
+      expect(() =>
+        validateConfig({ qaGates: [{ name: 'AST', type: 'builtin' }] })
+      ).toThrow(/needs a builtin name/);
+    });
+
+    it('should reject invalid redact regexes but accept literals', () => {
+      const qaGates = [{ name: 'Tests', command: 'npm test' }];
+
+      expect(() => validateConfig({ qaGates, redact: ['/(/'] })).toThrow(
+        /Invalid regular expression/
+      );
+      expect(
+        validateConfig({ qaGates, redact: ['(', '/tok_\\w+/'] }).redact
+      ).toEqual(['(', '/tok_\\w+/']);
+    });
+
+    it('should reject a dependency on an unknown gate', () => {
+      const invalidConfig = {
+        qaGates: [{ name: 'Tests', command: 'npm test', dependsOn: ['Build'] }],
+      };
+
+      expect(() => validateConfig(invalidConfig)).toThrow(
+        /depends on unknown gate/
+      );
+    });
+
+    it('should reject a dependency cycle naming its gates', () => {
+      const invalidConfig = {
+        qaGates: [
+          { name: 'Lint', command: 'npm run lint' },
+          { name: 'Build', command: 'npm run build', dependsOn: ['Tests'] },
+          { name: 'Tests', command: 'npm test', dependsOn: ['Build'] },
+        ],
+      };
+
+      expect(() => validateConfig(invalidConfig)).toThrow(
+        'Dependency cycle between gates: Build -> Tests -> Build'
+      );
+    });
   });
 
   describe('createExampleConfig', () => {
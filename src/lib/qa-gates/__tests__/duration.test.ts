@@ -0,0 +1,46 @@
+import { describe, it, expect } from 'vitest';
+import { parseDuration, parseTimeout } from '../duration';
+
+describe('parseDuration', () => {
+  it('should parse single-unit durations', () => {
+    expect(parseDuration('1500ms')).toBe(1500);
+    expect(parseDuration('30s')).toBe(30000);
+    expect(parseDuration('2m')).toBe(120000);
+    expect(parseDuration('1h')).toBe(3600000);
+  });
+
+  it('should parse compound and fractional durations', () => {
+    expect(parseDuration('1h30m')).toBe(5400000);
+    expect(parseDuration('1.5s')).toBe(1500);
+    expect(parseDuration('2m30s')).toBe(150000);
+  });
+
+  it('should accept a bare zero', () => {
+    expect(parseDuration('0')).toBe(0);
+  });
+
+  it('should reject values without a unit', () => {
+    expect(() => parseDuration('60000')).toThrow('Invalid duration "60000"');
+  });
+
+  it('should reject unknown units and garbage', () => {
+    expect(() => parseDuration('5 minutes')).toThrow('Invalid duration');
+    expect(() => parseDuration('')).toThrow('Invalid duration');
+    expect(() => parseDuration('3d')).toThrow('Invalid duration');
+  });
+});
+
+describe('parseTimeout', () => {
+  it('should treat numbers as milliseconds', () => {
+    expect(parseTimeout(60000)).toBe(60000);
+  });
+
+  it('should parse duration strings', () => {
+    expect(parseTimeout('2m')).toBe(120000);
+  });
+
+  it('should reject negative timeouts', () => {
+    expect(() => parseTimeout(-1)).toThrow('Timeout must not be negative');
+    expect(() => parseTimeout('-5s')).toThrow('Timeout must not be negative');
+  });
+});
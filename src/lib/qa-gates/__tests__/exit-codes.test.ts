@@ -0,0 +1,31 @@
+import { describe, it, expect } from 'vitest';
+import {
+  EXIT_CODES,
+  runExitCode,
+  validationExitCode,
+} from '../exit-codes';
+
+describe('runExitCode', () => {
+  it('should exit 0 for a passed run and 1 for a failed one', () => {
+    expect(runExitCode('passed')).toBe(EXIT_CODES.passed);
+    expect(runExitCode('failed')).toBe(EXIT_CODES.failed);
+  });
+
+  it('should exit 130 for an interrupted run', () => {
+    expect(runExitCode('failed', true)).toBe(130);
+    expect(runExitCode('cancelled')).toBe(130);
+  });
+
+  it('should have no exit code before the run finishes', () => {
+    expect(runExitCode('running')).toBeNull();
+    expect(runExitCode('running', true)).toBeNull();
+    expect(runExitCode('planned')).toBeNull();
+  });
+});
+
+describe('validationExitCode', () => {
+  it('should exit 2 when the config has problems', () => {
+    expect(validationExitCode(['qaGates.0.name: Required'])).toBe(2);
+    expect(validationExitCode([])).toBe(0);
+  });
+});
@@ -0,0 +1,95 @@
+import { describe, it, expect } from 'vitest';
+import {
+  emptyFilesetSkipReason,
+  expandFilesets,
+  filesetPatterns,
+  usesFilesets,
+} from '../filesets';
+
+describe('filesetPatterns', () => {
+  it('should find the patterns of a string or argv command', () => {
+    expect(filesetPatterns('gofmt -l ${files:*.go} ${files:*.go}')).toEqual([
+      '*.go',
+    ]);
+    expect(filesetPatterns(['eslint', '${files:src/**/*.ts}'])).toEqual([
+      'src/**/*.ts',
+    ]);
+    expect(usesFilesets([{ command: 'go vet ./...' }])).toBe(false);
+  });
+});
+
+describe('emptyFilesetSkipReason', () => {
+  const files = {
+    tracked: ['main.go', 'pkg/server.go', 'README.md'],
+    changed: ['README.md'],
+  };
+  const gate = { command: 'gofmt -l ${files:*.go}' };
+
+  it('should run a gate whose files exist', () => {
+    expect(emptyFilesetSkipReason(gate, files)).toBeNull();
+    expect(
+      emptyFilesetSkipReason(gate, { tracked: null, changed: null })
+    ).toBeNull();
+  });
+
+  it('should skip a gate with no matching files', () => {
+    expect(
+      emptyFilesetSkipReason({ ...gate, filesFromDiff: true }, files)
+    ).toBe('No changed files matching *.go');
+    expect(
+      emptyFilesetSkipReason({ command: 'black ${files:*.py}' }, files)
+    ).toBe('No files matching *.py');
+  });
+});
+
+describe('expandFilesets', () => {
+  const files = {
+    tracked: ['main.go', "cmd/it's.go", 'cmd/run.go', 'README.md'],
+    changed: ['cmd/run.go'],
+  };
+  const params = { files, root: '/repo', cwd: '/repo' };
+
+  it('should insert quoted, space-separated paths in a string command', () => {
+    const gate = { command: 'gofmt -l ${files:*.go}' };
+
+    expect(expandFilesets(gate.command, { ...params, gate })).toBe(
+      `gofmt -l main.go 'cmd/it'\\''s.go' cmd/run.go`
+    );
+  });
+
+  it('should insert one argv entry per changed file', () => {
+    const gate = {
+      command: ['gofmt', '-l', '${files:*.go}'],
+      filesFromDiff: true,
+    };
+
+    expect(expandFilesets(gate.command, { ...params, gate })).toEqual([
+      'gofmt',
+      '-l',
+      'cmd/run.go',
+    ]);
+  });
+
+  it('should give paths relative to the working directory', () => {
+    const gate = { command: ['ls', '${files:*.go}'] };
+
+    expect(
+      expandFilesets(gate.command, { ...params, gate, cwd: '/repo/cmd' })
+    ).toEqual(['ls', '../main.go', "it's.go", 'run.go']);
+  });
+
+  it('should reject a placeholder inside an argv entry or outside git', () => {
+    const gate = { command: ['gofmt', '--files=${files:*.go}'] };
+
+    expect(() => expandFilesets(gate.command, { ...params, gate })).toThrow(
+      'must be a whole argument'
+    );
+    expect(() =>
+      expandFilesets('gofmt ${files:*.go}', {
+        ...params,
+        gate,
+        files: { tracked: null, changed: null },
+      })
+    ).toThrow('${files:*.go} needs a git repository');
+  });
+});
@@ -0,0 +1,160 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import {
+  CACHE_DIR,
+  computeCacheKey,
+  listCacheInputs,
+  readCacheEntry,
+  writeCacheEntry,
+} from '../gate-cache';
+import type { ResolvedGate } from '../gate-resolver';
+
+describe('Gate cache', () => {
+  let root: string;
+  const gate = { cacheInputs: ['src/**/*.ts', 'package.json'] };
+  const resolved = (): ResolvedGate => ({
+    command: 'tsc --noEmit',
+    env: { PATH: '/usr/bin', FORGE_GATE_NAME: 'Types' },
+    cwd: root,
+  });
+
+  const write = (file: string, content: string) => {
+    fs.mkdirSync(path.dirname(path.join(root, file)), { recursive: true });
+    fs.writeFileSync(path.join(root, file), content);
+  };
+
+  beforeEach(() => {
+    root = fs.mkdtempSync(path.join(os.tmpdir(), 'forge-cache-'));
+    write('package.json', '{}');
+    write('src/index.ts', 'export {};');
+    write('src/lib/util.ts', 'export const a = 1;');
+    write('README.md', '# readme');
+    write('node_modules/dep/index.ts', 'export {};');
+  });
+
+  afterEach(() => {
+    fs.rmSync(root, { recursive: true, force: true });
+  });
+
+  describe('listCacheInputs', () => {
+    it('should list matching files in sorted order', async () => {
+      expect(await listCacheInputs(gate, root)).toEqual([
+        'package.json',
+        'src/index.ts',
+        'src/lib/util.ts',
+      ]);
+    });
+
+    it('should accept a single glob', async () => {
+      expect(await listCacheInputs({ cacheInputs: '*.md' }, root)).toEqual([
+        'README.md',
+      ]);
+    });
+
+    it('should leave out node_modules outside a git repository', async () => {
+      const files = await listCacheInputs({ cacheInputs: '**/*.ts' }, root);
+      expect(files).toEqual(['src/index.ts', 'src/lib/util.ts']);
+    });
+  });
+
+  describe('computeCacheKey', () => {
+    it('should be stable while nothing changes', async () => {
+      const first = await computeCacheKey(gate, resolved(), root);
+      expect(await computeCacheKey(gate, resolved(), root)).toBe(first);
+    });
+
+    it('should change when an input file changes', async () => {
+      const before = await computeCacheKey(gate, resolved(), root);
+      write('src/lib/util.ts', 'export const a = 2;');
+      expect(await computeCacheKey(gate, resolved(), root)).not.toBe(before);
+    });
+
+    it('should change when an input file is added', async () => {
+      const before = await computeCacheKey(gate, resolved(), root);
+      write('src/new.ts', '');
+      expect(await computeCacheKey(gate, resolved(), root)).not.toBe(before);
+    });
+
+    it('should ignore files outside the inputs', async () => {
+      const before = await computeCacheKey(gate, resolved(), root);
+      write('README.md', '# changed');
+      expect(await computeCacheKey(gate, resolved(), root)).toBe(before);
+    });
+
+    it('should change when the command or env changes', async () => {
+      const before = await computeCacheKey(gate, resolved(), root);
+      const command = { ...resolved(), command: 'tsc --noEmit --strict' };
+      const env = { ...resolved(), env: { PATH: '/bin' } };
+
+      expect(await computeCacheKey(gate, command, root)).not.toBe(before);
+      expect(await computeCacheKey(gate, env, root)).not.toBe(before);
+    });
+
+    it('should change with stdin text and stdin file contents', async () => {
+      const before = await computeCacheKey(gate, resolved(), root);
+      const text = { ...resolved(), stdin: { text: 'a.ts' } };
+      const file = { ...resolved(), stdin: { file: `${root}/files.txt` } };
+
+      expect(await computeCacheKey(gate, text, root)).not.toBe(before);
+      const missing = await computeCacheKey(gate, file, root);
+      write('files.txt', 'a.ts');
+      expect(await computeCacheKey(gate, file, root)).not.toBe(missing);
+    });
+
+    it('should not depend on env key order', async () => {
+      const before = await computeCacheKey(gate, resolved(), root);
+      const reordered = {
+        ...resolved(),
+        env: { FORGE_GATE_NAME: 'Types', PATH: '/usr/bin' },
+      };
+      expect(await computeCacheKey(gate, reordered, root)).toBe(before);
+    });
+  });
+
+  describe('cache entries', () => {
+    const result = { stdout: 'ok', stderr: 'warn', exitCode: 0 };
+    let cacheDir: string;
+
+    beforeEach(() => {
+      cacheDir = path.join(root, CACHE_DIR);
+    });
+
+    it('should read back an entry stored under the same key', async () => {
+      await writeCacheEntry(cacheDir, 'Types', 'key-1', result);
+
+      expect(await readCacheEntry(cacheDir, 'Types', 'key-1')).toEqual({
+        gateName: 'Types',
+        key: 'key-1',
+        ...result,
+        createdAt: expect.any(String),
+      });
+      expect(fs.readdirSync(cacheDir)).toHaveLength(1);
+    });
+
+    it('should miss when the key differs', async () => {
+      await writeCacheEntry(cacheDir, 'Types', 'key-1', result);
+      expect(await readCacheEntry(cacheDir, 'Types', 'key-2')).toBeNull();
+    });
+
+    it('should miss when the entry is missing or corrupt', async () => {
+      expect(await readCacheEntry(cacheDir, 'Types', 'key-1')).toBeNull();
+
+      await writeCacheEntry(cacheDir, 'Types', 'key-1', result);
+      const [file] = fs.readdirSync(cacheDir);
+      fs.writeFileSync(path.join(cacheDir, file!), '{');
+      expect(await readCacheEntry(cacheDir, 'Types', 'key-1')).toBeNull();
+    });
+
+    it('should keep one entry per gate', async () => {
+      await writeCacheEntry(cacheDir, 'Types', 'key-1', result);
+      await writeCacheEntry(cacheDir, 'Types', 'key-2', result);
+      await writeCacheEntry(cacheDir, 'Lint', 'key-3', result);
+
+      expect(fs.readdirSync(cacheDir)).toHaveLength(2);
+      expect(await readCacheEntry(cacheDir, 'Types', 'key-1')).toBeNull();
+      expect(await readCacheEntry(cacheDir, 'Types', 'key-2')).not.toBeNull();
+    });
+  });
+});
@@ -0,0 +1,41 @@
+import { describe, it, expect } from 'vitest';
+import { buildGateEnv } from '../gate-env';
+
+describe('buildGateEnv', () => {
+  const baseEnv = { PATH: '/usr/bin', GOFLAGS: '-mod=readonly' };
+
+  it('should inherit the base env when no overrides are given', () => {
+    expect(buildGateEnv(undefined, undefined, baseEnv)).toEqual(baseEnv);
+  });
+
+  it('should let gate env override config env and inherited env', () => {
+    const env = buildGateEnv(
+      { GOFLAGS: '-mod=mod' },
+      { GOFLAGS: '-v', CGO_ENABLED: '0' },
+      baseEnv
+    );
+
+    expect(env).toEqual({
+      PATH: '/usr/bin',
+      GOFLAGS: '-mod=mod',
+      CGO_ENABLED: '0',
+    });
+  });
+
+  it('should not leak a variable set on one gate into the next', () => {
+    const gateA = buildGateEnv({ ONLY_A: '1' }, undefined, baseEnv);
+    const gateB = buildGateEnv({ ONLY_B: '1' }, undefined, baseEnv);
+
+    expect(gateA.ONLY_A).toBe('1');
+    expect(gateB).not.toHaveProperty('ONLY_A');
+    expect(baseEnv).not.toHaveProperty('ONLY_A');
+  });
+
+  it('should default to the process env', () => {
+    const env = buildGateEnv({ FORGE_TEST_VAR: 'x' });
+
+    expect(env.PATH).toBe(process.env.PATH);
+    expect(env.FORGE_TEST_VAR).toBe('x');
+    expect(process.env.FORGE_TEST_VAR).toBeUndefined();
+  });
+});
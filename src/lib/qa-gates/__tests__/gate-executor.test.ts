@@ -2,6 +2,8 @@ import { describe, it, expect, vi, beforeEach } from 'vitest';
 import { executeGate } from '../gate-executor';
 import type { QAGateConfig } from '../config-loader';
 import * as commandExecutor from '../command-executor';
+import * as gateCache from '../gate-cache';
+import { createMemoryRunStore } from '../run-store';
 
 // Mock dependencies
 vi.mock('@/db', () => {
@@ -18,10 +20,12 @@ vi.mock('@/db', () => {
 
 vi.mock('drizzle-orm', () => ({
   eq: vi.fn((field, value) => ({ field, value })),
+  desc: vi.fn(),
   relations: vi.fn(),
 }));
 
 vi.mock('../command-executor');
+vi.mock('../gate-cache');
 
 describe('Gate Executor', () => {
   const mockGate: QAGateConfig = {
@@ -40,6 +44,9 @@ describe('Gate Executor', () => {
     vi.spyOn(commandExecutor, 'getContainerPath').mockImplementation(
       (path) => path
     );
+    vi.spyOn(commandExecutor, 'formatCommand').mockImplementation((command) =>
+      Array.isArray(command) ? command.join(' ') : command
+    );
   });
 
   it('should execute gate successfully', async () => {
@@ -71,11 +78,15 @@ describe('Gate Executor', () => {
       gateName: mockGate.name,
       status: 'passed',
       duration: expect.any(Number),
+      attempt: 1,
+      maxAttempts: 1,
     });
 
     expect(commandExecutor.execAsync).toHaveBeenCalledWith(mockGate.command, {
       cwd: '/test/repo',
       timeout: mockGate.timeout,
+      env: expect.any(Object),
+      maxOutputBytes: 1024 * 1024,
     });
   });
 
@@ -110,6 +121,8 @@ describe('Gate Executor', () => {
       gateName: mockGate.name,
       status: 'failed',
       duration: expect.any(Number),
+      attempt: 1,
+      maxAttempts: 1,
     });
   });
 
@@ -146,6 +159,8 @@ describe('Gate Executor', () => {
     expect(commandExecutor.execAsync).toHaveBeenCalledWith(mockGate.command, {
       cwd: '/workspace/repo',
       timeout: mockGate.timeout,
+      env: expect.any(Object),
+      maxOutputBytes: 1024 * 1024,
     });
   });
 
@@ -178,7 +193,101 @@ describe('Gate Executor', () => {
       command: mockGate.command,
       status: 'running',
       order: mockGate.order,
+      timeout: 30000,
+    });
+  });
+
+  it('should pass argv array commands through and record them as text', async () => {
+    const { db } = await import('@/db');
+    const argvGate: QAGateConfig = {
+      ...mockGate,
+      command: ['go', 'test', '-run', 'TestFoo'],
+    };
+
+    vi.mocked((db as any).returning).mockResolvedValue([{ id: 'exec-argv' }]);
+    vi.spyOn(commandExecutor, 'execAsync').mockResolvedValue({
+      stdout: 'ok',
+      stderr: '',
+    });
+
+    await executeGate({
+      runId: 'run-123',
+      gate: argvGate,
+      repoPath: '/test/repo',
+    });
+
+    expect(commandExecutor.execAsync).toHaveBeenCalledWith(argvGate.command, {
+      cwd: '/test/repo',
+      timeout: argvGate.timeout,
+      env: expect.any(Object),
+      maxOutputBytes: 1024 * 1024,
     });
+    expect(db.values).toHaveBeenCalledWith(
+      expect.objectContaining({ command: 'go test -run TestFoo' })
+    );
+  });
+
+  it('should merge config and gate env onto the process env', async () => {
+    const { db } = await import('@/db');
+    vi.mocked((db as any).returning).mockResolvedValue([{ id: 'exec-env' }]);
+    vi.spyOn(commandExecutor, 'execAsync').mockResolvedValue({
+      stdout: '',
+      stderr: '',
+    });
+
+    await executeGate({
+      runId: 'run-123',
+      gate: { ...mockGate, env: { CGO_ENABLED: '0', GOFLAGS: '-mod=mod' } },
+      repoPath: '/test/repo',
+      settings: { env: { GOFLAGS: '-v', SHARED: '1' } },
+    });
+
+    const options = vi.mocked(commandExecutor.execAsync).mock.calls[0]![1];
+    expect(options.env).toMatchObject({
+      CGO_ENABLED: '0',
+      GOFLAGS: '-mod=mod',
+      SHARED: '1',
+    });
+  });
+
+  it('should expand placeholders before executing', async () => {
+    const { db } = await import('@/db');
+    vi.mocked((db as any).returning).mockResolvedValue([{ id: 'exec-sub' }]);
+    vi.spyOn(commandExecutor, 'execAsync').mockResolvedValue({
+      stdout: '',
+      stderr: '',
+    });
+
+    await executeGate({
+      runId: 'run-123',
+      gate: { ...mockGate, command: 'make -C ${FORGE_ROOT}/${TARGET}' },
+      repoPath: '/test/repo',
+      settings: { env: { TARGET: 'api' } },
+    });
+
+    expect(commandExecutor.execAsync).toHaveBeenCalledWith(
+      'make -C /test/repo/api',
+      expect.objectContaining({ cwd: '/test/repo' })
+    );
+  });
+
+  it('should fail without executing when a placeholder is unknown', async () => {
+    const { db } = await import('@/db');
+    vi.mocked((db as any).returning).mockResolvedValue([{ id: 'exec-typo' }]);
+
+    const result = await executeGate({
+      runId: 'run-123',
+      gate: { ...mockGate, command: 'echo ${FORGE_TYPO_VARIABLE}' },
+      repoPath: '/test/repo',
+    });
+
+    expect(result.status).toBe('failed');
+    expect(commandExecutor.execAsync).not.toHaveBeenCalled();
+    expect((db as any).set).toHaveBeenCalledWith(
+      expect.objectContaining({
+        error: 'Unknown variable ${FORGE_TYPO_VARIABLE} in gate "Test Gate"',
+      })
+    );
   });
 
   it('should update gate execution on success', async () => {
@@ -373,4 +482,316 @@ describe('Gate Executor', () => {
       })
     );
   });
+
+  describe('cacheInputs', () => {
+    const cachedGate: QAGateConfig = { ...mockGate, cacheInputs: 'src/**' };
+
+    beforeEach(async () => {
+      const { db } = await import('@/db');
+      vi.mocked((db as any).returning).mockResolvedValue([{ id: 'exec-c' }]);
+      vi.spyOn(gateCache, 'computeCacheKey').mockResolvedValue('key-1');
+      vi.spyOn(commandExecutor, 'execAsync').mockResolvedValue({
+        stdout: 'fresh',
+        stderr: '',
+      });
+    });
+
+    it('should replay a cached result without running the command', async () => {
+      const { db } = await import('@/db');
+      vi.spyOn(gateCache, 'readCacheEntry').mockResolvedValue({
+        gateName: 'Test Gate',
+        key: 'key-1',
+        stdout: 'cached output',
+        stderr: '',
+        exitCode: 0,
+        createdAt: '2024-01-01T00:00:00.000Z',
+      });
+
+      const result = await executeGate({
+        runId: 'run-123',
+        gate: cachedGate,
+        repoPath: '/test/repo',
+      });
+
+      expect(result.status).toBe('cached');
+      expect(commandExecutor.execAsync).not.toHaveBeenCalled();
+      expect(gateCache.readCacheEntry).toHaveBeenCalledWith(
+        '/test/repo/.forge-cache',
+        'Test Gate',
+        'key-1'
+      );
+      expect(db.set).toHaveBeenCalledWith(
+        expect.objectContaining({ status: 'cached', output: 'cached output' })
+      );
+    });
+
+    it('should run and store the result on a miss', async () => {
+      vi.spyOn(gateCache, 'readCacheEntry').mockResolvedValue(null);
+
+      const result = await executeGate({
+        runId: 'run-123',
+        gate: cachedGate,
+        repoPath: '/test/repo',
+      });
+
+      expect(result.status).toBe('passed');
+      expect(gateCache.writeCacheEntry).toHaveBeenCalledWith(
+        '/test/repo/.forge-cache',
+        'Test Gate',
+        'key-1',
+        { stdout: 'fresh', stderr: '' }
+      );
+    });
+
+    it('should not store a failing result', async () => {
+      vi.spyOn(gateCache, 'readCacheEntry').mockResolvedValue(null);
+      vi.spyOn(commandExecutor, 'execAsync').mockRejectedValue(
+        Object.assign(new Error('failed'), { code: 1 })
+      );
+
+      const result = await executeGate({
+        runId: 'run-123',
+        gate: cachedGate,
+        repoPath: '/test/repo',
+      });
+
+      expect(result.status).toBe('failed');
+      expect(gateCache.writeCacheEntry).not.toHaveBeenCalled();
+    });
+
+    it('should skip the lookup but refresh the entry with noCache', async () => {
+      await executeGate({
+        runId: 'run-123',
+        gate: cachedGate,
+        repoPath: '/test/repo',
+        noCache: true,
+      });
+
+      expect(gateCache.readCacheEntry).not.toHaveBeenCalled();
+      expect(commandExecutor.execAsync).toHaveBeenCalled();
+      expect(gateCache.writeCacheEntry).toHaveBeenCalled();
+    });
+
+    it('should not touch the cache for gates without cacheInputs', async () => {
+      await executeGate({
+        runId: 'run-123',
+        gate: mockGate,
+        repoPath: '/test/repo',
+      });
+
+      expect(gateCache.computeCacheKey).not.toHaveBeenCalled();
+      expect(gateCache.writeCacheEntry).not.toHaveBeenCalled();
+    });
+  });
+
+  describe('retries', () => {
+    const flakyGate: QAGateConfig = { ...mockGate, retries: 2 };
+    const failure = (code: number, stdout: string) =>
+      Object.assign(new Error(`exit ${code}`), { code, stdout, stderr: '' });
+
+    it('should retry a failing gate and record the earlier attempts', async () => {
+      const store = createMemoryRunStore();
+      vi.spyOn(commandExecutor, 'execAsync')
+        .mockRejectedValueOnce(failure(1, 'flaky\n'))
+        .mockResolvedValueOnce({ stdout: 'ok\n', stderr: '' });
+
+      const result = await executeGate({
+        runId: 'run-1',
+        gate: flakyGate,
+        repoPath: '/test/repo',
+        store,
+      });
+
+      expect(result).toMatchObject({
+        status: 'passed',
+        attempt: 2,
+        maxAttempts: 3,
+      });
+      expect(commandExecutor.execAsync).toHaveBeenCalledTimes(2);
+      const [execution] = await store.listExecutions('run-1');
+      expect(execution?.output).toBe('ok\n');
+      expect(execution?.previousAttempts).toEqual([
+        {
+          attempt: 1,
+          exitCode: 1,
+          durationMs: expect.any(Number),
+          stdout: 'flaky\n',
+          stderr: 'exit 1',
+          backoffMs: expect.any(Number),
+        },
+      ]);
+    });
+
+    it('should give every attempt of a timed-out gate the same timeout', async () => {
+      const store = createMemoryRunStore();
+      const timedOut = Object.assign(
+        new Error('Command timed out after 30000ms'),
+        { code: null, stdout: 'ok  pkg/a\n', stderr: '', timedOut: true }
+      );
+      vi.spyOn(commandExecutor, 'execAsync').mockRejectedValue(timedOut);
+
+      const result = await executeGate({
+        runId: 'run-1',
+        gate: flakyGate,
+        repoPath: '/test/repo',
+        store,
+      });
+
+      expect(result).toMatchObject({ status: 'timedout', attempt: 3 });
+      const timeouts = vi
+        .mocked(commandExecutor.execAsync)
+        .mock.calls.map(([, options]) => options.timeout);
+      expect(timeouts).toEqual([30000, 30000, 30000]);
+      const [execution] = await store.listExecutions('run-1');
+      expect(execution?.previousAttempts?.[0]).toMatchObject({
+        stdout: 'ok  pkg/a\n',
+        timedOut: true,
+      });
+    });
+
+    it('should fail once every attempt has failed', async () => {
+      const store = createMemoryRunStore();
+      vi.spyOn(commandExecutor, 'execAsync')
+        .mockRejectedValueOnce(failure(1, 'one'))
+        .mockRejectedValueOnce(failure(2, 'two'))
+        .mockRejectedValueOnce(failure(3, 'three'));
+
+      const result = await executeGate({
+        runId: 'run-1',
+        gate: flakyGate,
+        repoPath: '/test/repo',
+        store,
+      });
+
+      expect(result).toMatchObject({ status: 'failed', attempt: 3 });
+      const [execution] = await store.listExecutions('run-1');
+      expect(execution).toMatchObject({ exitCode: 3, output: 'three' });
+      expect(execution?.previousAttempts?.map((a) => a.stdout)).toEqual([
+        'one',
+        'two',
+      ]);
+    });
+
+    it('should run onFailure as a plain command between attempts', async () => {
+      vi.spyOn(console, 'error').mockImplementation(() => {});
+      const formatGate: QAGateConfig = {
+        ...flakyGate,
+        command: 'gofmt -l .',
+        onFailure: 'gofmt -w .',
+        failIfOutputMatches: '\\S',
+      };
+      vi.spyOn(commandExecutor, 'execAsync')
+        .mockResolvedValueOnce({ stdout: 'main.go\n', stderr: '' })
+        .mockResolvedValueOnce({ stdout: 'main.go\n', stderr: '' })
+        .mockResolvedValueOnce({ stdout: '', stderr: '' });
+
+      const result = await executeGate({
+        runId: 'run-1',
+        gate: formatGate,
+        repoPath: '/test/repo',
+        store: createMemoryRunStore(),
+      });
+
+      expect(result).toMatchObject({ status: 'passed', attempt: 2 });
+      const commands = vi
+        .mocked(commandExecutor.execAsync)
+        .mock.calls.map(([command]) => command);
+      expect(commands).toEqual(['gofmt -l .', 'gofmt -w .', 'gofmt -l .']);
+    });
+
+    it('should stop retrying when the onFailure fix fails', async () => {
+      vi.spyOn(console, 'error').mockImplementation(() => {});
+      vi.spyOn(commandExecutor, 'execAsync')
+        .mockRejectedValueOnce(failure(1, 'main.go'))
+        .mockRejectedValueOnce(failure(1, ''));
+
+      const result = await executeGate({
+        runId: 'run-1',
+        gate: { ...flakyGate, onFailure: 'npm run fix' },
+        repoPath: '/test/repo',
+        store: createMemoryRunStore(),
+      });
+
+      expect(result).toMatchObject({ status: 'failed', attempt: 1 });
+      expect(commandExecutor.execAsync).toHaveBeenCalledTimes(2);
+      expect(console.error).toHaveBeenCalledWith(
+        'onFailure command for gate "Test Gate" failed: exit 1'
+      );
+    });
+
+    it('should run a gate without retries once', async () => {
+      vi.spyOn(commandExecutor, 'execAsync').mockRejectedValue(
+        failure(1, '')
+      );
+
+      const result = await executeGate({
+        runId: 'run-1',
+        gate: mockGate,
+        repoPath: '/test/repo',
+        store: createMemoryRunStore(),
+      });
+
+      expect(result).toMatchObject({ status: 'failed', attempt: 1 });
+      expect(commandExecutor.execAsync).toHaveBeenCalledTimes(1);
+    });
+
+    describe('retryIf', () => {
+      const transientGate: QAGateConfig = {
+        ...mockGate,
+        retries: 3,
+        retryIf: { exitCode: [75], outputMatches: 'connection reset' },
+      };
+      const run = () =>
+        executeGate({
+          runId: 'run-1',
+          gate: transientGate,
+          repoPath: '/test/repo',
+          store: createMemoryRunStore(),
+        });
+
+      it('should fail after one attempt when the failure is not retryable', async () => {
+        vi.spyOn(commandExecutor, 'execAsync').mockRejectedValue(
+          failure(2, 'error TS2322: Type mismatch')
+        );
+
+        const result = await run();
+
+        expect(result).toMatchObject({ status: 'failed', attempt: 1 });
+        expect(commandExecutor.execAsync).toHaveBeenCalledTimes(1);
+      });
+
+      it('should retry a listed exit code', async () => {
+        vi.spyOn(commandExecutor, 'execAsync')
+          .mockRejectedValueOnce(failure(75, 'temporary failure'))
+          .mockResolvedValueOnce({ stdout: 'ok\n', stderr: '' });
+
+        const result = await run();
+
+        expect(result).toMatchObject({ status: 'passed', attempt: 2 });
+      });
+
+      it('should retry output matching outputMatches', async () => {
+        vi.spyOn(commandExecutor, 'execAsync')
+          .mockRejectedValueOnce(failure(1, 'read: connection reset by peer'))
+          .mockRejectedValueOnce(failure(1, 'assertion failed'));
+
+        const result = await run();
+
+        // The second failure is genuine, so the budget of 3 goes unused
+        expect(result).toMatchObject({ status: 'failed', attempt: 2 });
+        expect(commandExecutor.execAsync).toHaveBeenCalledTimes(2);
+      });
+
+      it('should still stop at the retry budget', async () => {
+        vi.spyOn(commandExecutor, 'execAsync').mockRejectedValue(
+          failure(75, '')
+        );
+
+        const result = await run();
+
+        expect(result).toMatchObject({ status: 'failed', attempt: 4 });
+        expect(commandExecutor.execAsync).toHaveBeenCalledTimes(4);
+      });
+    });
+  });
 });
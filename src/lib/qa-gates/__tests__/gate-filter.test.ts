@@ -0,0 +1,91 @@
+import { describe, it, expect } from 'vitest';
+import { filterGates, parseGateFilter } from '../gate-filter';
+import type { QAGateConfig } from '../config-loader';
+
+function gate(overrides: Partial<QAGateConfig> & { name: string }) {
+  return {
+    enabled: true,
+    command: 'true',
+    failOnError: true,
+    ...overrides,
+  } as QAGateConfig;
+}
+
+describe('filterGates', () => {
+  const gates = [
+    gate({ name: 'Format', tags: ['lint'], order: 1 }),
+    gate({ name: 'Vet', tags: ['lint'], order: 2 }),
+    gate({ name: 'Build', order: 3 }),
+    gate({ name: 'Test', dependsOn: ['Build'], order: 4 }),
+    gate({ name: 'E2E', dependsOn: ['Test'], order: 5 }),
+    gate({ name: 'Docs', enabled: false }),
+  ];
+  const names = (selected: QAGateConfig[]) => selected.map((g) => g.name);
+
+  it('should select every enabled gate without a filter', () => {
+    expect(names(filterGates(gates))).toEqual([
+      'Format',
+      'Vet',
+      'Build',
+      'Test',
+      'E2E',
+    ]);
+  });
+
+  it('should select by name, skip by name and match tags', () => {
+    expect(names(filterGates(gates, { only: ['Vet', 'Format'] }))).toEqual([
+      'Format',
+      'Vet',
+    ]);
+    const lint = filterGates(gates, { tags: ['lint'], skip: ['Vet'] });
+    expect(names(lint)).toEqual(['Format']);
+  });
+
+  it("should select a matrix gate's instances by the gate's name", () => {
+    const instances = [
+      gate({ name: 'Test [os=linux]', matrixOf: 'Test' }),
+      gate({ name: 'Test [os=darwin]', matrixOf: 'Test' }),
+    ];
+
+    expect(names(filterGates(instances, { only: ['Test'] }))).toHaveLength(2);
+    expect(
+      names(filterGates(instances, { skip: ['Test [os=darwin]'] }))
+    ).toEqual(['Test [os=linux]']);
+  });
+
+  it('should reject unknown gate names', () => {
+    expect(() => filterGates(gates, { skip: ['Tset'] })).toThrow(
+      'Unknown gate "Tset" in filter'
+    );
+  });
+
+  it('should fail when a selected gate depends on a filtered-out gate', () => {
+    expect(() => filterGates(gates, { skip: ['Build'] })).toThrow(
+      'Gate "Test" depends on "Build", which is filtered out'
+    );
+  });
+
+  it('should drop dependents transitively with skipDependents', () => {
+    const selected = filterGates(gates, {
+      skip: ['Build'],
+      skipDependents: true,
+    });
+
+    expect(names(selected)).toEqual(['Format', 'Vet']);
+  });
+});
+
+describe('parseGateFilter', () => {
+  it('should read comma-separated query parameters', () => {
+    const params = new URLSearchParams(
+      'only=Lint, Tests&skip=E2E&tag=fast&skipDeps=true'
+    );
+
+    expect(parseGateFilter(params)).toEqual({
+      only: ['Lint', 'Tests'],
+      skip: ['E2E'],
+      tags: ['fast'],
+      skipDependents: true,
+    });
+  });
+});
@@ -0,0 +1,223 @@
+import { describe, it, expect } from 'vitest';
+import {
+  fixGate,
+  resolveGate,
+  resolveLogFormat,
+  resolveOutputOptions,
+} from '../gate-resolver';
+import type { QAGateConfig } from '../config-loader';
+
+describe('resolveGate', () => {
+  const baseEnv = { PATH: '/usr/bin', GOPATH: '/go' };
+  const gate: QAGateConfig = {
+    name: 'Go Test',
+    enabled: true,
+    command: 'go test ${PKG}',
+    timeout: 60000,
+    failOnError: true,
+  };
+
+  it('should resolve placeholders from the config-level env', () => {
+    const resolved = resolveGate({
+      gate,
+      root: '/repo',
+      settings: { env: { PKG: './...' } },
+      baseEnv,
+    });
+
+    expect(resolved.command).toBe('go test ./...');
+    expect(resolved.env.PKG).toBe('./...');
+  });
+
+  it('should expose FORGE_ROOT and FORGE_GATE_NAME', () => {
+    const resolved = resolveGate({
+      gate: { ...gate, command: ['echo', '${FORGE_GATE_NAME}@${FORGE_ROOT}'] },
+      root: '/repo',
+      baseEnv,
+    });
+
+    expect(resolved.command).toEqual(['echo', 'Go Test@/repo']);
+    expect(resolved.env.FORGE_ROOT).toBe('/repo');
+    expect(resolved.env.FORGE_GATE_NAME).toBe('Go Test');
+  });
+
+  it('should expand gate env values and make them visible to the command', () => {
+    const resolved = resolveGate({
+      gate: {
+        ...gate,
+        command: 'echo ${CACHE}',
+        env: { CACHE: '${GOPATH}/cache' },
+      },
+      root: '/repo',
+      baseEnv,
+    });
+
+    expect(resolved.env.CACHE).toBe('/go/cache');
+    expect(resolved.command).toBe('echo /go/cache');
+  });
+
+  it('should reject unknown variables by default and name the gate', () => {
+    expect(() => resolveGate({ gate, root: '/repo', baseEnv })).toThrow(
+      'Unknown variable ${PKG} in gate "Go Test"'
+    );
+  });
+
+  it('should expand unknown variables to empty when strictEnv is false', () => {
+    const resolved = resolveGate({
+      gate,
+      root: '/repo',
+      settings: { strictEnv: false },
+      baseEnv,
+    });
+
+    expect(resolved.command).toBe('go test ');
+  });
+
+  it('should resolve the working directory against the root', () => {
+    const resolve = (workdir?: string, defaultWorkdir?: string) =>
+      resolveGate({
+        gate: { ...gate, workdir },
+        root: '/repo',
+        settings: { workdir: defaultWorkdir, env: { PKG: './...' } },
+        baseEnv,
+      }).cwd;
+
+    expect(resolve()).toBe('/repo');
+    expect(resolve('services/api')).toBe('/repo/services/api');
+    expect(resolve(undefined, 'go')).toBe('/repo/go');
+    expect(resolve('tools', 'go')).toBe('/repo/tools');
+    expect(resolve('/abs/module')).toBe('/abs/module');
+  });
+
+  it('should mask redact patterns and resolved secretEnv values', () => {
+    const resolved = resolveGate({
+      gate,
+      root: '/repo',
+      settings: {
+        env: { PKG: './...', API_KEY: 'k3y' },
+        redact: ['/ghp_\\w+/'],
+        secretEnv: ['API_KEY', 'NPM_TOKEN'],
+      },
+      baseEnv: { ...baseEnv, NPM_TOKEN: 's3cr3t' },
+    });
+
+    expect(resolved.redact?.('k3y s3cr3t ghp_abc123 ./...')).toBe(
+      '*** *** *** ./...'
+    );
+  });
+
+  it('should expand text stdin and resolve @file against the root', () => {
+    const stdinOf = (stdin?: string) =>
+      resolveGate({
+        gate: { ...gate, stdin },
+        root: '/repo',
+        settings: { env: { PKG: './...' } },
+        baseEnv,
+      }).stdin;
+
+    expect(stdinOf('pkg ${PKG}\n')).toEqual({ text: 'pkg ./...\n' });
+    expect(stdinOf('@lint/files.txt')).toEqual({
+      file: '/repo/lint/files.txt',
+    });
+    expect(stdinOf(undefined)).toBeUndefined();
+  });
+
+  it('should not redact when nothing is configured', () => {
+    const resolved = resolveGate({
+      gate,
+      root: '/repo',
+      settings: { env: { PKG: './...' } },
+      baseEnv,
+    });
+
+    expect(resolved.redact).toBeUndefined();
+  });
+});
+
+describe('resolveOutputOptions', () => {
+  it('should default to a 1 MiB cap without streaming', () => {
+    expect(resolveOutputOptions({ name: 'Lint' })).toEqual({
+      maxOutputBytes: 1024 * 1024,
+      streamLine: undefined,
+    });
+  });
+
+  it('should let gate settings override the config-level ones', () => {
+    const settings = { maxOutputBytes: 4096, streamOutput: true };
+
+    const options = resolveOutputOptions({ name: 'Lint' }, settings);
+    expect(options.maxOutputBytes).toBe(4096);
+    expect(options.streamLine?.('ok', 'stdout')).toBe('[Lint] ok');
+    expect(
+      resolveOutputOptions(
+        { name: 'Lint', maxOutputBytes: 512, streamOutput: false },
+        settings
+      )
+    ).toEqual({ maxOutputBytes: 512, streamLine: undefined });
+  });
+
+  it('should stream json lines when logFormat is json', () => {
+    const options = resolveOutputOptions(
+      { name: 'Lint' },
+      { streamOutput: true, logFormat: 'json' }
+    );
+
+    expect(JSON.parse(options.streamLine!('ok', 'stdout'))).toMatchObject({
+      gate: 'Lint',
+      stream: 'stdout',
+      line: 'ok',
+    });
+  });
+});
+
+describe('resolveLogFormat', () => {
+  it('should prefer FORGE_LOG_FORMAT over the config', () => {
+    const settings = { logFormat: 'json' as const };
+
+    expect(resolveLogFormat(settings, {})).toBe('json');
+    expect(resolveLogFormat(settings, { FORGE_LOG_FORMAT: 'text' })).toBe(
+      'text'
+    );
+    expect(resolveLogFormat(undefined, { FORGE_LOG_FORMAT: 'xml' })).toBe(
+      'text'
+    );
+  });
+});
+
+describe('fixGate', () => {
+  it('should run the fix as a plain command gate', () => {
+    const gate: QAGateConfig = {
+      name: 'Format',
+      enabled: true,
+      command: 'gofmt -l .',
+      onFailure: 'gofmt -w .',
+      failOnError: false,
+      severity: 'warning',
+      env: { GOFLAGS: '-mod=mod' },
+      workdir: 'api',
+      shell: 'bash',
+      timeout: 30000,
+      stdin: 'input',
+      failIfOutputMatches: '\\S',
+      allowedExitCodes: [0, 1],
+      retries: 2,
+    };
+
+    expect(fixGate(gate)).toEqual({
+      name: 'Format',
+      enabled: true,
+      command: 'gofmt -w .',
+      failOnError: true,
+      env: { GOFLAGS: '-mod=mod' },
+      workdir: 'api',
+      shell: 'bash',
+      timeout: 30000,
+    });
+  });
+
+  it('should be null for a gate without onFailure', () => {
+    const gate = { name: 'Test', enabled: true, command: 'go test' };
+
+    expect(fixGate({ ...gate, failOnError: true })).toBeNull();
+  });
+});
@@ -0,0 +1,43 @@
+import { describe, it, expect } from 'vitest';
+import { createIgnoreMatcher } from '../gitignore';
+
+describe('createIgnoreMatcher', () => {
+  const isIgnored = createIgnoreMatcher(
+    [
+      '# build output',
+      'node_modules/',
+      '/dist',
+      '*.log',
+      '!keep.log',
+      'docs/**/*.tmp',
+      '',
+    ].join('\n')
+  );
+
+  it('should always ignore .git', () => {
+    expect(isIgnored('.git/index')).toBe(true);
+  });
+
+  it('should match unanchored patterns at any depth', () => {
+    expect(isIgnored('debug.log')).toBe(true);
+    expect(isIgnored('pkg/server/debug.log')).toBe(true);
+    expect(isIgnored('pkg/server/main.go')).toBe(false);
+  });
+
+  it('should match directory patterns only against directories', () => {
+    expect(isIgnored('node_modules/zod/index.js')).toBe(true);
+    expect(isIgnored('web/node_modules/zod/index.js')).toBe(true);
+    expect(isIgnored('node_modules')).toBe(false);
+  });
+
+  it('should anchor patterns with a leading slash to the root', () => {
+    expect(isIgnored('dist/app.js')).toBe(true);
+    expect(isIgnored('web/dist/app.js')).toBe(false);
+  });
+
+  it('should support ** and negation', () => {
+    expect(isIgnored('docs/a/b/page.tmp')).toBe(true);
+    expect(isIgnored('docs/page.tmp')).toBe(true);
+    expect(isIgnored('keep.log')).toBe(false);
+  });
+});
@@ -0,0 +1,137 @@
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import {
+  HISTORY_FILE,
+  appendRunHistory,
+  formatHistory,
+  readRunHistory,
+  summarizeHistory,
+} from '../history';
+import type { GateOutcome, RunResult } from '../run-report';
+
+function run(id: string, gates: [string, GateOutcome, number][]): RunResult {
+  return {
+    runId: id,
+    status: gates.some(([, status]) => status === 'failed')
+      ? 'failed'
+      : 'passed',
+    gates: gates.map(([name, status, durationMs]) => ({
+      name,
+      status,
+      durationMs,
+    })),
+  } as RunResult;
+}
+
+describe('run history', () => {
+  let root: string;
+  const historyFile = () => path.join(root, 'history', HISTORY_FILE);
+
+  beforeEach(() => {
+    root = fs.mkdtempSync(path.join(os.tmpdir(), 'forge-history-'));
+  });
+
+  afterEach(() => {
+    fs.rmSync(root, { recursive: true, force: true });
+  });
+
+  it('should append one line per run, also from concurrent runs', async () => {
+    const runs = Array.from({ length: 20 }, (_, i) =>
+      run(`run-${i}`, [['Lint', 'passed', 100]])
+    );
+
+    await Promise.all(runs.map((r) => appendRunHistory('history', root, r)));
+
+    const lines = fs.readFileSync(historyFile(), 'utf-8').split('\n');
+    expect(lines.pop()).toBe('');
+    expect(lines.map((line) => JSON.parse(line).runId).sort()).toEqual(
+      runs.map((r) => r.runId).sort()
+    );
+    expect(fs.existsSync(`${historyFile()}.lock`)).toBe(false);
+  });
+
+  it('should take over a lock left behind by a dead process', async () => {
+    fs.mkdirSync(path.join(root, 'history'));
+    const lock = `${historyFile()}.lock`;
+    fs.writeFileSync(lock, '');
+    const old = new Date(Date.now() - 60000);
+    fs.utimesSync(lock, old, old);
+
+    await appendRunHistory('history', root, run('run-1', []));
+
+    expect(await readRunHistory('history', root)).toHaveLength(1);
+  });
+
+  it('should read the last runs, leaving out torn lines', async () => {
+    for (const id of ['run-1', 'run-2', 'run-3']) {
+      await appendRunHistory('history', root, run(id, []));
+    }
+    fs.appendFileSync(historyFile(), '{"runId": "cut sh');
+
+    const runs = await readRunHistory('history', root, 2);
+
+    expect(runs.map((r) => r.runId)).toEqual(['run-2', 'run-3']);
+    expect(await readRunHistory('missing', root)).toEqual([]);
+  });
+});
+
+describe('summarizeHistory', () => {
+  const runs = [
+    run('run-1', [
+      ['Tests', 'passed', 1000],
+      ['Lint', 'passed', 200],
+    ]),
+    run('run-2', [
+      ['Tests', 'failed', 3000],
+      ['Lint', 'cached', 0],
+    ]),
+    run('run-3', [
+      ['Tests', 'timedout', 2000],
+      ['Lint', 'skipped', 0],
+    ]),
+  ];
+
+  it('should give pass rates and average durations, flaky gates first', () => {
+    expect(summarizeHistory(runs)).toEqual({
+      runs: 3,
+      gates: [
+        {
+          name: 'Tests',
+          runs: 3,
+          passed: 1,
+          failed: 2,
+          skipped: 0,
+          passRate: 1 / 3,
+          averageDurationMs: 2000,
+          flaky: true,
+        },
+        {
+          name: 'Lint',
+          runs: 3,
+          passed: 2,
+          failed: 0,
+          skipped: 1,
+          passRate: 1,
+          averageDurationMs: 200,
+          flaky: false,
+        },
+      ],
+    });
+  });
+
+  it('should format the summary as a table naming the flaky gates', () => {
+    expect(formatHistory(summarizeHistory(runs))).toBe(
+      [
+        'History of the last 3 runs',
+        'Gate   Runs  Pass rate  Avg duration',
+        'Tests  3     33%        2.0s          flaky',
+        'Lint   3     100%       200ms',
+        '',
+        'Flaky: Tests',
+      ].join('\n')
+    );
+    expect(formatHistory(summarizeHistory([]))).toBe('No runs recorded yet');
+  });
+});
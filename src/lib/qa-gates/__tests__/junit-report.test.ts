@@ -0,0 +1,144 @@
+import { describe, it, expect } from 'vitest';
+import { escapeXml, formatJUnitReport, junitSuiteOf } from '../junit-report';
+import type { RunResult } from '../run-report';
+
+describe('escapeXml', () => {
+  it('should escape markup characters', () => {
+    expect(escapeXml(`<a href="x">&'</a>`)).toBe(
+      '&lt;a href=&quot;x&quot;&gt;&amp;&apos;&lt;/a&gt;'
+    );
+  });
+
+  it('should replace characters XML cannot carry', () => {
+    expect(escapeXml('ok\u0000\u001b[31m\uD800end\n')).toBe(
+      'ok\uFFFD\uFFFD[31m\uFFFDend\n'
+    );
+  });
+
+  it('should keep valid surrogate pairs', () => {
+    expect(escapeXml('done 🎉')).toBe('done 🎉');
+  });
+});
+
+describe('formatJUnitReport', () => {
+  it('should render one testcase per gate with totals', () => {
+    const xml = formatJUnitReport({
+      name: 'forge',
+      timestamp: new Date('2024-01-01T00:00:00Z'),
+      cases: [
+        { name: 'Lint', status: 'passed', duration: 1500, stdout: 'clean' },
+        {
+          name: 'Tests',
+          status: 'failed',
+          duration: 2000,
+          exitCode: 2,
+          stderr: 'FAIL a.test.ts',
+        },
+        { name: 'E2E', status: 'skipped', duration: 0 },
+      ],
+    });
+
+    expect(xml).toContain(
+      '<testsuites name="forge" tests="3" failures="1" errors="0" skipped="1" time="3.500">'
+    );
+    expect(xml).toContain('timestamp="2024-01-01T00:00:00.000Z"');
+    expect(xml).toContain(
+      '<testcase name="Lint" classname="forge" time="1.500">'
+    );
+    expect(xml).toContain('<system-out>clean</system-out>');
+    expect(xml).toContain(
+      '<failure message="Exit code 2" type="exit-code">FAIL a.test.ts</failure>'
+    );
+    expect(xml).toContain('<system-err>FAIL a.test.ts</system-err>');
+    expect(xml).toContain('<skipped message="Skipped"/>');
+  });
+
+  it('should keep only the last lines of output in the failure', () => {
+    const output = Array.from({ length: 30 }, (_, i) => `line ${i}`).join(
+      '\n'
+    );
+
+    const xml = formatJUnitReport({
+      name: 'forge',
+      cases: [{ name: 'Tests', status: 'failed', stdout: output }],
+    });
+    const failure = xml.match(/<failure[^>]*>([\s\S]*?)<\/failure>/)![1]!;
+
+    expect(failure.split('\n')).toHaveLength(20);
+    expect(failure).toContain('line 29');
+    expect(failure).not.toContain('line 9\n');
+  });
+
+  it('should report a timed-out gate as a timeout failure', () => {
+    const xml = formatJUnitReport({
+      name: 'forge',
+      cases: [
+        {
+          name: 'Tests',
+          status: 'timedout',
+          timeout: 30000,
+          stdout: 'ok  pkg/a\n',
+          stderr: 'Command timed out after 30000ms',
+        },
+      ],
+    });
+
+    expect(xml).toContain('failures="1" errors="0" skipped="0"');
+    expect(xml).toContain(
+      '<failure message="Timed out after 30.0s" type="timeout">'
+    );
+  });
+
+  it('should count a cached gate as passed, not skipped', () => {
+    const xml = formatJUnitReport({
+      name: 'forge',
+      cases: [{ name: 'Build', status: 'cached', duration: 0 }],
+    });
+
+    expect(xml).toContain('tests="1" failures="0" errors="0" skipped="0"');
+    expect(xml).not.toContain('<skipped');
+  });
+
+  it('should report the attempt count as a property', () => {
+    const xml = formatJUnitReport({
+      name: 'forge',
+      cases: [
+        { name: 'Tests', status: 'passed', command: 'npm test', attempts: 3 },
+      ],
+    });
+
+    expect(xml).toContain('<property name="command" value="npm test"/>');
+    expect(xml).toContain('<property name="attempts" value="3"/>');
+  });
+});
+
+describe('junitSuiteOf', () => {
+  it('should take the gates of a RunResult in its order', () => {
+    const gate = {
+      command: 'make lint',
+      severity: 'error',
+      exitCode: 0,
+      durationMs: 1200,
+      timeoutMs: null,
+      attempts: 1,
+      attemptDetails: [],
+      stdout: null,
+      stderr: null,
+    };
+    const result = {
+      startedAt: '2024-01-01T00:00:00.000Z',
+      gates: [
+        { ...gate, name: 'Lint', status: 'passed' },
+        { ...gate, name: 'Tests', status: 'failed', exitCode: 1, attempts: 2 },
+      ],
+    } as unknown as RunResult;
+
+    const suite = junitSuiteOf('forge', result);
+
+    expect(suite.timestamp).toEqual(new Date('2024-01-01T00:00:00Z'));
+    expect(suite.cases.map((c) => c.name)).toEqual(['Lint', 'Tests']);
+    expect(suite.cases[0]).toMatchObject({ duration: 1200, stdout: null });
+    expect(suite.cases[0]?.attempts).toBeUndefined();
+    expect(suite.cases[1]).toMatchObject({ exitCode: 1, attempts: 2 });
+  });
+});
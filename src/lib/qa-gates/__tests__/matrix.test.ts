@@ -0,0 +1,100 @@
+import { describe, it, expect } from 'vitest';
+import {
+  expandMatrixGates,
+  matrixCombinations,
+  matrixInstanceNames,
+  matrixReferences,
+} from '../matrix';
+
+describe('matrixCombinations', () => {
+  it('should vary the first variable slowest', () => {
+    expect(
+      matrixCombinations({ os: ['linux', 'darwin'], node: [18, 20] })
+    ).toEqual([
+      { os: 'linux', node: '18' },
+      { os: 'linux', node: '20' },
+      { os: 'darwin', node: '18' },
+      { os: 'darwin', node: '20' },
+    ]);
+  });
+});
+
+describe('matrixInstanceNames', () => {
+  it('should follow the gate name with its values', () => {
+    expect(
+      matrixInstanceNames({
+        name: 'Go test',
+        matrix: { os: ['linux'], tags: ['integration', 'unit'] },
+      })
+    ).toEqual([
+      'Go test [os=linux,tags=integration]',
+      'Go test [os=linux,tags=unit]',
+    ]);
+    expect(matrixInstanceNames({ name: 'Lint' })).toEqual([]);
+  });
+});
+
+describe('matrixReferences', () => {
+  it('should find variables in the command and env values', () => {
+    expect(
+      matrixReferences({
+        command: ['go', 'test', '-tags=${matrix.tags}'],
+        env: { GOOS: '${matrix.os}', TAGS: '${matrix.tags}' },
+      })
+    ).toEqual(['tags', 'os']);
+  });
+});
+
+describe('expandMatrixGates', () => {
+  it('should substitute values in the command and env', () => {
+    const [linux, darwin] = expandMatrixGates([
+      {
+        name: 'Test',
+        command: 'go test -tags ${matrix.tags} ./...',
+        env: { GOOS: '${matrix.os}', CGO_ENABLED: '0' },
+        matrix: { os: ['linux', 'darwin'], tags: ['integration'] },
+      },
+    ]);
+
+    expect(linux).toEqual({
+      name: 'Test [os=linux,tags=integration]',
+      command: 'go test -tags integration ./...',
+      env: { GOOS: 'linux', CGO_ENABLED: '0' },
+      matrixOf: 'Test',
+    });
+    expect(darwin?.env?.GOOS).toBe('darwin');
+  });
+
+  it('should substitute each argument of an argv command', () => {
+    const gates = expandMatrixGates([
+      {
+        name: 'Node',
+        command: ['nvm', 'exec', '${matrix.v}'],
+        matrix: { v: [20] },
+      },
+    ]);
+
+    expect(gates.map((gate) => gate.command)).toEqual([['nvm', 'exec', '20']]);
+  });
+
+  it('should make dependents of the gate wait for every instance', () => {
+    const gates = expandMatrixGates([
+      { name: 'Build', command: 'make', matrix: { arch: ['amd64', 'arm64'] } },
+      { name: 'Lint', command: 'make lint' },
+      { name: 'Ship', command: 'make ship', dependsOn: ['Build', 'Lint'] },
+    ]);
+
+    expect(gates.map((gate) => gate.name)).toEqual([
+      'Build [arch=amd64]',
+      'Build [arch=arm64]',
+      'Lint',
+      'Ship',
+    ]);
+    expect(gates[1]).not.toHaveProperty('matrix');
+    expect(gates[3]?.dependsOn).toEqual([
+      'Build [arch=amd64]',
+      'Build [arch=arm64]',
+      'Lint',
+    ]);
+  });
+});
@@ -0,0 +1,123 @@
+import { describe, it, expect, vi, beforeEach } from 'vitest';
+import fs from 'fs/promises';
+import { escapeLabelValue, formatMetrics, writeMetrics } from '../metrics';
+import { RUN_RESULT_SCHEMA_VERSION, type RunResult } from '../run-report';
+
+vi.mock('fs/promises', () => ({
+  default: {
+    mkdir: vi.fn(),
+    writeFile: vi.fn(),
+    rename: vi.fn(),
+  },
+}));
+
+const result: RunResult = {
+  schemaVersion: RUN_RESULT_SCHEMA_VERSION,
+  runId: 'run-1',
+  status: 'failed',
+  startedAt: '2024-01-01T00:00:00.000Z',
+  finishedAt: '2024-01-01T00:00:05.000Z',
+  durationMs: 5250,
+  exitCode: 1,
+  totals: {
+    gates: 2,
+    passed: 1,
+    failed: 1,
+    skipped: 0,
+    timedout: 0,
+    cancelled: 0,
+    cached: 0,
+    planned: 0,
+  },
+  severities: {
+    error: { gates: 2, failed: ['Tests'] },
+    warning: { gates: 0, failed: [] },
+    info: { gates: 0, failed: [] },
+  },
+  gates: [
+    {
+      name: 'Lint',
+      command: 'npm run lint',
+      status: 'passed',
+      severity: 'error',
+      exitCode: 0,
+      durationMs: 1500,
+      timeoutMs: null,
+      attempts: 1,
+      attemptDetails: [],
+      stdout: null,
+      stderr: null,
+    },
+    {
+      name: 'Tests',
+      command: 'npm test',
+      status: 'failed',
+      severity: 'error',
+      exitCode: 1,
+      durationMs: 3000,
+      timeoutMs: null,
+      attempts: 2,
+      attemptDetails: [],
+      stdout: null,
+      stderr: null,
+    },
+  ],
+};
+
+describe('escapeLabelValue', () => {
+  it('should escape backslashes, quotes and newlines', () => {
+    expect(escapeLabelValue('a\\b "c"\nd')).toBe('a\\\\b \\"c\\"\\nd');
+  });
+});
+
+describe('formatMetrics', () => {
+  it('should render every metric in the text format', () => {
+    const text = formatMetrics(result);
+
+    expect(text).toContain(
+      '# TYPE forge_gate_duration_seconds gauge\n' +
+        'forge_gate_duration_seconds{gate="Lint"} 1.5\n' +
+        'forge_gate_duration_seconds{gate="Tests"} 3\n'
+    );
+    expect(text).toContain('# TYPE forge_gate_attempts gauge\n');
+    expect(text).toContain('forge_gate_attempts{gate="Tests"} 2\n');
+    expect(text).toContain(
+      'forge_gate_status{gate="Tests",status="passed"} 0\n' +
+        'forge_gate_status{gate="Tests",status="failed"} 1\n'
+    );
+    expect(text).toContain(
+      '# TYPE forge_run_duration_seconds gauge\n' +
+        'forge_run_duration_seconds 5.25\n'
+    );
+    expect(text.endsWith('\n')).toBe(true);
+  });
+
+  it('should escape gate names in labels', () => {
+    const text = formatMetrics({
+      ...result,
+      gates: [{ ...result.gates[0]!, name: 'Say "hi"' }],
+    });
+
+    expect(text).toContain('forge_gate_duration_seconds{gate="Say \\"hi\\""}');
+  });
+});
+
+describe('writeMetrics', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  it('should replace the file atomically, relative to the root', async () => {
+    await writeMetrics('metrics/forge.prom', '/workspace/repo', result);
+
+    const [temporary, text] = vi.mocked(fs.writeFile).mock.calls[0]!;
+    expect(fs.mkdir).toHaveBeenCalledWith('/workspace/repo/metrics', {
+      recursive: true,
+    });
+    expect(text).toBe(formatMetrics(result));
+    expect(fs.rename).toHaveBeenCalledWith(
+      temporary,
+      '/workspace/repo/metrics/forge.prom'
+    );
+  });
+});
@@ -0,0 +1,165 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from 'vitest';
+import {
+  defaultPayload,
+  renderPayload,
+  sendNotification,
+  shouldNotify,
+} from '../notify';
+import { RUN_RESULT_SCHEMA_VERSION, type RunResult } from '../run-report';
+
+vi.mock('../retry-backoff', () => ({ sleep: vi.fn() }));
+
+const gate = {
+  command: 'npm test',
+  severity: 'error' as const,
+  exitCode: 1,
+  durationMs: 1000,
+  timeoutMs: null,
+  attempts: 1,
+  attemptDetails: [],
+  stdout: null,
+  stderr: null,
+};
+
+const result: RunResult = {
+  schemaVersion: RUN_RESULT_SCHEMA_VERSION,
+  runId: 'run-1',
+  status: 'failed',
+  startedAt: '2024-01-01T00:00:00.000Z',
+  finishedAt: '2024-01-01T00:00:42.000Z',
+  durationMs: 42000,
+  exitCode: 1,
+  totals: {
+    gates: 3,
+    passed: 1,
+    failed: 2,
+    skipped: 0,
+    timedout: 0,
+    cancelled: 0,
+    cached: 0,
+    planned: 0,
+  },
+  severities: {
+    error: { gates: 3, failed: ['Lint', 'Tests'] },
+    warning: { gates: 0, failed: [] },
+    info: { gates: 0, failed: [] },
+  },
+  gates: [
+    { ...gate, name: 'Build', status: 'passed', exitCode: 0 },
+    { ...gate, name: 'Lint', status: 'failed' },
+    { ...gate, name: 'Tests', status: 'failed' },
+  ],
+};
+
+describe('shouldNotify', () => {
+  it('should match the run status against when', () => {
+    expect(shouldNotify('always', 'passed')).toBe(true);
+    expect(shouldNotify('failure', 'failed')).toBe(true);
+    expect(shouldNotify('failure', 'passed')).toBe(false);
+    expect(shouldNotify('success', 'passed')).toBe(true);
+    expect(shouldNotify('success', 'failed')).toBe(false);
+  });
+});
+
+describe('defaultPayload', () => {
+  it('should summarize the run with Slack-compatible text', () => {
+    expect(defaultPayload(result)).toEqual({
+      text: 'Forge QA run failed in 42.0s; failed: Lint, Tests',
+      runId: 'run-1',
+      status: 'failed',
+      failedGates: ['Lint', 'Tests'],
+      durationMs: 42000,
+    });
+  });
+});
+
+describe('renderPayload', () => {
+  it('should fill placeholders in nested strings only', () => {
+    const template = {
+      text: '{{ status }}: {{failedGates}} ({{failedCount}}/{{gateCount}})',
+      blocks: [{ type: 'section', text: 'took {{duration}}' }],
+      unknown: '{{nope}}',
+      count: 3,
+    };
+
+    expect(renderPayload(template, result)).toEqual({
+      text: 'failed: Lint, Tests (2/3)',
+      blocks: [{ type: 'section', text: 'took 42.0s' }],
+      unknown: '{{nope}}',
+      count: 3,
+    });
+  });
+});
+
+describe('sendNotification', () => {
+  const fetchMock = vi.fn();
+  const notify = {
+    webhookURL: 'https://hooks.example.com/${HOOK_TOKEN}',
+    when: 'failure' as const,
+  };
+  const env = { HOOK_TOKEN: 'abc' };
+
+  beforeEach(() => {
+    fetchMock.mockReset();
+    vi.stubGlobal('fetch', fetchMock);
+    vi.spyOn(console, 'error').mockImplementation(() => {});
+  });
+
+  afterEach(() => {
+    vi.unstubAllGlobals();
+    vi.restoreAllMocks();
+  });
+
+  it('should POST the payload to the expanded URL', async () => {
+    fetchMock.mockResolvedValue({ ok: true, status: 200 });
+
+    await sendNotification(notify, result, undefined, env);
+
+    expect(fetchMock).toHaveBeenCalledTimes(1);
+    const [url, init] = fetchMock.mock.calls[0]!;
+    expect(url).toBe('https://hooks.example.com/abc');
+    expect(init.method).toBe('POST');
+    expect(JSON.parse(init.body)).toEqual(defaultPayload(result));
+  });
+
+  it('should expand the URL from the config-level env too', async () => {
+    fetchMock.mockResolvedValue({ ok: true, status: 200 });
+
+    await sendNotification(notify, result, { env: { HOOK_TOKEN: 'cfg' } }, {});
+
+    expect(fetchMock.mock.calls[0]![0]).toBe('https://hooks.example.com/cfg');
+  });
+
+  it('should skip runs that do not match when', async () => {
+    await sendNotification(notify, { ...result, status: 'passed' }, {}, env);
+    expect(fetchMock).not.toHaveBeenCalled();
+  });
+
+  it('should retry once and log without throwing', async () => {
+    fetchMock.mockResolvedValue({ ok: false, status: 500 });
+
+    await expect(
+      sendNotification(notify, result, undefined, env)
+    ).resolves.toBeUndefined();
+    expect(fetchMock).toHaveBeenCalledTimes(2);
+    expect(console.error).toHaveBeenCalledTimes(1);
+  });
+
+  it('should stop after a successful retry', async () => {
+    fetchMock
+      .mockRejectedValueOnce(new Error('ECONNRESET'))
+      .mockResolvedValueOnce({ ok: true, status: 200 });
+
+    await sendNotification(notify, result, undefined, env);
+
+    expect(fetchMock).toHaveBeenCalledTimes(2);
+    expect(console.error).not.toHaveBeenCalled();
+  });
+
+  it('should not send when a URL variable is missing', async () => {
+    await sendNotification(notify, result, undefined, {});
+
+    expect(fetchMock).not.toHaveBeenCalled();
+    expect(console.error).toHaveBeenCalled();
+  });
+});
@@ -0,0 +1,80 @@
+import { describe, it, expect } from 'vitest';
+import {
+  TailBuffer,
+  createLineWriter,
+  gateLineFormatter,
+} from '../output-buffer';
+
+describe('TailBuffer', () => {
+  it('should keep everything under the limit', () => {
+    const buffer = new TailBuffer(16);
+    buffer.append('hello ');
+    buffer.append(Buffer.from('world'));
+
+    expect(buffer.truncated).toBe(false);
+    expect(buffer.toString()).toBe('hello world');
+  });
+
+  it('should keep only the tail once the limit is exceeded', () => {
+    const buffer = new TailBuffer(8);
+    buffer.append('line 1\n');
+    buffer.append('line 2\n');
+    buffer.append('end\n');
+
+    expect(buffer.truncated).toBe(true);
+    expect(buffer.dropped).toBe(10);
+    expect(buffer.toString()).toBe(
+      '[... 10 bytes truncated ...]\n' + 'e 2\nend\n'
+    );
+  });
+
+  it('should drop part of a single oversized chunk', () => {
+    const buffer = new TailBuffer(3);
+    buffer.append('abcdef');
+
+    expect(buffer.toString()).toBe('[... 3 bytes truncated ...]\ndef');
+  });
+
+  it('should be unlimited by default', () => {
+    const buffer = new TailBuffer();
+    buffer.append('x'.repeat(100_000));
+
+    expect(buffer.truncated).toBe(false);
+  });
+});
+
+describe('createLineWriter', () => {
+  it('should prefix complete lines and hold partial ones until flushed', () => {
+    const written: string[] = [];
+    const prefixer = createLineWriter(
+      (line) => `[Lint] ${line}`,
+      (t) => written.push(t)
+    );
+
+    prefixer.write('one\ntw');
+    prefixer.write('o\nthree');
+    expect(written).toEqual(['[Lint] one\n', '[Lint] two\n']);
+
+    prefixer.flush();
+    expect(written).toEqual([
+      '[Lint] one\n',
+      '[Lint] two\n',
+      '[Lint] three\n',
+    ]);
+  });
+});
+
+describe('gateLineFormatter', () => {
+  it('should prefix text lines with the gate name', () => {
+    expect(gateLineFormatter('Lint')('ok', 'stdout')).toBe('[Lint] ok');
+  });
+
+  it('should render json lines with the gate, stream and a timestamp', () => {
+    const line = gateLineFormatter('Lint', 'json')('a "b"', 'stderr');
+    const parsed = JSON.parse(line);
+
+    expect(parsed).toMatchObject({ gate: 'Lint', stream: 'stderr' });
+    expect(parsed.line).toBe('a "b"');
+    expect(Number.isNaN(Date.parse(parsed.ts))).toBe(false);
+  });
+});
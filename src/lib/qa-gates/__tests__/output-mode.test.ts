@@ -0,0 +1,107 @@
+import { describe, it, expect } from 'vitest';
+import type { ExecOptions } from '../command-executor';
+import { writeLines } from '../output-buffer';
+import {
+  createOutputDisplay,
+  omittedMarker,
+  parseOutputMode,
+} from '../output-mode';
+
+describe('parseOutputMode', () => {
+  it('should parse every mode', () => {
+    expect(parseOutputMode('full')).toEqual({ kind: 'full' });
+    expect(parseOutputMode('onFailureOnly')).toEqual({
+      kind: 'onFailureOnly',
+    });
+    expect(parseOutputMode('tail:20')).toEqual({ kind: 'tail', lines: 20 });
+    expect(parseOutputMode('head:5')).toEqual({ kind: 'head', lines: 5 });
+  });
+
+  it('should reject unknown modes and line counts below one', () => {
+    for (const mode of ['tail', 'tail:0', 'head:-1', 'head:x', 'all']) {
+      expect(() => parseOutputMode(mode)).toThrow(/Invalid outputMode/);
+    }
+  });
+});
+
+describe('createOutputDisplay', () => {
+  const output = '1\n2\n3\n4\n5\n';
+
+  // Stream `output` the way execAsync would, then end the gate
+  function display(
+    mode: string,
+    failed = false,
+    extra: Partial<ExecOptions> = {}
+  ) {
+    const written: string[] = [];
+    const write = (text: string) => written.push(text);
+    const { options, finish } = createOutputDisplay({
+      cwd: '/repo',
+      streamLine: (line) => `[Gate] ${line}`,
+      outputMode: parseOutputMode(mode),
+      output: { stdout: write, stderr: write },
+      ...extra,
+    });
+    writeLines(output, options.streamLine!, options.output!.stdout);
+    finish(failed);
+    return written;
+  }
+
+  it('should show everything in full mode', () => {
+    expect(display('full')).toHaveLength(5);
+  });
+
+  it('should show the first lines, then a marker, in head mode', () => {
+    expect(display('head:2')).toEqual([
+      '[Gate] 1\n',
+      '[Gate] 2\n',
+      `[Gate] ${omittedMarker(3)}\n`,
+    ]);
+  });
+
+  it('should show a marker, then the last lines, in tail mode', () => {
+    expect(display('tail:2')).toEqual([
+      '[Gate] … (3 lines omitted) …\n',
+      '[Gate] 4\n',
+      '[Gate] 5\n',
+    ]);
+    expect(display('tail:10')).toHaveLength(5);
+  });
+
+  it('should only show output of a failed gate in onFailureOnly mode', () => {
+    expect(display('onFailureOnly')).toEqual([]);
+    expect(display('onFailureOnly', true)).toHaveLength(5);
+  });
+
+  it('should cap the held output like captured output', () => {
+    expect(display('onFailureOnly', true, { maxOutputBytes: 20 })).toEqual([
+      '[Gate] … (3 lines omitted) …\n',
+      '[Gate] 4\n',
+      '[Gate] 5\n',
+    ]);
+  });
+
+  it('should keep each line on its own stream', () => {
+    const written: string[] = [];
+    const { options, finish } = createOutputDisplay({
+      cwd: '/repo',
+      streamLine: (line) => line,
+      outputMode: parseOutputMode('tail:1'),
+      output: {
+        stdout: (text) => written.push(`out ${text}`),
+        stderr: (text) => written.push(`err ${text}`),
+      },
+    });
+    options.output!.stdout('ok\n');
+    options.output!.stderr('boom\n');
+    finish(true);
+
+    expect(written).toEqual(['out … (1 line omitted) …\n', 'err boom\n']);
+  });
+
+  it('should leave the options alone without streaming', () => {
+    const options = { cwd: '/repo', outputMode: parseOutputMode('tail:1') };
+
+    expect(createOutputDisplay(options).options).toBe(options);
+  });
+});
@@ -0,0 +1,144 @@
+import { describe, it, expect, vi, beforeEach } from 'vitest';
+import { execAsync, type CommandError } from '../command-executor';
+import { execGateCommand } from '../output-rules';
+
+vi.mock('../command-executor', () => ({
+  execAsync: vi.fn(),
+}));
+
+function exitWith(code: number | null, stdout: string, stderr = '') {
+  if (code === 0) {
+    vi.mocked(execAsync).mockResolvedValue({ stdout, stderr });
+    return;
+  }
+  const error: CommandError = new Error(`Command failed: exit code ${code}`);
+  error.code = code;
+  error.stdout = stdout;
+  error.stderr = stderr;
+  vi.mocked(execAsync).mockRejectedValue(error);
+}
+
+const options = { cwd: '/repo' };
+
+describe('execGateCommand', () => {
+  beforeEach(() => {
+    vi.mocked(execAsync).mockReset();
+    vi.spyOn(console, 'log').mockImplementation(() => {});
+  });
+
+  it('should follow the exit code without rules', async () => {
+    exitWith(0, 'ok');
+    await expect(execGateCommand({}, 'lint', options)).resolves.toEqual({
+      stdout: 'ok',
+      stderr: '',
+    });
+
+    exitWith(1, '', 'boom');
+    await expect(execGateCommand({}, 'lint', options)).rejects.toThrow(
+      'exit code 1'
+    );
+  });
+
+  it('should fail a zero exit when failIfOutputMatches matches', async () => {
+    exitWith(0, 'done', 'WARNING: deprecated API');
+
+    const error = (await execGateCommand(
+      { failIfOutputMatches: '^WARNING' },
+      'lint',
+      options
+    ).catch((e) => e)) as CommandError;
+
+    const reason = 'Output matched failIfOutputMatches /^WARNING/';
+    expect(error.message).toBe(reason);
+    expect(error.code).toBe(0);
+    expect(error.stderr).toBe(`WARNING: deprecated API\n${reason}`);
+  });
+
+  it('should tolerate a nonzero exit when passIfOutputMatches matches', async () => {
+    exitWith(5, 'no tests found');
+
+    const rules = { passIfOutputMatches: 'no tests found' };
+    await expect(execGateCommand(rules, 'test', options)).resolves.toEqual({
+      stdout: 'no tests found',
+      stderr: '',
+      exitCode: 5,
+    });
+  });
+
+  it('should let failIfOutputMatches win when both match', async () => {
+    const rules = { failIfOutputMatches: 'panic', passIfOutputMatches: 'ok' };
+
+    exitWith(1, 'ok\npanic: nil map');
+    await expect(execGateCommand(rules, 'test', options)).rejects.toThrow(
+      'exit code 1'
+    );
+
+    exitWith(0, 'ok\npanic: nil map');
+    await expect(execGateCommand(rules, 'test', options)).rejects.toThrow(
+      'failIfOutputMatches'
+    );
+  });
+
+  it('should never tolerate a killed command', async () => {
+    exitWith(null, 'no tests found');
+
+    await expect(
+      execGateCommand({ passIfOutputMatches: 'no tests' }, 'test', options)
+    ).rejects.toThrow('exit code null');
+  });
+
+  describe('allowedExitCodes', () => {
+    it('should pass a gate exiting with an allowed code', async () => {
+      exitWith(1, 'found 3 issues');
+
+      const rules = { allowedExitCodes: [0, 1] };
+      await expect(execGateCommand(rules, 'scan', options)).resolves.toEqual({
+        stdout: 'found 3 issues',
+        stderr: '',
+        exitCode: 1,
+      });
+    });
+
+    it('should fail on a code outside the list', async () => {
+      exitWith(2, '', 'crashed');
+
+      await expect(
+        execGateCommand({ allowedExitCodes: [0, 1] }, 'scan', options)
+      ).rejects.toThrow('exit code 2');
+    });
+
+    it('should fail a zero exit when 0 is not listed', async () => {
+      exitWith(0, 'clean');
+
+      const error = (await execGateCommand(
+        { allowedExitCodes: [1] },
+        'scan',
+        options
+      ).catch((e) => e)) as CommandError;
+
+      expect(error.message).toBe('Exit code 0 is not in allowedExitCodes');
+      expect(error.code).toBe(0);
+    });
+
+    it('should take any exit code with an empty list', async () => {
+      exitWith(127, 'whatever');
+
+      await expect(
+        execGateCommand({ allowedExitCodes: [] }, 'scan', options)
+      ).resolves.toMatchObject({ exitCode: 127 });
+    });
+
+    it('should still let failIfOutputMatches and kills fail the gate', async () => {
+      exitWith(1, 'panic: nil map');
+      const rules = { allowedExitCodes: [0, 1], failIfOutputMatches: 'panic' };
+      await expect(execGateCommand(rules, 'scan', options)).rejects.toThrow(
+        'exit code 1'
+      );
+
+      exitWith(null, '');
+      await expect(
+        execGateCommand({ allowedExitCodes: [] }, 'scan', options)
+      ).rejects.toThrow('exit code null');
+    });
+  });
+});
@@ -0,0 +1,172 @@
+import { describe, it, expect } from 'vitest';
+import type { ForgeConfig, QAGateConfig } from '../config-loader';
+import { formatPlan, planQAGates, planToRunResult } from '../plan';
+
+function gate(overrides: Partial<QAGateConfig>): QAGateConfig {
+  return {
+    name: 'Gate',
+    enabled: true,
+    command: 'true',
+    timeout: 60000,
+    failOnError: true,
+    ...overrides,
+  };
+}
+
+describe('planQAGates', () => {
+  it('should group gates by order and skip disabled ones', () => {
+    const config: ForgeConfig = {
+      maxParallel: 2,
+      qaGates: [
+        gate({ name: 'Lint', command: 'npm run lint', order: 1 }),
+        gate({ name: 'Format', command: 'npm run fmt', order: 1 }),
+        gate({ name: 'Off', enabled: false, order: 2 }),
+        gate({ name: 'Tests', command: ['npm', 'test'], order: 3 }),
+      ],
+    };
+
+    const plan = planQAGates(config, '/repo');
+
+    expect(plan.mode).toBe('stages');
+    expect(plan.maxParallel).toBe(2);
+    expect(plan.steps.map((step) => step.map((g) => g.name))).toEqual([
+      ['Lint', 'Format'],
+      ['Tests'],
+    ]);
+    expect(plan.steps[1]![0]).toEqual({
+      name: 'Tests',
+      command: 'npm test',
+      cwd: '/repo',
+      timeout: 60000,
+      failOnError: true,
+      severity: 'error',
+      dependsOn: [],
+    });
+    expect(plan.errors).toEqual([]);
+  });
+
+  it('should lay out dependency graphs in levels', () => {
+    const config: ForgeConfig = {
+      qaGates: [
+        gate({ name: 'E2E', dependsOn: ['Build', 'Tests'] }),
+        gate({ name: 'Tests', dependsOn: ['Build'] }),
+        gate({ name: 'Build' }),
+        gate({ name: 'Lint' }),
+      ],
+    };
+
+    const plan = planQAGates(config, '/repo');
+
+    expect(plan.mode).toBe('graph');
+    expect(plan.steps.map((step) => step.map((g) => g.name))).toEqual([
+      ['Build', 'Lint'],
+      ['Tests'],
+      ['E2E'],
+    ]);
+  });
+
+  it('should resolve placeholders and collect resolution errors', () => {
+    const config: ForgeConfig = {
+      env: { TARGET: 'dist' },
+      qaGates: [
+        gate({ name: 'Build', command: 'make ${TARGET}', timeout: 0 }),
+        gate({ name: 'Bad', command: 'echo ${FORGE_PLAN_MISSING}' }),
+      ],
+    };
+
+    const plan = planQAGates(config, '/repo');
+    const [build, bad] = plan.steps.flat();
+
+    expect(build?.command).toBe('make dist');
+    expect(build?.timeout).toBeNull();
+    expect(bad?.command).toBe('echo ${FORGE_PLAN_MISSING}');
+    expect(plan.errors).toEqual([
+      'Unknown variable ${FORGE_PLAN_MISSING} in gate "Bad"',
+    ]);
+  });
+
+  it('should plan only the gates selected by a filter', () => {
+    const config: ForgeConfig = {
+      qaGates: [
+        gate({ name: 'Lint', tags: ['lint'], order: 1 }),
+        gate({ name: 'Tests', order: 2 }),
+      ],
+    };
+
+    const plan = planQAGates(config, '/repo', { tags: ['lint'] });
+    const invalid = planQAGates(config, '/repo', { only: ['Lnit'] });
+
+    expect(plan.steps.flat().map((g) => g.name)).toEqual(['Lint']);
+    expect(invalid.steps).toEqual([]);
+    expect(invalid.errors).toEqual(['Unknown gate "Lnit" in filter']);
+  });
+});
+
+describe('formatPlan', () => {
+  it('should describe each step and gate', () => {
+    const plan = planQAGates(
+      {
+        maxRetries: 2,
+        qaGates: [
+          gate({ name: 'Lint', command: 'npm run lint', order: 1 }),
+          gate({
+            name: 'Types',
+            command: 'tsc',
+            order: 1,
+            timeout: 5000,
+            failOnError: false,
+          }),
+        ],
+      },
+      '/repo'
+    );
+
+    expect(formatPlan(plan)).toBe(
+      [
+        'Execution plan (by order, max parallel: unlimited)',
+        'Retries: 2, mode all',
+        '',
+        'Step 1 (parallel):',
+        '  Lint: npm run lint',
+        '    cwd /repo, timeout 60000ms, severity error',
+        '  Types: tsc',
+        '    cwd /repo, timeout 5000ms, severity warning',
+        '',
+      ].join('\n')
+    );
+  });
+
+  it('should note gates their enabledWhen would skip', () => {
+    const plan = planQAGates(
+      {
+        qaGates: [
+          gate({
+            name: 'Deploy',
+            command: 'npm run deploy',
+            enabledWhen: { envSet: 'FORGE_TEST_UNSET_VAR' },
+          }),
+        ],
+      },
+      '/repo'
+    );
+
+    expect(formatPlan(plan)).toContain(
+      '    skipped: disabled by condition\n'
+    );
+  });
+});
+
+describe('planToRunResult', () => {
+  it('should mark every gate as planned', () => {
+    const plan = planQAGates(
+      { qaGates: [gate({ name: 'Lint' }), gate({ name: 'Tests' })] },
+      '/repo'
+    );
+
+    const result = planToRunResult(plan);
+
+    expect(result.status).toBe('planned');
+    expect(result.totals).toMatchObject({ gates: 2, planned: 2 });
+    expect(result.gates.map((g) => g.status)).toEqual(['planned', 'planned']);
+  });
+});
@@ -0,0 +1,82 @@
+import { describe, it, expect } from 'vitest';
+import { spawn } from 'child_process';
+import { superviseChild, terminateGroup } from '../process-group';
+
+function startGroup(script: string) {
+  return spawn('sh', ['-c', script], { detached: true, stdio: 'ignore' });
+}
+
+function closed(child: ReturnType<typeof spawn>) {
+  return new Promise<NodeJS.Signals | null>((resolve) =>
+    child.once('close', (_code, signal) => resolve(signal))
+  );
+}
+
+describe('terminateGroup', () => {
+  it('should stop a process group with SIGTERM', async () => {
+    const child = startGroup('sleep 5');
+    const done = closed(child);
+
+    terminateGroup(child, 1000);
+
+    expect(await done).toBe('SIGTERM');
+  });
+
+  it('should stop a child that was not started in its own group', async () => {
+    const child = spawn('sleep', ['5'], { stdio: 'ignore' });
+    const done = closed(child);
+
+    terminateGroup(child, 1000);
+
+    expect(await done).toBe('SIGTERM');
+  });
+
+  it('should SIGKILL a group that ignores SIGTERM after the grace period', async () => {
+    const child = startGroup('trap "" TERM; sleep 5');
+    const done = closed(child);
+    await new Promise((resolve) => setTimeout(resolve, 100));
+
+    const start = Date.now();
+    terminateGroup(child, 200);
+
+    expect(await done).toBe('SIGKILL');
+    expect(Date.now() - start).toBeGreaterThanOrEqual(150);
+  });
+});
+
+describe('superviseChild', () => {
+  it('should kill the group when the timeout elapses', async () => {
+    const child = startGroup('sleep 5');
+    const done = closed(child);
+
+    const reason = superviseChild(child, { timeout: 50, graceMs: 1000 });
+
+    expect(await done).toBe('SIGTERM');
+    expect(reason()).toBe('timeout');
+  });
+
+  it('should kill the group when the signal is aborted', async () => {
+    const child = startGroup('sleep 5');
+    const done = closed(child);
+    const controller = new AbortController();
+
+    const reason = superviseChild(child, {
+      signal: controller.signal,
+      graceMs: 1000,
+    });
+    controller.abort();
+
+    expect(await done).toBe('SIGTERM');
+    expect(reason()).toBe('aborted');
+  });
+
+  it('should report no reason when the child exits on its own', async () => {
+    const child = startGroup('exit 0');
+    const done = closed(child);
+
+    const reason = superviseChild(child, { timeout: 5000, graceMs: 1000 });
+
+    expect(await done).toBeNull();
+    expect(reason()).toBeNull();
+  });
+});
@@ -0,0 +1,111 @@
+import { describe, it, expect } from 'vitest';
+import { execAsync } from '../command-executor';
+import {
+  createLineRedactor,
+  createRedactor,
+  parseRedactPattern,
+} from '../redaction';
+
+describe('parseRedactPattern', () => {
+  it('should match plain strings literally', () => {
+    const pattern = parseRedactPattern('a.b*c');
+    expect('xa.b*c a-bbc'.replace(pattern, '#')).toBe('x# a-bbc');
+  });
+
+  it('should compile /source/flags as a global regular expression', () => {
+    const pattern = parseRedactPattern('/token=\\w+/i');
+    expect(pattern.flags).toBe('gi');
+    expect('TOKEN=abc token=def'.replace(pattern, '#')).toBe('# #');
+  });
+
+  it('should throw on an invalid expression', () => {
+    expect(() => parseRedactPattern('/(/')).toThrow();
+  });
+});
+
+describe('createRedactor', () => {
+  it('should be undefined without patterns or secrets', () => {
+    expect(createRedactor()).toBeUndefined();
+    expect(createRedactor([], [undefined, ''])).toBeUndefined();
+  });
+
+  it('should mask patterns and secret values', () => {
+    const redact = createRedactor(['/Bearer \\S+/'], ['hunter2'])!;
+    expect(redact('pw hunter2, auth Bearer abc.def')).toBe('pw ***, auth ***');
+  });
+
+  it('should mask secrets literally, even ones shaped like a regex', () => {
+    const redact = createRedactor([], ['/a.c/'])!;
+    expect(redact('abc /a.c/')).toBe('abc ***');
+  });
+
+  it('should mask the longer of two overlapping secrets whole', () => {
+    const redact = createRedactor([], ['abc', 'abcdef'])!;
+    expect(redact('abcdef abc')).toBe('*** ***');
+  });
+});
+
+describe('createLineRedactor', () => {
+  const collect = () => {
+    const written: string[] = [];
+    const redactor = createLineRedactor(createRedactor([], ['s3cr3t'])!, (t) =>
+      written.push(t)
+    );
+    return { written, redactor };
+  };
+
+  it('should catch a secret split across two chunks', () => {
+    const { written, redactor } = collect();
+    redactor.write('token: s3c');
+    redactor.write('r3t\nnext');
+    redactor.flush();
+
+    expect(written.join('')).toBe('token: ***\nnext');
+    expect(written.join('')).not.toContain('s3c');
+  });
+
+  it('should write a partial line once it grows past maxPending', () => {
+    const written: string[] = [];
+    const redactor = createLineRedactor(
+      createRedactor([], ['s3cr3t'])!,
+      (t) => written.push(t),
+      8
+    );
+    redactor.write('s3cr3t and');
+    redactor.write(' more');
+
+    expect(written).toEqual(['*** and']);
+    redactor.write('\n');
+    expect(written).toEqual(['*** and', ' more\n']);
+  });
+
+  it('should hold partial lines until their newline arrives', () => {
+    const { written, redactor } = collect();
+    redactor.write('partial');
+    expect(written).toEqual([]);
+
+    redactor.write(' line\n');
+    expect(written).toEqual(['partial line\n']);
+  });
+});
+
+describe('execAsync with a redactor', () => {
+  it('should mask a secret written in two pieces', async () => {
+    const result = await execAsync(
+      "printf 'key=s3c' >&2; sleep 0.1; printf 'r3t\\n' >&2; echo s3cr3t",
+      { cwd: process.cwd(), redact: createRedactor([], ['s3cr3t']) }
+    );
+
+    expect(result.stderr).toBe('key=***\n');
+    expect(result.stdout).toBe('***\n');
+  });
+
+  it('should mask secrets in the output of a failing command', async () => {
+    await expect(
+      execAsync('echo s3cr3t >&2; exit 3', {
+        cwd: process.cwd(),
+        redact: createRedactor([], ['s3cr3t']),
+      })
+    ).rejects.toMatchObject({ stderr: '***\n', code: 3 });
+  });
+});
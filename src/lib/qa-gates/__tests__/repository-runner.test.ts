@@ -0,0 +1,702 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from 'vitest';
+import { execFileSync } from 'child_process';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import { Runner } from '../repository-runner';
+import { validateConfig } from '../config-loader';
+import type { GateRunResult } from '../run-report';
+
+vi.mock('@/db', () => ({ db: {} }));
+
+describe('Runner', () => {
+  let repoPath: string;
+  const lines: string[] = [];
+  const output = {
+    stdout: (text: string) => lines.push(text),
+    stderr: (text: string) => lines.push(text),
+  };
+
+  const config = validateConfig({
+    qaGates: [
+      { name: 'Echo', command: 'echo hello', order: 1 },
+      { name: 'Lint', command: 'echo bad >&2; exit 3', order: 2 },
+      { name: 'Docs', command: 'echo docs', order: 3, severity: 'warning' },
+    ],
+  });
+
+  beforeEach(() => {
+    repoPath = fs.mkdtempSync(path.join(os.tmpdir(), 'forge-runner-'));
+    lines.length = 0;
+  });
+
+  afterEach(() => {
+    fs.rmSync(repoPath, { recursive: true, force: true });
+  });
+
+  it('should run the gates and return the RunResult', async () => {
+    const result = await new Runner(config, { repoPath, output }).run();
+
+    expect(result.status).toBe('failed');
+    expect(result.gates.map((gate) => [gate.name, gate.status])).toEqual([
+      ['Echo', 'passed'],
+      ['Lint', 'failed'],
+    ]);
+    expect(result.gates[0]?.stdout).toBe('hello\n');
+    expect(result.gates[1]).toMatchObject({ exitCode: 3, stderr: 'bad\n' });
+    expect(result.totals).toMatchObject({ gates: 2, passed: 1, failed: 1 });
+  });
+
+  describe('exit codes', () => {
+    it('should exit 0 when every error-severity gate passed', async () => {
+      const result = await new Runner(config, {
+        repoPath,
+        output,
+        filter: { only: ['Echo'] },
+      }).run();
+
+      expect(result.exitCode).toBe(0);
+    });
+
+    it('should exit 1 when a gate failed', async () => {
+      const result = await new Runner(config, { repoPath, output }).run();
+
+      expect(result.exitCode).toBe(1);
+    });
+
+    it('should exit 130 when the run is interrupted', async () => {
+      const controller = new AbortController();
+      const slow = validateConfig({
+        qaGates: [{ name: 'Sleep', command: 'sleep 5' }],
+      });
+      setTimeout(() => controller.abort(), 100);
+
+      const result = await new Runner(slow, { repoPath, output }).run(
+        controller.signal
+      );
+
+      expect(result.exitCode).toBe(130);
+    });
+
+    it('should print a summary table once the run is over', async () => {
+      await new Runner(config, { repoPath, output }).run();
+
+      const summary = lines.join('');
+      expect(summary).toMatch(/Gate +Status +Duration +Attempts\n/);
+      expect(summary).toMatch(/Lint +failed +\d+ms +1\n/);
+      // Docs never ran once Lint stopped the run
+      expect(summary).toMatch(/Docs +pending +- +-\n/);
+      expect(summary).toMatch(
+        /Total: 3 gates in \S+ \(1 passed, 1 failed, 1 pending\)/
+      );
+    });
+  });
+
+  describe('log levels', () => {
+    it('should print only the summary and failed tails when quiet', async () => {
+      await new Runner(config, { repoPath, output, logLevel: 'quiet' }).run();
+
+      const text = lines.join('');
+      expect(text).not.toMatch(/✓|✗/);
+      expect(text).toMatch(/Lint +failed/);
+      expect(text).toMatch(/\n--- Lint \(last 1 lines\) ---\nbad\n$/);
+    });
+
+    it('should take the level from the config', async () => {
+      const quiet = validateConfig({ ...config, logLevel: 'quiet' });
+
+      await new Runner(quiet, { repoPath, output }).run();
+
+      expect(lines.join('')).not.toMatch(/✓|✗/);
+    });
+
+    it('should let the option override the config', async () => {
+      const quiet = validateConfig({ ...config, logLevel: 'quiet' });
+
+      await new Runner(quiet, { repoPath, output, logLevel: 'normal' }).run();
+
+      expect(lines.join('')).toContain('✓ Echo');
+    });
+
+    it('should stream command output when verbose', async () => {
+      await new Runner(config, {
+        repoPath,
+        output,
+        logLevel: 'verbose',
+        filter: { only: ['Echo'] },
+      }).run();
+
+      expect(lines).toContain('[Echo] hello\n');
+      expect(lines.join('')).not.toContain('[debug]');
+    });
+
+    it('should log resolved commands and timings when debugging', async () => {
+      const withEnv = validateConfig({
+        env: { MODE: 'ci' },
+        qaGates: [{ name: 'Echo', command: 'echo $MODE', timeout: 5000 }],
+      });
+
+      await new Runner(withEnv, { repoPath, output, logLevel: 'debug' }).run();
+
+      const text = lines.join('');
+      expect(text).toContain('[debug] Echo: command echo $MODE\n');
+      expect(text).toMatch(
+        /\[debug\] Echo: cwd \S+, shell default, timeout 5\.0s\n/
+      );
+      expect(text).toContain('[debug] Echo: env MODE=ci\n');
+      expect(text).toMatch(
+        /\[debug\] Echo: passed in \S+, attempt 1 of 1\n/
+      );
+      expect(lines).toContain('[Echo] ci\n');
+    });
+  });
+
+  it('should report each gate to the hooks', async () => {
+    const events: string[] = [];
+    const runner = new Runner(config, {
+      repoPath,
+      output,
+      filter: { only: ['Echo', 'Docs'] },
+      onGateStart: (gate) => events.push(`start ${gate.name}`),
+      onGateFinish: (gate: GateRunResult) =>
+        events.push(`finish ${gate.name} ${gate.status}`),
+    });
+
+    const result = await runner.run();
+
+    expect(result.status).toBe('passed');
+    expect(events).toEqual([
+      'start Echo',
+      'finish Echo passed',
+      'start Docs',
+      'finish Docs passed',
+    ]);
+  });
+
+  it('should write status lines and streamed output to the writers', async () => {
+    const streaming = validateConfig({
+      streamOutput: true,
+      qaGates: [{ name: 'Echo', command: 'echo hello' }],
+    });
+
+    await new Runner(streaming, { repoPath, output }).run();
+
+    expect(lines).toContain('[Echo] hello\n');
+    expect(lines.some((line) => line.includes('✓ Echo'))).toBe(true);
+  });
+
+  it('should stream json lines, also when replaying a cached result', async () => {
+    fs.writeFileSync(path.join(repoPath, 'input.txt'), 'a');
+    const config = validateConfig({
+      streamOutput: true,
+      logFormat: 'json',
+      qaGates: [{ name: 'Echo', command: 'echo hello', cacheInputs: '*.txt' }],
+    });
+
+    await new Runner(config, { repoPath, output }).run();
+    await new Runner(config, { repoPath, output }).run();
+
+    const streamed = lines
+      .filter((line) => line.startsWith('{'))
+      .map((line) => JSON.parse(line));
+    expect(streamed).toHaveLength(2);
+    for (const entry of streamed) {
+      expect(entry).toMatchObject({
+        gate: 'Echo',
+        stream: 'stdout',
+        line: 'hello',
+      });
+    }
+  });
+
+  it('should capture each attempt of a retried gate separately', async () => {
+    const flaky = validateConfig({
+      maxOutputBytes: 64,
+      qaGates: [
+        {
+          name: 'Flaky',
+          command:
+            'if [ -f done ]; then echo ok; else touch done; echo boom; exit 3; fi',
+          retries: 2,
+        },
+      ],
+    });
+
+    const result = await new Runner(flaky, { repoPath, output }).run();
+
+    expect(result.status).toBe('passed');
+    expect(result.gates[0]).toMatchObject({ attempts: 2, stdout: 'ok\n' });
+    expect(result.gates[0]?.attemptDetails[0]).toMatchObject({
+      attempt: 1,
+      exitCode: 3,
+      stdout: 'boom\n',
+    });
+    expect(lines.join('')).toContain('(passed on attempt 2/3)');
+  });
+
+  it('should store cached results in the given cache directory', async () => {
+    fs.writeFileSync(path.join(repoPath, 'input.txt'), 'a');
+    const cached = validateConfig({
+      qaGates: [{ name: 'Echo', command: 'echo hi', cacheInputs: '*.txt' }],
+    });
+    const options = { repoPath, output, cacheDir: 'tmp/cache' };
+
+    await new Runner(cached, options).run();
+    const second = await new Runner(cached, options).run();
+
+    expect(fs.readdirSync(path.join(repoPath, 'tmp/cache'))).toHaveLength(1);
+    expect(second.gates[0]?.status).toBe('cached');
+  });
+
+  it('should record and copy the artifacts a gate declares', async () => {
+    const withArtifacts = validateConfig({
+      qaGates: [
+        {
+          name: 'Coverage',
+          command: 'mkdir -p out && printf 42 > out/coverage.txt',
+          artifacts: 'out/*.txt',
+        },
+        {
+          name: 'Lint',
+          command: 'true',
+          artifacts: 'lint.sarif',
+          artifactsRequired: true,
+        },
+      ],
+    });
+    const options = { repoPath, output, artifactsDir: 'artifacts' };
+
+    const result = await new Runner(withArtifacts, options).run();
+
+    expect(result.gates[0]).toMatchObject({
+      status: 'passed',
+      artifacts: [{ path: 'out/coverage.txt', size: 2 }],
+    });
+    expect(
+      fs.readFileSync(
+        path.join(repoPath, 'artifacts/Coverage/out/coverage.txt'),
+        'utf-8'
+      )
+    ).toBe('42');
+    expect(result.gates[1]).toMatchObject({ status: 'failed', artifacts: [] });
+    expect(result.gates[1]?.stderr).toBe(
+      'Expected artifacts not found: lint.sarif'
+    );
+  });
+
+  it('should print a timing profile and report the critical path', async () => {
+    const graph = validateConfig({
+      qaGates: [
+        { name: 'Build', command: 'sleep 0.2' },
+        { name: 'Lint', command: 'true' },
+        { name: 'Tests', command: 'sleep 0.1', dependsOn: ['Build'] },
+      ],
+    });
+
+    const result = await new Runner(graph, {
+      repoPath,
+      output,
+      profile: true,
+    }).run();
+
+    expect(result.timing?.criticalPath.gates).toEqual(['Build', 'Tests']);
+    expect(result.timing?.gates.map((gate) => gate.name)).toEqual([
+      'Build',
+      'Tests',
+      'Lint',
+    ]);
+    const text = lines.join('');
+    expect(text).toContain('Timing profile\nGate   Duration  Retries');
+    expect(text).toMatch(/Critical path: Build -> Tests \(\d/);
+  });
+
+  it('should write the junit report in config order', async () => {
+    const withJUnit = validateConfig({
+      junit: 'reports/junit.xml',
+      qaGates: [
+        { name: 'Slow', command: 'sleep 0.2', order: 1 },
+        { name: 'Fast', command: 'true', order: 1 },
+      ],
+    });
+
+    await new Runner(withJUnit, { repoPath, output }).run();
+
+    const xml = fs.readFileSync(
+      path.join(repoPath, 'reports/junit.xml'),
+      'utf-8'
+    );
+    expect(xml).toContain('tests="2" failures="0"');
+    expect(xml.indexOf('name="Slow"')).toBeLessThan(
+      xml.indexOf('name="Fast"')
+    );
+  });
+
+  it('should append each run to the history in historyDir', async () => {
+    const options = { repoPath, output, historyDir: 'history' };
+
+    await new Runner(config, options).run();
+    const second = await new Runner(config, options).run();
+
+    const history = fs
+      .readFileSync(path.join(repoPath, 'history/history.jsonl'), 'utf-8')
+      .trimEnd()
+      .split('\n');
+    expect(history).toHaveLength(2);
+    expect(JSON.parse(history[1]!)).toMatchObject({
+      runId: second.runId,
+      status: 'failed',
+    });
+  });
+
+  describe('outputMode', () => {
+    const run = (qaGates: Record<string, unknown>[]) =>
+      new Runner(validateConfig({ streamOutput: true, qaGates }), {
+        repoPath,
+        output,
+      }).run();
+
+    it('should stream only the tail and report all the output', async () => {
+      const result = await run([
+        { name: 'Seq', command: 'seq 1 5', outputMode: 'tail:2' },
+      ]);
+
+      expect(lines.filter((line) => line.startsWith('[Seq]'))).toEqual([
+        '[Seq] … (3 lines omitted) …\n',
+        '[Seq] 4\n',
+        '[Seq] 5\n',
+      ]);
+      expect(result.gates[0]?.stdout).toBe('1\n2\n3\n4\n5\n');
+    });
+
+    it('should show onFailureOnly output only for failed gates', async () => {
+      await run([
+        { name: 'Green', command: 'echo fine', outputMode: 'onFailureOnly' },
+        {
+          name: 'Red',
+          command: 'echo broken; exit 1',
+          outputMode: 'onFailureOnly',
+        },
+      ]);
+
+      expect(lines).not.toContain('[Green] fine\n');
+      expect(lines).toContain('[Red] broken\n');
+    });
+  });
+
+  describe('stdin', () => {
+    const run = (qaGates: Record<string, unknown>[]) =>
+      new Runner(validateConfig({ qaGates }), { repoPath, output }).run();
+
+    it('should pipe exactly the given text to the command', async () => {
+      const result = await run([
+        { name: 'Bytes', command: 'od -An -c', stdin: 'line1\nline2' },
+      ]);
+
+      expect(result.gates[0]?.stdout?.split(/\s+/).filter(Boolean)).toEqual([
+        ...'line1',
+        '\\n',
+        ...'line2',
+      ]);
+    });
+
+    it('should pipe a @file and read /dev/null without stdin', async () => {
+      fs.writeFileSync(path.join(repoPath, 'files.txt'), 'a.ts\nb.ts\n');
+
+      const result = await run([
+        { name: 'File', command: 'cat', stdin: '@files.txt' },
+        { name: 'None', command: 'cat' },
+      ]);
+
+      expect(result.gates.map((gate) => gate.stdout)).toEqual([
+        'a.ts\nb.ts\n',
+        null,
+      ]);
+    });
+
+    it('should fail a gate whose stdin file is missing', async () => {
+      const result = await run([
+        { name: 'File', command: 'cat', stdin: '@missing.txt' },
+      ]);
+
+      expect(result.gates[0]?.status).toBe('failed');
+      expect(result.gates[0]?.stderr).toMatch(/Cannot read stdin file/);
+    });
+  });
+
+  it('should skip a gate whose enabledWhen is false', async () => {
+    const conditional = validateConfig({
+      qaGates: [
+        {
+          name: 'Deploy',
+          command: 'echo deployed',
+          enabledWhen: "env.FORGE_TEST_UNSET_VAR == 'yes'",
+        },
+        {
+          name: 'Echo',
+          command: 'echo hello',
+          enabledWhen: { envSet: 'PATH' },
+        },
+      ],
+    });
+
+    const result = await new Runner(conditional, { repoPath, output }).run();
+
+    expect(result.gates.map((gate) => [gate.name, gate.status])).toEqual([
+      ['Deploy', 'skipped'],
+      ['Echo', 'passed'],
+    ]);
+    expect(result.gates[0]?.stdout).toBe('disabled by condition');
+  });
+
+  it('should run matrix instances together and report each', async () => {
+    // Each instance waits for the other's file, so both must run at once
+    const matrix = validateConfig({
+      maxParallel: 2,
+      qaGates: [
+        {
+          name: 'Shard',
+          command:
+            'touch shard-${matrix.n}; ' +
+            'until [ -f shard-1 ] && [ -f shard-2 ]; do sleep 0.05; done; ' +
+            'echo ${matrix.n}',
+          matrix: { n: [1, 2] },
+          timeout: 5000,
+        },
+      ],
+    });
+
+    const result = await new Runner(matrix, { repoPath, output }).run();
+
+    expect(
+      result.gates.map((gate) => [gate.name, gate.status, gate.stdout])
+    ).toEqual([
+      ['Shard [n=1]', 'passed', '1\n'],
+      ['Shard [n=2]', 'passed', '2\n'],
+    ]);
+  });
+
+  it('should pass unignored files as arguments and skip empty lists', async () => {
+    execFileSync('git', ['init', '-q'], { cwd: repoPath });
+    fs.mkdirSync(path.join(repoPath, 'vendor'));
+    for (const file of ['main.go', 'my file.go', 'vendor/dep.go']) {
+      fs.writeFileSync(path.join(repoPath, file), '');
+    }
+    fs.writeFileSync(path.join(repoPath, '.gitignore'), 'vendor/\n');
+    const filesets = validateConfig({
+      qaGates: [
+        { name: 'Go', command: "printf '%s\\n' ${files:*.go}" },
+        { name: 'Python', command: 'black --check ${files:*.py}' },
+      ],
+    });
+
+    const result = await new Runner(filesets, { repoPath, output }).run();
+
+    expect(result.gates.map((gate) => [gate.name, gate.status])).toEqual([
+      ['Go', 'passed'],
+      ['Python', 'skipped'],
+    ]);
+    expect(result.gates[0]?.stdout).toBe('main.go\nmy file.go\n');
+    expect(result.gates[1]?.stdout).toBe('No files matching *.py');
+  });
+
+  it('should report a timed-out gate with the output it wrote first', async () => {
+    const slow = validateConfig({
+      qaGates: [
+        { name: 'Tests', command: 'echo partial; sleep 5', timeout: 300 },
+      ],
+    });
+
+    const result = await new Runner(slow, { repoPath, output }).run();
+
+    expect(result.status).toBe('failed');
+    expect(result.exitCode).toBe(1);
+    expect(result.gates[0]).toMatchObject({
+      status: 'timedout',
+      timeoutMs: 300,
+      stdout: 'partial\n',
+      stderr: 'Command timed out after 300ms',
+    });
+    expect(result.totals).toMatchObject({ failed: 0, timedout: 1 });
+    expect(result.severities.error.failed).toEqual(['Tests']);
+  });
+
+  describe('stopping on failure', () => {
+    const stage = (settings: Record<string, unknown>) =>
+      validateConfig({
+        ...settings,
+        maxParallel: 2,
+        qaGates: [
+          { name: 'Lint', command: 'sleep 0.2; exit 1', order: 1 },
+          { name: 'Tests', command: 'sleep 5', order: 1 },
+          { name: 'Docs', command: 'true', order: 1 },
+        ],
+      });
+
+    it('should cancel the rest of the stage once an error gate fails', async () => {
+      const startedAt = Date.now();
+
+      const result = await new Runner(stage({}), { repoPath, output }).run();
+
+      expect(Date.now() - startedAt).toBeLessThan(4000);
+      expect(result.status).toBe('failed');
+      expect(result.gates.map((gate) => [gate.name, gate.status])).toEqual([
+        ['Lint', 'failed'],
+        ['Tests', 'cancelled'],
+        ['Docs', 'skipped'],
+      ]);
+      expect(result.gates[1]?.stderr).toBe('Command was cancelled');
+      expect(result.gates[2]?.stdout).toBe('Skipped because a gate failed');
+      expect(result.totals).toMatchObject({ failed: 1, cancelled: 1 });
+      expect(result.severities.error.failed).toEqual(['Lint']);
+    });
+
+    it('should cancel other branches once an error gate fails', async () => {
+      const graph = validateConfig({
+        qaGates: [
+          { name: 'Build', command: 'sleep 0.2; exit 1' },
+          { name: 'Lint', command: 'sleep 5' },
+          { name: 'Tests', command: 'true', dependsOn: ['Build'] },
+        ],
+      });
+      const startedAt = Date.now();
+
+      const result = await new Runner(graph, { repoPath, output }).run();
+
+      expect(Date.now() - startedAt).toBeLessThan(4000);
+      expect(result.gates.map((gate) => [gate.name, gate.status])).toEqual([
+        ['Build', 'failed'],
+        ['Lint', 'cancelled'],
+        ['Tests', 'skipped'],
+      ]);
+    });
+
+    it('should let the stage finish with continueOnFailure', async () => {
+      const config = stage({ continueOnFailure: true });
+      config.qaGates[1]!.command = 'sleep 0.4';
+
+      const result = await new Runner(config, { repoPath, output }).run();
+
+      expect(result.gates.map((gate) => gate.status)).toEqual([
+        'failed',
+        'passed',
+        'passed',
+      ]);
+    });
+  });
+
+  describe('beforeAll and afterAll', () => {
+    const run = (config: Record<string, unknown>, signal?: AbortSignal) =>
+      new Runner(validateConfig(config), { repoPath, output }).run(signal);
+
+    it('should run once around the gates and appear in the report', async () => {
+      const result = await run({
+        beforeAll: 'touch db-up',
+        afterAll: ['rm', 'db-up'],
+        qaGates: [
+          { name: 'Tests', command: 'test -f db-up', order: 1 },
+          { name: 'Lint', command: 'exit 1', order: 2 },
+        ],
+      });
+
+      expect(result.status).toBe('failed');
+      expect(result.gates.map((gate) => [gate.name, gate.status])).toEqual([
+        ['beforeAll', 'passed'],
+        ['Tests', 'passed'],
+        ['Lint', 'failed'],
+        ['afterAll', 'passed'],
+      ]);
+      expect(result.gates[0]?.severity).toBe('error');
+      expect(fs.existsSync(path.join(repoPath, 'db-up'))).toBe(false);
+    });
+
+    it('should skip the gates but still tear down when beforeAll fails', async () => {
+      const result = await run({
+        beforeAll: 'echo no docker >&2; exit 1',
+        afterAll: 'touch torn-down',
+        qaGates: [{ name: 'Tests', command: 'true' }],
+      });
+
+      expect(result.status).toBe('failed');
+      expect(result.gates[0]).toMatchObject({
+        name: 'beforeAll',
+        stderr: 'no docker\n',
+      });
+      expect(result.gates[1]).toMatchObject({
+        status: 'skipped',
+        skipReason: 'Skipped because beforeAll failed',
+      });
+      expect(fs.existsSync(path.join(repoPath, 'torn-down'))).toBe(true);
+    });
+
+    it('should run the gates after a beforeAll allowed to fail', async () => {
+      const result = await run({
+        beforeAll: { command: 'exit 1', failOnError: false },
+        qaGates: [{ name: 'Tests', command: 'true' }],
+      });
+
+      expect(result.status).toBe('passed');
+      expect(result.gates[1]?.status).toBe('passed');
+    });
+
+    it('should not let a failed afterAll fail the run unless asked to', async () => {
+      const qaGates = [{ name: 'Tests', command: 'true' }];
+
+      const tolerated = await run({ afterAll: 'exit 1', qaGates });
+      const strict = await run({
+        afterAll: { command: 'exit 1', failOnError: true },
+        qaGates,
+      });
+
+      expect(tolerated.status).toBe('passed');
+      expect(tolerated.gates[1]).toMatchObject({
+        name: 'afterAll',
+        status: 'failed',
+        severity: 'warning',
+      });
+      expect(strict.status).toBe('failed');
+    });
+
+    it('should run afterAll in a cancelled run', async () => {
+      const controller = new AbortController();
+      controller.abort();
+
+      const result = await run(
+        {
+          beforeAll: 'touch set-up',
+          afterAll: 'touch torn-down',
+          qaGates: [{ name: 'Tests', command: 'true' }],
+        },
+        controller.signal
+      );
+
+      expect(result.gates.map((gate) => gate.status)).toEqual([
+        'skipped',
+        'skipped',
+        'passed',
+      ]);
+      expect(fs.existsSync(path.join(repoPath, 'set-up'))).toBe(false);
+      expect(fs.existsSync(path.join(repoPath, 'torn-down'))).toBe(true);
+    });
+  });
+
+  it('should reject an unknown gate in the filter up front', () => {
+    expect(
+      () => new Runner(config, { repoPath, filter: { only: ['Nope'] } })
+    ).toThrow('Unknown gate "Nope" in filter');
+  });
+
+  it('should skip the gates of a cancelled run', async () => {
+    const controller = new AbortController();
+    controller.abort();
+
+    const result = await new Runner(config, { repoPath, output }).run(
+      controller.signal
+    );
+
+    expect(result.status).toBe('failed');
+    expect(result.gates.every((gate) => gate.status === 'skipped')).toBe(true);
+    expect(result.gates[0]?.stdout).toBe(
+      'Skipped because the run was cancelled'
+    );
+  });
+});
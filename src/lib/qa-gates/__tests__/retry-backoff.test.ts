@@ -0,0 +1,74 @@
+import { describe, it, expect } from 'vitest';
+import { computeBackoffDelay, sleep } from '../retry-backoff';
+
+describe('computeBackoffDelay', () => {
+  it('should not delay when no backoff is configured', () => {
+    expect(computeBackoffDelay(undefined, 1)).toBe(0);
+    expect(
+      computeBackoffDelay({ strategy: 'exponential', baseDelayMs: 0 }, 3)
+    ).toBe(0);
+  });
+
+  it('should use the base delay for every attempt with fixed', () => {
+    const backoff = { strategy: 'fixed' as const, baseDelayMs: 500 };
+
+    expect(computeBackoffDelay(backoff, 1)).toBe(500);
+    expect(computeBackoffDelay(backoff, 4)).toBe(500);
+  });
+
+  it('should grow linearly with linear', () => {
+    const backoff = { strategy: 'linear' as const, baseDelayMs: 200 };
+
+    expect(computeBackoffDelay(backoff, 1)).toBe(200);
+    expect(computeBackoffDelay(backoff, 3)).toBe(600);
+  });
+
+  it('should double per attempt with exponential and respect the cap', () => {
+    const backoff = {
+      strategy: 'exponential' as const,
+      baseDelayMs: 100,
+      maxDelayMs: 500,
+    };
+
+    expect(computeBackoffDelay(backoff, 1)).toBe(100);
+    expect(computeBackoffDelay(backoff, 2)).toBe(200);
+    expect(computeBackoffDelay(backoff, 3)).toBe(400);
+    expect(computeBackoffDelay(backoff, 4)).toBe(500);
+  });
+
+  it('should keep jittered delays within the upper half of the delay', () => {
+    const backoff = {
+      strategy: 'fixed' as const,
+      baseDelayMs: 1000,
+      jitter: true,
+    };
+
+    expect(computeBackoffDelay(backoff, 1, () => 0)).toBe(500);
+    expect(computeBackoffDelay(backoff, 1, () => 0.5)).toBe(750);
+    expect(computeBackoffDelay(backoff, 1, () => 1)).toBe(1000);
+  });
+});
+
+describe('sleep', () => {
+  it('should resolve immediately for a zero delay', async () => {
+    await expect(sleep(0)).resolves.toBeUndefined();
+  });
+
+  it('should reject as soon as the signal is aborted', async () => {
+    const controller = new AbortController();
+    const start = Date.now();
+    const pending = sleep(10000, controller.signal);
+
+    controller.abort(new Error('interrupted'));
+
+    await expect(pending).rejects.toThrow('interrupted');
+    expect(Date.now() - start).toBeLessThan(1000);
+  });
+
+  it('should reject when the signal is already aborted', async () => {
+    const controller = new AbortController();
+    controller.abort(new Error('cancelled'));
+
+    await expect(sleep(0, controller.signal)).rejects.toThrow('cancelled');
+  });
+});
@@ -0,0 +1,33 @@
+import { describe, it, expect } from 'vitest';
+import { isInsideRoot, parseRunDirs, runDirsError } from '../run-dirs';
+
+describe('run dirs', () => {
+  it('should accept directories inside the repository', () => {
+    expect(isInsideRoot('.forge-history', '/repo')).toBe(true);
+    expect(isInsideRoot('out/../artifacts', '/repo')).toBe(true);
+    expect(isInsideRoot('..cache', '/repo')).toBe(true);
+    expect(isInsideRoot('/repo/out', '/repo')).toBe(true);
+    expect(isInsideRoot('.', '/repo')).toBe(true);
+  });
+
+  it('should reject directories resolving outside the repository', () => {
+    expect(isInsideRoot('..', '/repo')).toBe(false);
+    expect(isInsideRoot('../other', '/repo')).toBe(false);
+    expect(isInsideRoot('out/../../etc', '/repo')).toBe(false);
+    expect(isInsideRoot('/tmp', '/repo')).toBe(false);
+    expect(isInsideRoot('/repository', '/repo')).toBe(false);
+  });
+
+  it('should name the first run directory outside the repository', () => {
+    const dirs = parseRunDirs(
+      new URLSearchParams('artifactsDir=out&historyDir=/var/forge')
+    );
+
+    expect(dirs).toEqual({ artifactsDir: 'out', historyDir: '/var/forge' });
+    expect(runDirsError(dirs, '/repo')).toBe(
+      'historyDir must be inside the repository'
+    );
+    expect(runDirsError({ artifactsDir: 'out' }, '/repo')).toBeNull();
+    expect(runDirsError({}, '/repo')).toBeNull();
+  });
+});
@@ -2,6 +2,12 @@ import { describe, it, expect, vi, beforeEach } from 'vitest';
 import { orchestrateQAGates } from '../run-orchestrator';
 import type { QAGateConfig } from '../config-loader';
 import * as gateExecutor from '../gate-executor';
+import * as runReport from '../run-report';
+import * as metrics from '../metrics';
+import * as notify from '../notify';
+import * as statusService from '../status-service';
+import * as changedFiles from '../changed-files';
+import { createMemoryRunStore, databaseRunStore } from '../run-store';
 
 // Mock dependencies
 vi.mock('@/db', () => {
@@ -15,10 +21,16 @@ vi.mock('@/db', () => {
 
 vi.mock('drizzle-orm', () => ({
   eq: vi.fn((field, value) => ({ field, value })),
+  desc: vi.fn(),
   relations: vi.fn(),
 }));
 
 vi.mock('../gate-executor');
+vi.mock('../run-report');
+vi.mock('../metrics');
+vi.mock('../notify');
+vi.mock('../status-service');
+vi.mock('../changed-files');
 
 describe('Run Orchestrator', () => {
   const mockGates: QAGateConfig[] = [
@@ -116,6 +128,50 @@ describe('Run Orchestrator', () => {
     });
   });
 
+  describe('continueOnFailure', () => {
+    beforeEach(() => {
+      vi.spyOn(gateExecutor, 'executeGate').mockImplementation(
+        async ({ gate }) => ({
+          id: `exec-${gate.name}`,
+          gateName: gate.name,
+          status: gate.name === 'ESLint' ? 'failed' : 'passed',
+          duration: 100,
+        })
+      );
+    });
+
+    it('should run later stages and fail the run at the end', async () => {
+      const { db } = await import('@/db');
+
+      const status = await orchestrateQAGates({
+        runId: 'run-123',
+        repoPath: '/test/repo',
+        gates: mockGates,
+        settings: { continueOnFailure: true },
+      });
+
+      expect(gateExecutor.executeGate).toHaveBeenCalledTimes(3);
+      expect(status).toBe('failed');
+      expect((db as any).set).toHaveBeenCalledWith({
+        status: 'failed',
+        duration: expect.any(Number),
+        completedAt: expect.any(Date),
+      });
+    });
+
+    it('should let the run option override the config', async () => {
+      await orchestrateQAGates({
+        runId: 'run-123',
+        repoPath: '/test/repo',
+        gates: mockGates,
+        settings: { continueOnFailure: true },
+        continueOnFailure: false,
+      });
+
+      expect(gateExecutor.executeGate).toHaveBeenCalledTimes(2);
+    });
+  });
+
   it('should continue execution when gate fails but failOnError is false', async () => {
     const { db } = await import('@/db');
 
@@ -184,6 +240,32 @@ describe('Run Orchestrator', () => {
     });
   });
 
+  it('should treat warning gates as errors in a strict run', async () => {
+    const { db } = await import('@/db');
+
+    vi.spyOn(gateExecutor, 'executeGate').mockResolvedValueOnce({
+      id: 'exec-1',
+      gateName: 'Docs',
+      status: 'failed',
+      duration: 500,
+    });
+
+    await orchestrateQAGates({
+      runId: 'run-123',
+      repoPath: '/test/repo',
+      gates: [
+        { ...mockGates[0]!, name: 'Docs', failOnError: false, order: 1 },
+        { ...mockGates[1]!, order: 2 },
+      ],
+      strict: true,
+    });
+
+    expect(gateExecutor.executeGate).toHaveBeenCalledTimes(1);
+    expect((db as any).set).toHaveBeenCalledWith(
+      expect.objectContaining({ status: 'failed' })
+    );
+  });
+
   it('should only execute enabled gates', async () => {
     const gatesWithDisabled: QAGateConfig[] = [
       {
@@ -283,6 +365,9 @@ describe('Run Orchestrator', () => {
       runId,
       gate: mockGates[0]!,
       repoPath,
+      files: { tracked: null, changed: null },
+      signal: expect.any(AbortSignal),
+      store: databaseRunStore,
     });
   });
 
@@ -392,4 +477,473 @@ describe('Run Orchestrator', () => {
       completedAt: expect.any(Date),
     });
   });
+  it('should finish a parallel stage before stopping on failure', async () => {
+    const { db } = await import('@/db');
+
+    const parallelGates: QAGateConfig[] = ['fmt', 'vet', 'license'].map(
+      (name) => ({
+        name,
+        command: `make ${name}`,
+        timeout: 30000,
+        enabled: true,
+        failOnError: true,
+        order: 1,
+      })
+    );
+    parallelGates.push({
+      name: 'test',
+      command: 'make test',
+      timeout: 30000,
+      enabled: true,
+      failOnError: true,
+      order: 2,
+    });
+
+    vi.spyOn(gateExecutor, 'executeGate').mockImplementation(
+      async ({ gate }) => ({
+        id: `exec-${gate.name}`,
+        gateName: gate.name,
+        status: gate.name === 'fmt' ? 'failed' : 'passed',
+        duration: 100,
+      })
+    );
+
+    await orchestrateQAGates({
+      runId: 'run-123',
+      repoPath: '/test/repo',
+      gates: parallelGates,
+    });
+
+    // The whole first stage runs, the second stage is never started
+    expect(gateExecutor.executeGate).toHaveBeenCalledTimes(3);
+    expect((db as any).set).toHaveBeenCalledWith({
+      status: 'failed',
+      duration: expect.any(Number),
+      completedAt: expect.any(Date),
+    });
+  });
+  it('should skip dependents of a failed gate when using dependsOn', async () => {
+    const { db } = await import('@/db');
+
+    const graphGate = (name: string, dependsOn?: string[]): QAGateConfig => ({
+      name,
+      command: `make ${name.toLowerCase()}`,
+      timeout: 30000,
+      enabled: true,
+      failOnError: true,
+      dependsOn,
+    });
+    const graphGates = [
+      graphGate('Build'),
+      graphGate('Tests', ['Build']),
+      graphGate('Lint'),
+    ];
+
+    vi.spyOn(gateExecutor, 'executeGate').mockImplementation(
+      async ({ gate }) => ({
+        id: `exec-${gate.name}`,
+        gateName: gate.name,
+        status: gate.name === 'Build' ? 'failed' : 'passed',
+        duration: 100,
+      })
+    );
+    vi.spyOn(gateExecutor, 'skipGate').mockImplementation(async ({ gate }) => ({
+      id: `skip-${gate.name}`,
+      gateName: gate.name,
+      status: 'skipped',
+      duration: 0,
+    }));
+
+    await orchestrateQAGates({
+      runId: 'run-123',
+      repoPath: '/test/repo',
+      gates: graphGates,
+    });
+
+    expect(gateExecutor.executeGate).toHaveBeenCalledTimes(2);
+    expect(gateExecutor.skipGate).toHaveBeenCalledWith({
+      runId: 'run-123',
+      gate: graphGates[1],
+      reason: 'Skipped because a dependency failed',
+      store: databaseRunStore,
+    });
+    expect((db as any).set).toHaveBeenCalledWith({
+      status: 'failed',
+      duration: expect.any(Number),
+      completedAt: expect.any(Date),
+    });
+  });
+  describe('continueOnFailure with dependsOn', () => {
+    const graphGates: QAGateConfig[] = [
+      {
+        name: 'Build',
+        command: 'make build',
+        enabled: true,
+        failOnError: true,
+      },
+      {
+        name: 'Lint',
+        command: 'make lint',
+        enabled: true,
+        failOnError: true,
+      },
+      {
+        name: 'Tests',
+        command: 'make test',
+        enabled: true,
+        failOnError: true,
+        dependsOn: ['Build'],
+      },
+    ];
+
+    beforeEach(() => {
+      vi.spyOn(gateExecutor, 'executeGate').mockImplementation(
+        async ({ gate }) => ({
+          id: `exec-${gate.name}`,
+          gateName: gate.name,
+          status: gate.name === 'Build' ? 'failed' : 'passed',
+          duration: 100,
+        })
+      );
+      vi.spyOn(gateExecutor, 'skipGate').mockImplementation(
+        async ({ gate }) => ({
+          id: `skip-${gate.name}`,
+          gateName: gate.name,
+          status: 'skipped',
+          duration: 0,
+        })
+      );
+    });
+
+    it('should skip the gates not started yet after a failure', async () => {
+      await orchestrateQAGates({
+        runId: 'run-123',
+        repoPath: '/test/repo',
+        gates: graphGates,
+        settings: { maxParallel: 1 },
+      });
+
+      expect(gateExecutor.executeGate).toHaveBeenCalledTimes(1);
+      expect(gateExecutor.skipGate).toHaveBeenCalledWith({
+        runId: 'run-123',
+        gate: graphGates[1],
+        reason: 'Skipped because a gate failed',
+        store: databaseRunStore,
+      });
+    });
+
+    it('should keep running unrelated branches when set', async () => {
+      await orchestrateQAGates({
+        runId: 'run-123',
+        repoPath: '/test/repo',
+        gates: graphGates,
+        settings: { maxParallel: 1 },
+        continueOnFailure: true,
+      });
+
+      expect(gateExecutor.executeGate).toHaveBeenCalledTimes(2);
+      expect(gateExecutor.skipGate).toHaveBeenCalledTimes(1);
+    });
+  });
+  it('should write the JSON report even when the run fails', async () => {
+    vi.spyOn(gateExecutor, 'executeGate').mockResolvedValue({
+      id: 'exec-1',
+      gateName: 'TypeScript Check',
+      status: 'failed',
+      duration: 500,
+    });
+    vi.spyOn(statusService, 'getGateExecutions').mockResolvedValue([]);
+    vi.spyOn(runReport, 'buildRunResult').mockReturnValue({} as any);
+
+    await orchestrateQAGates({
+      runId: 'run-123',
+      repoPath: '/test/repo',
+      gates: mockGates,
+      settings: { reportJson: 'qa-report.json' },
+    });
+
+    expect(runReport.buildRunResult).toHaveBeenCalledWith(
+      expect.objectContaining({ id: 'run-123', status: 'failed' }),
+      [],
+      mockGates
+    );
+    expect(runReport.writeRunResult).toHaveBeenCalledWith(
+      'qa-report.json',
+      '/test/repo',
+      {}
+    );
+  });
+
+  it('should not write a report unless configured', async () => {
+    vi.spyOn(gateExecutor, 'executeGate').mockResolvedValue({
+      id: 'exec-1',
+      gateName: 'TypeScript Check',
+      status: 'passed',
+      duration: 500,
+    });
+
+    await orchestrateQAGates({
+      runId: 'run-123',
+      repoPath: '/test/repo',
+      gates: mockGates,
+    });
+
+    expect(runReport.writeRunResult).not.toHaveBeenCalled();
+    expect(metrics.writeMetrics).not.toHaveBeenCalled();
+  });
+
+  it('should write metrics when metricsOut is configured', async () => {
+    vi.spyOn(gateExecutor, 'executeGate').mockResolvedValue({
+      id: 'exec-1',
+      gateName: 'TypeScript Check',
+      status: 'passed',
+      duration: 500,
+    });
+    vi.spyOn(statusService, 'getGateExecutions').mockResolvedValue([]);
+    vi.spyOn(runReport, 'buildRunResult').mockReturnValue({} as any);
+
+    await orchestrateQAGates({
+      runId: 'run-123',
+      repoPath: '/test/repo',
+      gates: mockGates,
+      settings: { metricsOut: '/var/lib/node_exporter/forge.prom' },
+    });
+
+    expect(metrics.writeMetrics).toHaveBeenCalledWith(
+      '/var/lib/node_exporter/forge.prom',
+      '/test/repo',
+      {}
+    );
+    expect(runReport.writeRunResult).not.toHaveBeenCalled();
+  });
+
+  describe('notify', () => {
+    const settings = {
+      notify: { webhookURL: 'https://hooks.example.com/x', when: 'failure' },
+    } as const;
+
+    beforeEach(() => {
+      vi.spyOn(gateExecutor, 'executeGate').mockResolvedValue({
+        id: 'exec-1',
+        gateName: 'TypeScript Check',
+        status: 'failed',
+        duration: 500,
+      });
+      vi.spyOn(statusService, 'getGateExecutions').mockResolvedValue([]);
+      vi.spyOn(runReport, 'buildRunResult').mockReturnValue({} as any);
+    });
+
+    it('should send the notification once the run finishes', async () => {
+      await orchestrateQAGates({
+        runId: 'run-123',
+        repoPath: '/test/repo',
+        gates: mockGates,
+        settings,
+      });
+
+      expect(notify.sendNotification).toHaveBeenCalledWith(
+        settings.notify,
+        {},
+        settings
+      );
+    });
+
+    it('should send it even when the report fails to write', async () => {
+      vi.mocked(runReport.writeRunResult).mockRejectedValueOnce(
+        new Error('EACCES')
+      );
+
+      await orchestrateQAGates({
+        runId: 'run-123',
+        repoPath: '/test/repo',
+        gates: mockGates,
+        settings: { ...settings, reportJson: 'report.json' },
+      });
+
+      expect(notify.sendNotification).toHaveBeenCalled();
+    });
+
+    it('should not send it with notify false', async () => {
+      await orchestrateQAGates({
+        runId: 'run-123',
+        repoPath: '/test/repo',
+        gates: mockGates,
+        settings,
+        notify: false,
+      });
+
+      expect(notify.sendNotification).not.toHaveBeenCalled();
+      expect(statusService.getGateExecutions).not.toHaveBeenCalled();
+    });
+  });
+
+  it('should skip gates whose changedFilesGlob matches no changed file', async () => {
+    const goGate = { ...mockGates[2]!, changedFilesGlob: '**/*.go' };
+    vi.mocked(changedFiles.usesChangedFiles).mockReturnValue(true);
+    vi.mocked(changedFiles.listChangedFiles).mockResolvedValue(['README.md']);
+    vi.mocked(changedFiles.unchangedSkipReason).mockImplementation((gate) =>
+      gate.changedFilesGlob ? 'No changes matching **/*.go' : null
+    );
+    vi.spyOn(gateExecutor, 'executeGate').mockImplementation(
+      async ({ gate }) => ({
+        id: `exec-${gate.name}`,
+        gateName: gate.name,
+        status: 'passed',
+        duration: 100,
+      })
+    );
+
+    await orchestrateQAGates({
+      runId: 'run-123',
+      repoPath: '/test/repo',
+      gates: [mockGates[0]!, goGate],
+      since: 'origin/main',
+    });
+
+    expect(changedFiles.listChangedFiles).toHaveBeenCalledWith(
+      '/test/repo',
+      'origin/main'
+    );
+    expect(gateExecutor.executeGate).toHaveBeenCalledTimes(1);
+    expect(gateExecutor.skipGate).toHaveBeenCalledWith({
+      runId: 'run-123',
+      gate: goGate,
+      reason: 'No changes matching **/*.go',
+      store: databaseRunStore,
+    });
+  });
+
+  describe('library options', () => {
+    it('should record into the given store and report to the hooks', async () => {
+      const store = createMemoryRunStore();
+      const execution = await store.insertExecution({
+        runId: 'run-lib',
+        gateName: 'TypeScript Check',
+        command: 'tsc --noEmit',
+        status: 'failed',
+        exitCode: 2,
+        order: 1,
+      });
+      vi.spyOn(gateExecutor, 'executeGate').mockResolvedValue({
+        id: execution!.id,
+        gateName: 'TypeScript Check',
+        status: 'failed',
+        duration: 500,
+      });
+      const gateResult = { name: 'TypeScript Check', status: 'failed' };
+      vi.spyOn(runReport, 'toGateRunResult').mockReturnValue(gateResult as any);
+      const started: string[] = [];
+      const finished: unknown[] = [];
+
+      const status = await orchestrateQAGates({
+        runId: 'run-lib',
+        repoPath: '/test/repo',
+        gates: [mockGates[0]!],
+        store,
+        hooks: {
+          onGateStart: (gate) => started.push(gate.name),
+          onGateFinish: (result) => finished.push(result),
+        },
+      });
+
+      expect(status).toBe('failed');
+      expect(gateExecutor.executeGate).toHaveBeenCalledWith(
+        expect.objectContaining({ store })
+      );
+      expect(started).toEqual(['TypeScript Check']);
+      expect(runReport.toGateRunResult).toHaveBeenCalledWith(
+        expect.objectContaining({ id: execution!.id, exitCode: 2 }),
+        'error'
+      );
+      expect(finished).toEqual([gateResult]);
+    });
+
+    it('should not let a throwing hook break the run', async () => {
+      vi.spyOn(gateExecutor, 'executeGate').mockResolvedValue({
+        id: 'exec-id',
+        gateName: 'TypeScript Check',
+        status: 'passed',
+        duration: 100,
+      });
+
+      const status = await orchestrateQAGates({
+        runId: 'run-lib',
+        repoPath: '/test/repo',
+        gates: mockGates,
+        store: createMemoryRunStore(),
+        hooks: {
+          onGateStart: () => {
+            throw new Error('hook failed');
+          },
+        },
+      });
+
+      expect(status).toBe('passed');
+      expect(gateExecutor.executeGate).toHaveBeenCalledTimes(3);
+    });
+
+    it('should skip every gate and store the run as cancelled once aborted', async () => {
+      const { db } = await import('@/db');
+      const controller = new AbortController();
+      controller.abort();
+
+      await orchestrateQAGates({
+        runId: 'run-lib',
+        repoPath: '/test/repo',
+        gates: [mockGates[0]!],
+        signal: controller.signal,
+      });
+
+      expect(gateExecutor.executeGate).not.toHaveBeenCalled();
+      expect(gateExecutor.skipGate).toHaveBeenCalledWith(
+        expect.objectContaining({
+          reason: 'Skipped because the run was cancelled',
+        })
+      );
+      expect((db as any).set).toHaveBeenCalledWith(
+        expect.objectContaining({ status: 'cancelled' })
+      );
+    });
+
+    it('should report a cancelled run as interrupted', async () => {
+      const controller = new AbortController();
+      controller.abort();
+      vi.spyOn(statusService, 'getGateExecutions').mockResolvedValue([]);
+      vi.spyOn(runReport, 'buildRunResult').mockReturnValue({} as any);
+
+      await orchestrateQAGates({
+        runId: 'run-lib',
+        repoPath: '/test/repo',
+        gates: [mockGates[0]!],
+        settings: { reportJson: 'qa-report.json' },
+        signal: controller.signal,
+      });
+
+      expect(runReport.buildRunResult).toHaveBeenCalledWith(
+        expect.objectContaining({ id: 'run-lib', interrupted: true }),
+        [],
+        [mockGates[0]]
+      );
+    });
+
+    it('should write status lines to the given output', async () => {
+      vi.spyOn(gateExecutor, 'executeGate').mockResolvedValue({
+        id: 'exec-id',
+        gateName: 'TypeScript Check',
+        status: 'passed',
+        duration: 100,
+      });
+      const lines: string[] = [];
+
+      await orchestrateQAGates({
+        runId: 'run-lib',
+        repoPath: '/test/repo',
+        gates: [mockGates[0]!],
+        store: createMemoryRunStore(),
+        output: { stdout: (text) => lines.push(text), stderr: () => {} },
+      });
+
+      expect(lines.join('')).toContain('TypeScript Check');
+    });
+  });
 });
@@ -0,0 +1,127 @@
+import { describe, it, expect, afterEach } from 'vitest';
+import {
+  DEFAULT_MAX_CONCURRENT_RUNS,
+  drainRuns,
+  enqueueRun,
+  maxConcurrentRuns,
+  reopenRunQueue,
+  runQueueStatus,
+} from '../run-queue';
+
+function deferred() {
+  let resolve: () => void = () => {};
+  const promise = new Promise<void>((done) => (resolve = done));
+  return { promise, resolve };
+}
+
+const tick = () => new Promise((resolve) => setTimeout(resolve, 0));
+
+describe('maxConcurrentRuns', () => {
+  it('should read FORGE_MAX_CONCURRENT_RUNS', () => {
+    expect(maxConcurrentRuns({ FORGE_MAX_CONCURRENT_RUNS: '3' })).toBe(3);
+  });
+
+  it('should fall back to the default for unset or invalid values', () => {
+    expect(maxConcurrentRuns({})).toBe(DEFAULT_MAX_CONCURRENT_RUNS);
+    expect(maxConcurrentRuns({ FORGE_MAX_CONCURRENT_RUNS: '0' })).toBe(1);
+    expect(maxConcurrentRuns({ FORGE_MAX_CONCURRENT_RUNS: 'two' })).toBe(1);
+  });
+});
+
+describe('enqueueRun', () => {
+  afterEach(() => {
+    delete process.env.FORGE_MAX_CONCURRENT_RUNS;
+    reopenRunQueue();
+  });
+
+  it('should run one at a time by default, in order', async () => {
+    const first = deferred();
+    const order: string[] = [];
+
+    const runs = [
+      enqueueRun(async () => {
+        order.push('first');
+        await first.promise;
+      }),
+      enqueueRun(async () => {
+        order.push('second');
+      }),
+    ];
+    await tick();
+
+    expect(order).toEqual(['first']);
+    expect(runQueueStatus()).toEqual({
+      running: 1,
+      queued: 1,
+      accepting: true,
+    });
+
+    first.resolve();
+    await Promise.all(runs);
+    expect(order).toEqual(['first', 'second']);
+    expect(runQueueStatus().running).toBe(0);
+  });
+
+  it('should allow FORGE_MAX_CONCURRENT_RUNS runs at once', async () => {
+    process.env.FORGE_MAX_CONCURRENT_RUNS = '2';
+    const gate = deferred();
+    let active = 0;
+    let peak = 0;
+    const task = async () => {
+      peak = Math.max(peak, ++active);
+      await gate.promise;
+      active--;
+    };
+
+    const runs = [enqueueRun(task), enqueueRun(task), enqueueRun(task)];
+    await tick();
+    expect(runQueueStatus()).toMatchObject({ running: 2, queued: 1 });
+
+    gate.resolve();
+    await Promise.all(runs);
+    expect(peak).toBe(2);
+  });
+
+  it('should free the slot when a run throws', async () => {
+    await expect(
+      enqueueRun(async () => {
+        throw new Error('boom');
+      })
+    ).rejects.toThrow('boom');
+
+    expect(await enqueueRun(async () => 'next')).toBe('next');
+  });
+});
+
+describe('drainRuns', () => {
+  afterEach(() => {
+    reopenRunQueue();
+  });
+
+  it('should wait for running and queued runs, then refuse new ones', async () => {
+    const first = deferred();
+    let finished = 0;
+    enqueueRun(async () => {
+      await first.promise;
+      finished++;
+    });
+    enqueueRun(async () => {
+      finished++;
+    });
+
+    let drained = false;
+    const drain = drainRuns().then(() => (drained = true));
+    await tick();
+    expect(drained).toBe(false);
+    expect(runQueueStatus().accepting).toBe(false);
+    await expect(enqueueRun(async () => {})).rejects.toThrow(/shutting down/);
+
+    first.resolve();
+    await drain;
+    expect(finished).toBe(2);
+  });
+
+  it('should resolve at once when nothing is running', async () => {
+    await expect(drainRuns()).resolves.toBeUndefined();
+  });
+});
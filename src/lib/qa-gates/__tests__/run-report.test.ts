@@ -0,0 +1,266 @@
+import { describe, it, expect, vi, beforeEach } from 'vitest';
+import fs from 'fs/promises';
+import {
+  buildRunResult,
+  RUN_RESULT_SCHEMA_VERSION,
+  storedRunSummary,
+  writeRunResult,
+} from '../run-report';
+
+vi.mock('fs/promises', () => ({
+  default: {
+    mkdir: vi.fn(),
+    writeFile: vi.fn(),
+  },
+}));
+
+function execution(overrides: Record<string, unknown>) {
+  return {
+    id: 'exec',
+    runId: 'run-1',
+    gateName: 'Gate',
+    command: 'true',
+    status: 'passed',
+    output: null,
+    error: null,
+    exitCode: 0,
+    duration: 100,
+    startedAt: new Date('2024-01-01T00:00:00Z'),
+    completedAt: new Date('2024-01-01T00:00:01Z'),
+    order: 1,
+    ...overrides,
+  } as Parameters<typeof buildRunResult>[1][number];
+}
+
+describe('buildRunResult', () => {
+  const run = {
+    id: 'run-1',
+    status: 'failed' as const,
+    startedAt: new Date('2024-01-01T00:00:00Z'),
+    completedAt: new Date('2024-01-01T00:00:05Z'),
+    duration: 5000,
+  };
+
+  it('should aggregate gate outcomes and totals', () => {
+    const result = buildRunResult(run, [
+      execution({ gateName: 'Lint', command: 'npm run lint' }),
+      execution({ gateName: 'Tests', status: 'failed', exitCode: 1 }),
+      execution({ gateName: 'E2E', status: 'skipped', exitCode: null }),
+    ]);
+
+    expect(result).toMatchObject({
+      schemaVersion: RUN_RESULT_SCHEMA_VERSION,
+      runId: 'run-1',
+      status: 'failed',
+      startedAt: '2024-01-01T00:00:00.000Z',
+      finishedAt: '2024-01-01T00:00:05.000Z',
+      durationMs: 5000,
+      exitCode: 1,
+      totals: { gates: 3, passed: 1, failed: 1, skipped: 1, timedout: 0 },
+    });
+    expect(result.gates[0]).toEqual({
+      name: 'Lint',
+      command: 'npm run lint',
+      status: 'passed',
+      severity: null,
+      exitCode: 0,
+      durationMs: 100,
+      timeoutMs: null,
+      attempts: 1,
+      attemptDetails: [
+        {
+          attempt: 1,
+          exitCode: 0,
+          durationMs: 100,
+          stdout: null,
+          stderr: null,
+        },
+      ],
+      stdout: null,
+      stderr: null,
+    });
+  });
+
+  it('should group gates by the severity configured for them', () => {
+    const result = buildRunResult(
+      run,
+      [
+        execution({ gateName: 'Lint' }),
+        execution({ gateName: 'Docs', status: 'failed' }),
+        execution({ gateName: 'Size', status: 'failed' }),
+        execution({ gateName: 'Removed', status: 'failed' }),
+      ],
+      [
+        { name: 'Lint', failOnError: true },
+        { name: 'Docs', failOnError: false },
+        { name: 'Size', failOnError: true, severity: 'info' },
+      ]
+    );
+
+    expect(result.gates.map((gate) => gate.severity)).toEqual([
+      'error',
+      'warning',
+      'info',
+      null,
+    ]);
+    expect(result.severities).toEqual({
+      error: { gates: 1, failed: [] },
+      warning: { gates: 1, failed: ['Docs'] },
+      info: { gates: 1, failed: ['Size'] },
+    });
+  });
+
+  it('should include captured stdout and stderr', () => {
+    const result = buildRunResult(run, [
+      execution({ status: 'failed', output: 'ran 3 tests\n', error: 'FAIL\n' }),
+    ]);
+
+    expect(result.gates[0]).toMatchObject({
+      stdout: 'ran 3 tests\n',
+      stderr: 'FAIL\n',
+    });
+  });
+
+  it('should list every attempt of a retried gate', () => {
+    const first = {
+      attempt: 1,
+      exitCode: 1,
+      durationMs: 300,
+      stdout: 'flaky',
+      stderr: null,
+    };
+    const result = buildRunResult(run, [
+      execution({ duration: 500, output: 'ok', previousAttempts: [first] }),
+    ]);
+
+    expect(result.gates[0]?.attempts).toBe(2);
+    expect(result.gates[0]?.durationMs).toBe(500);
+    expect(result.gates[0]?.attemptDetails).toEqual([
+      first,
+      { attempt: 2, exitCode: 0, durationMs: 200, stdout: 'ok', stderr: null },
+    ]);
+  });
+
+  it('should not count the backoff before a retry in its attempt', () => {
+    const first = {
+      attempt: 1,
+      exitCode: 1,
+      durationMs: 300,
+      stdout: 'flaky',
+      stderr: null,
+      backoffMs: 1000,
+    };
+    const result = buildRunResult(run, [
+      execution({ duration: 1500, output: 'ok', previousAttempts: [first] }),
+    ]);
+
+    expect(result.gates[0]?.attemptDetails[1]?.durationMs).toBe(200);
+    expect(result.timing?.gates[0]).toMatchObject({
+      retryMs: 200,
+      backoffMs: 1000,
+    });
+  });
+
+  it('should list gates in config order whatever order they started in', () => {
+    const configGates = ['Lint', 'Types', 'Tests'].map((name) => ({
+      name,
+      failOnError: true,
+    }));
+    const result = buildRunResult(
+      run,
+      [
+        execution({ gateName: 'Tests' }),
+        execution({ gateName: 'Gone' }),
+        execution({ gateName: 'Lint' }),
+        execution({ gateName: 'Build', order: 0 }),
+        execution({ gateName: 'Types' }),
+      ],
+      configGates
+    );
+
+    expect(result.gates.map((gate) => gate.name)).toEqual([
+      'Build',
+      'Lint',
+      'Types',
+      'Tests',
+      'Gone',
+    ]);
+  });
+
+  it('should tell skipped gates apart from failed ones', () => {
+    const result = buildRunResult(run, [
+      execution({ gateName: 'Build', status: 'failed', exitCode: 1 }),
+      execution({
+        gateName: 'Tests',
+        status: 'skipped',
+        output: 'Skipped because a dependency failed',
+        exitCode: null,
+      }),
+    ]);
+
+    expect(result.gates[0]?.skipReason).toBeUndefined();
+    expect(result.gates[1]).toMatchObject({
+      status: 'skipped',
+      skipReason: 'Skipped because a dependency failed',
+    });
+  });
+
+  it('should give the run the exit code for its outcome', () => {
+    const gates = [execution({})];
+
+    expect(
+      buildRunResult({ ...run, status: 'passed' }, gates).exitCode
+    ).toBe(0);
+    expect(buildRunResult(run, gates).exitCode).toBe(1);
+    expect(
+      buildRunResult({ ...run, interrupted: true }, gates).exitCode
+    ).toBe(130);
+    expect(
+      buildRunResult({ ...run, status: 'running' }, gates).exitCode
+    ).toBeNull();
+  });
+
+  it('should read a stored cancelled run back as interrupted', () => {
+    const stored = storedRunSummary({ ...run, status: 'cancelled' });
+    const result = buildRunResult(stored, [execution({})]);
+
+    expect(result.status).toBe('failed');
+    expect(result.exitCode).toBe(130);
+    expect(storedRunSummary(run)).toBe(run);
+  });
+
+  it('should report unfinished gates as skipped', () => {
+    const result = buildRunResult(
+      { ...run, status: 'running', completedAt: null, duration: null },
+      [execution({ status: 'running', exitCode: null, duration: null })]
+    );
+
+    expect(result.finishedAt).toBeNull();
+    expect(result.gates[0]?.status).toBe('skipped');
+    expect(result.gates[0]?.durationMs).toBe(0);
+  });
+});
+
+describe('writeRunResult', () => {
+  beforeEach(() => {
+    vi.clearAllMocks();
+  });
+
+  it('should write JSON relative to the root', async () => {
+    const result = buildRunResult(
+      { id: 'run-1', status: 'passed', startedAt: new Date(0) },
+      []
+    );
+
+    await writeRunResult('reports/qa.json', '/workspace/repo', result);
+
+    expect(fs.mkdir).toHaveBeenCalledWith('/workspace/repo/reports', {
+      recursive: true,
+    });
+    expect(fs.writeFile).toHaveBeenCalledWith(
+      '/workspace/repo/reports/qa.json',
+      JSON.stringify(result, null, 2) + '\n',
+      'utf-8'
+    );
+  });
+});
@@ -1,4 +1,4 @@
-import { describe, it, expect, vi } from 'vitest';
+import { describe, it, expect, vi, beforeEach, afterEach } from 'vitest';
 
 // Mock dependencies to prevent DB/module initialization from hanging
 vi.mock('@/db', () => ({
@@ -32,6 +32,251 @@ vi.mock('../command-executor', () => ({
 }));
 
 describe('QA Gate Runner', () => {
+  describe('retries', () => {
+    const gates = [
+      {
+        name: 'Format',
+        enabled: true,
+        command: 'gofmt -l .',
+        timeout: 30000,
+        failOnError: true,
+        order: 1,
+      },
+      {
+        name: 'Test',
+        enabled: true,
+        command: 'go test ./...',
+        timeout: 30000,
+        failOnError: true,
+        order: 2,
+      },
+    ];
+
+    beforeEach(() => {
+      vi.spyOn(console, 'log').mockImplementation(() => {});
+    });
+
+    afterEach(() => {
+      vi.restoreAllMocks();
+    });
+
+    it('should reuse the results in passedGates and store them again', async () => {
+      const { db } = await import('@/db');
+      const { loadRepositoryConfig } = await import('../config-loader');
+      const { execAsync } = await import('../command-executor');
+      const { runQAGates } = await import('../runner');
+
+      vi.mocked(loadRepositoryConfig).mockResolvedValue({ qaGates: gates });
+      vi.mocked(execAsync).mockReset();
+      vi.mocked(execAsync).mockResolvedValue({ stdout: 'ok', stderr: '' });
+      vi.mocked(db.insert).mockClear();
+      const formatResult = {
+        gateName: 'Format',
+        status: 'passed' as const,
+        output: 'earlier',
+        duration: 10,
+      };
+
+      const results = await runQAGates(
+        'task-1',
+        '/repo',
+        new Map([['Format', formatResult]])
+      );
+
+      expect(results[0]).toEqual(formatResult);
+      expect(vi.mocked(execAsync).mock.calls.map(([cmd]) => cmd)).toEqual([
+        'go test ./...',
+      ]);
+      expect(db.insert).toHaveBeenCalledTimes(2);
+    });
+  });
+
+  describe('runFailureFixes', () => {
+    const formatGate = {
+      name: 'Format',
+      enabled: true,
+      command: 'gofmt -l .',
+      onFailure: 'gofmt -w .',
+      failIfOutputMatches: '\\S',
+      failOnError: true,
+      order: 1,
+    };
+    const failed = {
+      gateName: 'Format',
+      status: 'failed' as const,
+      output: 'main.go',
+      duration: 10,
+    };
+
+    afterEach(() => {
+      vi.restoreAllMocks();
+    });
+
+    async function runFixes(results: (typeof failed)[], fixOutput: string) {
+      const { db } = await import('@/db');
+      const { execAsync } = await import('../command-executor');
+      const { runFailureFixes } = await import('../runner');
+
+      vi.mocked(db.insert).mockClear();
+      vi.mocked(execAsync).mockReset();
+      vi.mocked(execAsync).mockImplementation(async () => {
+        if (fixOutput === 'denied') throw new Error('permission denied');
+        return { stdout: fixOutput, stderr: '' };
+      });
+
+      const error = await runFailureFixes(
+        'task-1',
+        results,
+        { qaGates: [formatGate] },
+        '/repo'
+      );
+      const calls = vi.mocked(execAsync).mock.calls.map(([cmd]) => cmd);
+      const stored = vi
+        .mocked(db.insert)
+        .mock.results.map(
+          ({ value }) => vi.mocked(value.values).mock.calls[0]?.[0]
+        );
+      return { error, calls, stored };
+    }
+
+    it('should run the fix as a plain command, without output rules', async () => {
+      const { error, calls, stored } = await runFixes([failed], 'main.go');
+
+      expect(error).toBeNull();
+      expect(calls).toEqual(['gofmt -w .']);
+      expect(stored).toMatchObject([
+        { gateName: 'Format (onFailure)', status: 'passed' },
+      ]);
+    });
+
+    it('should not run the fix of a gate that passed', async () => {
+      const { calls } = await runFixes([{ ...failed, status: 'passed' }], '');
+
+      expect(calls).toEqual([]);
+    });
+
+    it('should report a failing fix', async () => {
+      const { error, stored } = await runFixes([failed], 'denied');
+
+      expect(error).toBe(
+        'onFailure command for gate "Format" failed: permission denied'
+      );
+      expect(stored).toMatchObject([
+        { gateName: 'Format (onFailure)', status: 'failed' },
+      ]);
+    });
+  });
+
+  describe('output rules', () => {
+    beforeEach(() => {
+      vi.spyOn(console, 'log').mockImplementation(() => {});
+    });
+
+    afterEach(() => {
+      vi.restoreAllMocks();
+    });
+
+    async function runGates(failOnError: boolean, continueOnFailure = false) {
+      const { loadRepositoryConfig } = await import('../config-loader');
+      const { execAsync } = await import('../command-executor');
+      const { runQAGates } = await import('../runner');
+
+      vi.mocked(loadRepositoryConfig).mockResolvedValue({
+        continueOnFailure,
+        qaGates: [
+          {
+            name: 'Vet',
+            enabled: true,
+            command: 'go vet ./...',
+            failIfOutputMatches: 'possible misuse',
+            failOnError,
+            order: 1,
+          },
+          {
+            name: 'Test',
+            enabled: true,
+            command: 'go test ./...',
+            failOnError: true,
+            order: 2,
+          },
+        ],
+      });
+      vi.mocked(execAsync).mockReset();
+      vi.mocked(execAsync).mockResolvedValue({
+        stdout: '',
+        stderr: 'possible misuse of unsafe.Pointer',
+      });
+
+      return runQAGates('task-1', '/repo');
+    }
+
+    it('should stop the run on a regex failure when failOnError is set', async () => {
+      const results = await runGates(true);
+
+      expect(results.map((r) => r.status)).toEqual(['failed', 'skipped']);
+    });
+
+    it('should continue after a regex failure without failOnError', async () => {
+      const results = await runGates(false);
+
+      expect(results.map((r) => r.status)).toEqual(['failed', 'passed']);
+    });
+
+    it('should run later stages after a failure with continueOnFailure', async () => {
+      const results = await runGates(true, true);
+
+      expect(results.map((r) => r.status)).toEqual(['failed', 'passed']);
+    });
+  });
+
+  describe('severity', () => {
+    beforeEach(() => {
+      vi.spyOn(console, 'log').mockImplementation(() => {});
+    });
+
+    afterEach(() => {
+      vi.restoreAllMocks();
+    });
+
+    async function runWithSeverity(gate: {
+      failOnError: boolean;
+      severity?: 'error' | 'warning' | 'info';
+    }) {
+      const { loadRepositoryConfig } = await import('../config-loader');
+      const { execAsync } = await import('../command-executor');
+      const { runQAGatesWithRetry } = await import('../runner');
+
+      vi.mocked(loadRepositoryConfig).mockResolvedValue({
+        maxRetries: 2,
+        qaGates: [
+          { name: 'Docs', enabled: true, command: 'make docs', ...gate },
+        ],
+      });
+      vi.mocked(execAsync).mockReset();
+      vi.mocked(execAsync).mockRejectedValue(new Error('broken links'));
+
+      return runQAGatesWithRetry('task-1', '/repo');
+    }
+
+    it('should pass the task when only warning gates fail', async () => {
+      const result = await runWithSeverity({
+        failOnError: true,
+        severity: 'warning',
+      });
+
+      expect(result).toEqual({ passed: true, attempt: 1 });
+    });
+
+    it('should let an explicit error severity override failOnError', async () => {
+      const result = await runWithSeverity({
+        failOnError: false,
+        severity: 'error',
+      });
+
+      expect(result).toEqual({ passed: false, attempt: 2 });
+    });
+  });
+
   describe('Type Definitions and Structure', () => {
     it('should have expected exports', async () => {
       // Import the module dynamically to test structure
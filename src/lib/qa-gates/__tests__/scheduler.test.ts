@@ -0,0 +1,365 @@
+import { describe, it, expect } from 'vitest';
+import {
+  countDependents,
+  findDependencyCycle,
+  groupByDependencies,
+  groupByOrder,
+  hasBlockingFailure,
+  hasDependencies,
+  mapWithConcurrency,
+  runDependencyGraph,
+} from '../scheduler';
+
+function deferred() {
+  let resolve!: () => void;
+  const promise = new Promise<void>((r) => (resolve = r));
+  return { promise, resolve };
+}
+
+describe('groupByOrder', () => {
+  it('should group consecutive gates sharing an order', () => {
+    const gates = [
+      { name: 'fmt', order: 1 },
+      { name: 'vet', order: 1 },
+      { name: 'license', order: 1 },
+      { name: 'test', order: 2 },
+    ];
+
+    expect(groupByOrder(gates).map((s) => s.map((g) => g.name))).toEqual([
+      ['fmt', 'vet', 'license'],
+      ['test'],
+    ]);
+  });
+
+  it('should keep gates without an order in their own stage', () => {
+    const gates = [{ name: 'a' }, { name: 'b' }, { name: 'c', order: 3 }];
+
+    expect(groupByOrder(gates)).toHaveLength(3);
+  });
+
+  it("should keep a matrix gate's instances in one stage", () => {
+    const gates = [
+      { name: 'a [v=1]', matrixOf: 'a' },
+      { name: 'a [v=2]', matrixOf: 'a' },
+      { name: 'b' },
+    ];
+
+    expect(groupByOrder(gates).map((s) => s.length)).toEqual([2, 1]);
+  });
+
+  it('should return no stages for no gates', () => {
+    expect(groupByOrder([])).toEqual([]);
+  });
+});
+
+describe('mapWithConcurrency', () => {
+  it('should return results in input order', async () => {
+    const results = await mapWithConcurrency([30, 10, 20], undefined, (ms) =>
+      new Promise<number>((resolve) => setTimeout(() => resolve(ms), ms))
+    );
+
+    expect(results).toEqual([30, 10, 20]);
+  });
+
+  it('should never exceed the concurrency limit', async () => {
+    let running = 0;
+    let peak = 0;
+    const gates = Array.from({ length: 5 }, () => deferred());
+
+    const pending = mapWithConcurrency(gates, 2, async (gate) => {
+      running++;
+      peak = Math.max(peak, running);
+      await gate.promise;
+      running--;
+    });
+
+    for (const gate of gates) {
+      await new Promise((resolve) => setTimeout(resolve, 5));
+      gate.resolve();
+    }
+    await pending;
+
+    expect(peak).toBe(2);
+  });
+
+  it('should run everything at once without a limit', async () => {
+    let running = 0;
+    let peak = 0;
+
+    await mapWithConcurrency([1, 2, 3, 4], undefined, async () => {
+      running++;
+      peak = Math.max(peak, running);
+      await new Promise((resolve) => setTimeout(resolve, 5));
+      running--;
+    });
+
+    expect(peak).toBe(4);
+  });
+});
+
+describe('hasBlockingFailure', () => {
+  const stage = [{ failOnError: false }, { failOnError: true }];
+
+  it('should ignore failures of gates that do not fail on error', () => {
+    expect(
+      hasBlockingFailure(stage, [{ status: 'failed' }, { status: 'passed' }])
+    ).toBe(false);
+  });
+
+  it('should detect a failed failOnError gate', () => {
+    expect(
+      hasBlockingFailure(stage, [{ status: 'passed' }, { status: 'failed' }])
+    ).toBe(true);
+  });
+});
+
+describe('findDependencyCycle', () => {
+  it('should return null for an acyclic graph', () => {
+    const gates = [
+      { name: 'build' },
+      { name: 'lint' },
+      { name: 'test', dependsOn: ['build', 'lint'] },
+    ];
+
+    expect(findDependencyCycle(gates)).toBeNull();
+  });
+
+  it('should name the gates in a cycle', () => {
+    const gates = [
+      { name: 'a', dependsOn: ['b'] },
+      { name: 'b', dependsOn: ['c'] },
+      { name: 'c', dependsOn: ['a'] },
+    ];
+
+    expect(findDependencyCycle(gates)).toEqual(['a', 'b', 'c', 'a']);
+  });
+
+  it('should detect a gate depending on itself', () => {
+    expect(findDependencyCycle([{ name: 'a', dependsOn: ['a'] }])).toEqual([
+      'a',
+      'a',
+    ]);
+  });
+});
+
+describe('countDependents', () => {
+  it('should count direct and transitive dependents once each', () => {
+    const counts = countDependents([
+      { name: 'build' },
+      { name: 'test', dependsOn: ['build'] },
+      { name: 'lint', dependsOn: ['build'] },
+      { name: 'e2e', dependsOn: ['test', 'lint'] },
+    ]);
+
+    expect(Object.fromEntries(counts)).toEqual({
+      build: 3,
+      test: 1,
+      lint: 1,
+      e2e: 0,
+    });
+  });
+});
+
+describe('groupByDependencies', () => {
+  it('should place each gate one level after its deepest dependency', () => {
+    const gates = [
+      { name: 'deploy', dependsOn: ['test', 'lint'] },
+      { name: 'test', dependsOn: ['build'] },
+      { name: 'lint', order: 2 },
+      { name: 'build', order: 1 },
+    ];
+
+    expect(
+      groupByDependencies(gates).map((level) => level.map((g) => g.name))
+    ).toEqual([['build', 'lint'], ['test'], ['deploy']]);
+  });
+});
+
+describe('runDependencyGraph', () => {
+  type Gate = {
+    name: string;
+    failOnError: boolean;
+    order?: number;
+    dependsOn?: string[];
+  };
+  const gate = (name: string, extra: Partial<Gate> = {}): Gate => ({
+    name,
+    failOnError: true,
+    ...extra,
+  });
+
+  it('should detect gates that declare dependencies', () => {
+    expect(hasDependencies([gate('a')])).toBe(false);
+    expect(hasDependencies([gate('a'), gate('b', { dependsOn: ['a'] })])).toBe(
+      true
+    );
+  });
+
+  it('should run dependencies before their dependents', async () => {
+    const started: string[] = [];
+    const gates = [
+      gate('test', { dependsOn: ['build'] }),
+      gate('build'),
+      gate('lint'),
+    ];
+
+    const results = await runDependencyGraph(
+      gates,
+      1,
+      async (g) => {
+        started.push(g.name);
+        return { name: g.name, status: 'passed' };
+      },
+      async (g) => ({ name: g.name, status: 'skipped' })
+    );
+
+    expect(started.indexOf('build')).toBeLessThan(started.indexOf('test'));
+    expect(results.map((r) => r.name)).toEqual(['test', 'build', 'lint']);
+  });
+
+  it('should use order to break ties between ready gates', async () => {
+    const started: string[] = [];
+    const gates = [
+      gate('slow', { order: 3 }),
+      gate('fast', { order: 1 }),
+      gate('mid', { order: 2 }),
+    ];
+
+    await runDependencyGraph(
+      gates,
+      1,
+      async (g) => {
+        started.push(g.name);
+        return { status: 'passed' };
+      },
+      async () => ({ status: 'skipped' })
+    );
+
+    expect(started).toEqual(['fast', 'mid', 'slow']);
+  });
+
+  it('should start gates with more dependents first when limited', async () => {
+    const started: string[] = [];
+    const gates = [
+      gate('lint', { order: 1 }),
+      gate('docs', { order: 2 }),
+      gate('build', { order: 3 }),
+      gate('test', { dependsOn: ['build'] }),
+      gate('e2e', { dependsOn: ['test'] }),
+    ];
+
+    await runDependencyGraph(
+      gates,
+      1,
+      async (g) => {
+        started.push(g.name);
+        return { status: 'passed' };
+      },
+      async () => ({ status: 'skipped' })
+    );
+
+    expect(started).toEqual(['build', 'test', 'lint', 'docs', 'e2e']);
+  });
+
+  it('should never run more than maxParallel ready gates at once', async () => {
+    let running = 0;
+    let peak = 0;
+    const gates = ['a', 'b', 'c', 'd'].map((name) => gate(name));
+
+    const results = await runDependencyGraph(
+      gates,
+      2,
+      async (g) => {
+        running++;
+        peak = Math.max(peak, running);
+        await new Promise((resolve) => setTimeout(resolve, 5));
+        running--;
+        return { name: g.name, status: 'passed' };
+      },
+      async (g) => ({ name: g.name, status: 'skipped' })
+    );
+
+    expect(peak).toBe(2);
+    expect(results.map((r) => r.name)).toEqual(['a', 'b', 'c', 'd']);
+  });
+
+  it('should skip dependents of a failed gate but keep other branches', async () => {
+    const gates = [
+      gate('build'),
+      gate('test', { dependsOn: ['build'] }),
+      gate('e2e', { dependsOn: ['test'] }),
+      gate('lint'),
+    ];
+
+    const results = await runDependencyGraph(
+      gates,
+      undefined,
+      async (g) => ({ status: g.name === 'build' ? 'failed' : 'passed' }),
+      async () => ({ status: 'skipped' })
+    );
+
+    expect(results.map((r) => r.status)).toEqual([
+      'failed',
+      'skipped',
+      'skipped',
+      'passed',
+    ]);
+  });
+
+  it('should skip dependents of a cancelled gate', async () => {
+    const gates = [
+      gate('audit', { failOnError: false }),
+      gate('report', { dependsOn: ['audit'] }),
+    ];
+
+    const results = await runDependencyGraph(
+      gates,
+      undefined,
+      async (g) => ({ status: g.name === 'audit' ? 'cancelled' : 'passed' }),
+      async () => ({ status: 'skipped' })
+    );
+
+    expect(results.map((r) => r.status)).toEqual(['cancelled', 'skipped']);
+  });
+
+  it('should run dependents when the failed gate does not fail on error', async () => {
+    const gates = [
+      gate('audit', { failOnError: false }),
+      gate('report', { dependsOn: ['audit'] }),
+    ];
+
+    const results = await runDependencyGraph(
+      gates,
+      undefined,
+      async (g) => ({ status: g.name === 'audit' ? 'failed' : 'passed' }),
+      async () => ({ status: 'skipped' })
+    );
+
+    expect(results.map((r) => r.status)).toEqual(['failed', 'passed']);
+  });
+
+  it('should run independent branches concurrently', async () => {
+    let running = 0;
+    let peak = 0;
+    const gates = [
+      gate('a'),
+      gate('b'),
+      gate('c', { dependsOn: ['a', 'b'] }),
+    ];
+
+    await runDependencyGraph(
+      gates,
+      undefined,
+      async () => {
+        running++;
+        peak = Math.max(peak, running);
+        await new Promise((resolve) => setTimeout(resolve, 5));
+        running--;
+        return { status: 'passed' };
+      },
+      async () => ({ status: 'skipped' })
+    );
+
+    expect(peak).toBe(2);
+  });
+});
@@ -0,0 +1,91 @@
+import { describe, it, expect } from 'vitest';
+import {
+  blocksRun,
+  gateSeverity,
+  hasErrorFailure,
+  promoteWarnings,
+  summarizeBySeverity,
+} from '../severity';
+
+describe('gateSeverity', () => {
+  it('should derive the severity from failOnError', () => {
+    expect(gateSeverity({ failOnError: true })).toBe('error');
+    expect(gateSeverity({ failOnError: false })).toBe('warning');
+  });
+
+  it('should prefer an explicit severity', () => {
+    expect(gateSeverity({ failOnError: true, severity: 'info' })).toBe('info');
+    expect(gateSeverity({ failOnError: false, severity: 'error' })).toBe(
+      'error'
+    );
+  });
+
+  it('should promote only warnings when strict', () => {
+    expect(gateSeverity({ failOnError: false }, true)).toBe('error');
+    expect(gateSeverity({ failOnError: true, severity: 'info' }, true)).toBe(
+      'info'
+    );
+  });
+});
+
+describe('blocksRun', () => {
+  it('should block only for error severity', () => {
+    expect(blocksRun({ failOnError: true })).toBe(true);
+    expect(blocksRun({ failOnError: true, severity: 'warning' })).toBe(false);
+  });
+});
+
+describe('hasErrorFailure', () => {
+  const gates = [
+    { name: 'Lint', failOnError: true },
+    { name: 'Docs', failOnError: true, severity: 'info' as const },
+  ];
+
+  it('should only count failures of error-severity gates', () => {
+    const failed = (gateName: string, status = 'failed') => [
+      { gateName, status },
+    ];
+
+    expect(hasErrorFailure(failed('Lint'), gates)).toBe(true);
+    expect(hasErrorFailure(failed('Lint', 'timedout'), gates)).toBe(true);
+    expect(hasErrorFailure(failed('Lint', 'skipped'), gates)).toBe(false);
+    expect(hasErrorFailure(failed('Docs'), gates)).toBe(false);
+    expect(hasErrorFailure(failed('Removed'), gates)).toBe(true);
+  });
+});
+
+describe('promoteWarnings', () => {
+  it('should resolve every gate for a strict run', () => {
+    const gates = promoteWarnings([
+      { name: 'Docs', failOnError: false },
+      { name: 'Size', failOnError: false, severity: 'info' as const },
+    ]);
+
+    expect(gates.map((gate) => gate.severity)).toEqual(['error', 'info']);
+    expect(blocksRun(gates[0]!)).toBe(true);
+  });
+});
+
+describe('summarizeBySeverity', () => {
+  it('should count gates and name failures per severity', () => {
+    const summary = summarizeBySeverity(
+      [
+        { name: 'Lint', status: 'failed' },
+        { name: 'Tests', status: 'passed' },
+        { name: 'Docs', status: 'failed' },
+        { name: 'Gone', status: 'failed' },
+      ],
+      [
+        { name: 'Lint', failOnError: true },
+        { name: 'Tests', failOnError: true },
+        { name: 'Docs', failOnError: false },
+      ]
+    );
+
+    expect(summary).toEqual({
+      error: { gates: 2, failed: ['Lint'] },
+      warning: { gates: 1, failed: ['Docs'] },
+      info: { gates: 0, failed: [] },
+    });
+  });
+});
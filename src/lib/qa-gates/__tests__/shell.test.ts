@@ -0,0 +1,116 @@
+import { describe, it, expect, beforeAll, afterAll } from 'vitest';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import {
+  defaultShell,
+  findOnPath,
+  quoteArgument,
+  shellInvocation,
+  splitCommandLine,
+} from '../shell';
+
+describe('splitCommandLine', () => {
+  it('should split on whitespace', () => {
+    expect(splitCommandLine('  go  test ./... ')).toEqual([
+      'go',
+      'test',
+      './...',
+    ]);
+  });
+
+  it('should group quoted text without expanding it', () => {
+    expect(splitCommandLine(`go test -run "Test Foo" '$HOME'`)).toEqual([
+      'go',
+      'test',
+      '-run',
+      'Test Foo',
+      '$HOME',
+    ]);
+  });
+
+  it('should join adjacent quoted and plain text into one argument', () => {
+    expect(splitCommandLine(`--name="a b"c ''`)).toEqual(['--name=a bc', '']);
+  });
+
+  it('should honour backslash escapes outside single quotes', () => {
+    expect(splitCommandLine(`echo a\\ b "say \\"hi\\"" 'c\\d'`)).toEqual([
+      'echo',
+      'a b',
+      'say "hi"',
+      'c\\d',
+    ]);
+  });
+
+  it('should reject unterminated quotes', () => {
+    expect(() => splitCommandLine(`echo "oops`)).toThrow(
+      'Unterminated " quote in command'
+    );
+  });
+});
+
+describe('findOnPath', () => {
+  let dir: string;
+
+  beforeAll(() => {
+    dir = fs.mkdtempSync(path.join(os.tmpdir(), 'forge-shell-'));
+    fs.writeFileSync(path.join(dir, 'tool'), '#!/bin/sh\n', { mode: 0o755 });
+    fs.writeFileSync(path.join(dir, 'plain'), 'not executable', {
+      mode: 0o644,
+    });
+  });
+
+  afterAll(() => {
+    fs.rmSync(dir, { recursive: true, force: true });
+  });
+
+  it('should return the full path of an executable on PATH', () => {
+    expect(findOnPath('tool', { PATH: `/nonexistent:${dir}` })).toBe(
+      path.join(dir, 'tool')
+    );
+  });
+
+  it('should return null for missing or non-executable files', () => {
+    expect(findOnPath('missing', { PATH: dir })).toBeNull();
+    expect(findOnPath('plain', { PATH: dir })).toBeNull();
+  });
+});
+
+describe('shellInvocation', () => {
+  it('should pass the command to POSIX shells with -c', () => {
+    expect(shellInvocation('bash', 'echo hi')).toEqual({
+      file: 'bash',
+      args: ['-c', 'echo hi'],
+    });
+  });
+
+  it('should run cmd with /c and a verbatim command line', () => {
+    expect(shellInvocation('cmd', 'echo hi')).toEqual({
+      file: 'cmd',
+      args: ['/d', '/s', '/c', '"echo hi"'],
+      verbatim: true,
+    });
+  });
+});
+
+describe('quoteArgument', () => {
+  it('should leave plain paths alone', () => {
+    expect(quoteArgument('cmd/main_test.go', 'sh')).toBe('cmd/main_test.go');
+  });
+
+  it("should quote for each shell's own rules", () => {
+    expect(quoteArgument("it's $HOME.go", 'bash')).toBe(`'it'\\''s $HOME.go'`);
+    expect(splitCommandLine(quoteArgument("it's a.go", 'none'))).toEqual([
+      "it's a.go",
+    ]);
+    expect(quoteArgument("it's a.go", 'pwsh')).toBe(`'it''s a.go'`);
+    expect(quoteArgument('say "hi".go', 'cmd')).toBe('"say ""hi"".go"');
+  });
+});
+
+describe('defaultShell', () => {
+  it('should default to cmd on Windows only', () => {
+    expect(defaultShell('win32')).toBe('cmd');
+    expect(defaultShell('linux')).toBeUndefined();
+  });
+});
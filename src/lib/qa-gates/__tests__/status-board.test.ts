@@ -0,0 +1,309 @@
+import { describe, it, expect } from 'vitest';
+import {
+  StatusBoard,
+  formatElapsed,
+  parseLogLevel,
+  resolveTerminalOptions,
+  type TerminalOptions,
+} from '../status-board';
+
+const plain: TerminalOptions = { color: false, live: false, verbose: false };
+
+function createBoard(names: string[], options: TerminalOptions = plain) {
+  const output: string[] = [];
+  const board = new StatusBoard(names, options, (text) => output.push(text));
+  return { board, output };
+}
+
+describe('resolveTerminalOptions', () => {
+  it('should enable color and live rendering on a TTY', () => {
+    expect(resolveTerminalOptions({}, true)).toEqual({
+      color: true,
+      live: true,
+      verbose: false,
+    });
+  });
+
+  it('should render plain lines when stdout is not a TTY', () => {
+    expect(resolveTerminalOptions({}, false)).toMatchObject({
+      color: false,
+      live: false,
+    });
+  });
+
+  it('should render plain lines in CI', () => {
+    expect(resolveTerminalOptions({ CI: 'true' }, true)).toMatchObject({
+      color: false,
+      live: false,
+    });
+  });
+
+  it('should disable color for NO_COLOR and FORGE_NO_COLOR', () => {
+    expect(resolveTerminalOptions({ NO_COLOR: '1' }, true).color).toBe(false);
+    expect(resolveTerminalOptions({ FORGE_NO_COLOR: '1' }, true).color).toBe(
+      false
+    );
+    expect(resolveTerminalOptions({ FORGE_NO_COLOR: '0' }, true).color).toBe(
+      true
+    );
+  });
+
+  it('should enable verbose output with FORGE_VERBOSE', () => {
+    expect(resolveTerminalOptions({ FORGE_VERBOSE: '1' }, false).verbose).toBe(
+      true
+    );
+  });
+});
+
+describe('formatElapsed', () => {
+  it('should pick a unit by magnitude', () => {
+    expect(formatElapsed(850)).toBe('850ms');
+    expect(formatElapsed(3140)).toBe('3.1s');
+    expect(formatElapsed(125000)).toBe('2m05s');
+  });
+});
+
+describe('StatusBoard', () => {
+  it('should print an aligned line per finished gate', async () => {
+    const { board, output } = createBoard(['Lint', 'Unit tests', 'Docs']);
+
+    await board.run('Lint', async () => ({ status: 'passed', duration: 1200 }));
+    await board.run('Unit tests', async () => ({
+      status: 'failed',
+      duration: 3400,
+    }));
+    await board.run('Docs', async () => ({ status: 'skipped', duration: 0 }));
+    board.close();
+
+    expect(output).toEqual([
+      '✓ Lint        1.2s\n',
+      '✗ Unit tests  3.4s\n',
+      '↷ Docs\n',
+    ]);
+  });
+
+  it('should note the attempt a retried gate finished on', async () => {
+    const { board, output } = createBoard(['Tests', 'Lint']);
+
+    await board.run('Tests', async () => ({
+      status: 'passed',
+      duration: 2000,
+      attempt: 2,
+      maxAttempts: 3,
+    }));
+    await board.run('Lint', async () => ({
+      status: 'passed',
+      duration: 1000,
+      attempt: 1,
+      maxAttempts: 3,
+    }));
+
+    expect(output).toEqual([
+      '✓ Tests  2.0s (passed on attempt 2/3)\n',
+      '✓ Lint   1.0s\n',
+    ]);
+  });
+
+  it('should show why a gate was skipped', async () => {
+    const { board, output } = createBoard(['Tests']);
+
+    await board.run('Tests', async () => ({
+      status: 'skipped',
+      duration: 0,
+      reason: 'Skipped because a dependency failed',
+    }));
+
+    expect(output).toEqual(['↷ Tests  Skipped because a dependency failed\n']);
+  });
+
+  it('should show a timed-out gate apart from a failed one', async () => {
+    const { board, output } = createBoard(['Tests']);
+
+    await board.run('Tests', async () => ({
+      status: 'timedout',
+      duration: 30000,
+    }));
+    board.printSummary();
+
+    expect(output[0]).toBe('⏱ Tests  30.0s timed out\n');
+    expect(output[1]).toContain('Tests  timedout  30.0s     1\n');
+  });
+
+  it('should show a cancelled gate apart from a skipped one', async () => {
+    const { board, output } = createBoard(['Tests']);
+
+    await board.run('Tests', async () => ({
+      status: 'cancelled',
+      duration: 1500,
+    }));
+    board.printSummary();
+
+    expect(output[0]).toBe('⊘ Tests  1.5s cancelled\n');
+    expect(output[1]).toContain('Tests  cancelled  1.5s      1\n');
+  });
+
+  it('should show a gate whose task throws as failed', async () => {
+    const { board, output } = createBoard(['Lint']);
+
+    await expect(
+      board.run('Lint', () => Promise.reject(new Error('boom')))
+    ).rejects.toThrow('boom');
+
+    expect(output[0]).toMatch(/^✗ Lint {2}\d+ms\n$/);
+  });
+
+  it('should color symbols when color is on', async () => {
+    const { board, output } = createBoard(['Lint'], { ...plain, color: true });
+
+    await board.run('Lint', async () => ({ status: 'passed', duration: 5 }));
+
+    expect(output).toEqual(['\x1b[32m✓\x1b[0m Lint  5ms\n']);
+  });
+
+  it('should redraw the whole block in place when live', async () => {
+    const { board, output } = createBoard(['Lint', 'Tests'], {
+      ...plain,
+      live: true,
+    });
+
+    await board.run('Lint', async () => ({ status: 'passed', duration: 5 }));
+    board.close();
+
+    expect(output[output.length - 1]).toBe('\x1b[2A\x1b[2K✓ Lint   5ms\n\x1b[2K· Tests\n');
+  });
+
+  it('should print plain lines when gate output is streamed', async () => {
+    const { board, output } = createBoard(['Lint', 'Tests'], {
+      ...plain,
+      live: true,
+      streamsOutput: true,
+    });
+
+    await board.run('Lint', async () => ({ status: 'passed', duration: 5 }));
+    board.close();
+
+    expect(output).toEqual(['✓ Lint   5ms\n']);
+  });
+
+  it('should print plain lines while another live board draws', async () => {
+    const live = { ...plain, live: true };
+    const first = createBoard(['Lint'], live);
+    const second = createBoard(['Tests'], live);
+    let release: () => void = () => {};
+
+    const running = first.board.run(
+      'Lint',
+      () =>
+        new Promise((resolve) => {
+          release = () => resolve({ status: 'passed', duration: 5 });
+        })
+    );
+    await second.board.run('Tests', async () => ({
+      status: 'failed',
+      duration: 7,
+    }));
+    release();
+    await running;
+    first.board.close();
+    second.board.close();
+
+    expect(second.output).toEqual(['✗ Tests  7ms\n']);
+    expect(first.output[first.output.length - 1]).toBe('✓ Lint  5ms\n');
+  });
+
+  it('should end the run with a summary table and totals', async () => {
+    const { board, output } = createBoard(['Lint', 'Unit tests', 'Docs']);
+
+    await board.run('Lint', async () => ({ status: 'passed', duration: 1200 }));
+    await board.run('Unit tests', async () => ({
+      status: 'failed',
+      duration: 3400,
+      attempt: 2,
+      maxAttempts: 2,
+    }));
+    await board.run('Docs', async () => ({ status: 'skipped', duration: 0 }));
+    board.close();
+    output.length = 0;
+    board.printSummary();
+
+    const lines = output.join('').split('\n');
+    expect(lines.slice(0, 5)).toEqual([
+      '',
+      'Gate        Status   Duration  Attempts',
+      'Lint        passed   1.2s      1',
+      'Unit tests  failed   3.4s      2',
+      'Docs        skipped  -         -',
+    ]);
+    expect(lines[5]).toMatch(
+      /^Total: 3 gates in \d+ms \(1 passed, 1 failed, 1 skipped\)$/
+    );
+  });
+
+  it('should print the summary under verbose output too', () => {
+    const { board, output } = createBoard(['Lint'], {
+      ...plain,
+      verbose: true,
+    });
+
+    board.printSummary();
+
+    expect(output.join('')).toContain('Lint  pending  -         -\n');
+  });
+
+  it('should print nothing under verbose output', async () => {
+    const { board, output } = createBoard(['Lint'], {
+      ...plain,
+      live: true,
+      verbose: true,
+    });
+
+    await board.run('Lint', async () => ({ status: 'passed', duration: 5 }));
+    board.close();
+
+    expect(output).toEqual([]);
+  });
+});
+
+describe('quiet output', () => {
+  const quiet: TerminalOptions = { ...plain, quiet: true };
+
+  it('should print only the summary', async () => {
+    const { board, output } = createBoard(['Lint'], quiet);
+
+    await board.run('Lint', async () => ({ status: 'failed', duration: 5 }));
+    board.close();
+    expect(output).toEqual([]);
+
+    board.printSummary();
+    expect(output.join('')).toContain('Lint  failed  5ms');
+  });
+
+  it('should print the last 20 lines of a failed gate', () => {
+    const { board, output } = createBoard(['Tests'], quiet);
+    const lines = Array.from({ length: 25 }, (_, i) => `line ${i + 1}`);
+
+    board.printFailureTail('Tests', `${lines.join('\n')}\n`);
+
+    const text = output.join('');
+    expect(text).toMatch(/^\n--- Tests \(last 20 lines\) ---\nline 6\n/);
+    expect(text).toMatch(/line 25\n$/);
+    expect(text).not.toContain('line 5\n');
+  });
+
+  it('should not print failure tails unless quiet', () => {
+    const { board, output } = createBoard(['Tests']);
+
+    board.printFailureTail('Tests', 'boom');
+
+    expect(output).toEqual([]);
+  });
+});
+
+describe('parseLogLevel', () => {
+  it('should accept the known levels and ignore anything else', () => {
+    expect(parseLogLevel('quiet')).toBe('quiet');
+    expect(parseLogLevel('debug')).toBe('debug');
+    expect(parseLogLevel('loud')).toBeUndefined();
+    expect(parseLogLevel(null)).toBeUndefined();
+  });
+});
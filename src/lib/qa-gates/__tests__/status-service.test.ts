@@ -2,6 +2,7 @@ import { describe, it, expect, vi, beforeEach } from 'vitest';
 import {
   getRepository,
   getLatestQARun,
+  getQARun,
   getGateExecutions,
   getQAGateStatus,
 } from '../status-service';
@@ -144,6 +145,34 @@ describe('Status Service', () => {
     });
   });
 
+  describe('getQARun', () => {
+    it('should fetch a QA run by ID', async () => {
+      const mockRun = { id: 'run-1', repositoryId: 'repo-1', status: 'passed' };
+      const mockChain = {
+        from: vi.fn().mockReturnThis(),
+        where: vi.fn().mockReturnThis(),
+        limit: vi.fn().mockResolvedValue([mockRun]),
+      };
+
+      vi.mocked(db.select).mockReturnValue(mockChain as any);
+
+      expect(await getQARun('run-1')).toEqual(mockRun);
+      expect(mockChain.limit).toHaveBeenCalledWith(1);
+    });
+
+    it('should return undefined when the run does not exist', async () => {
+      const mockChain = {
+        from: vi.fn().mockReturnThis(),
+        where: vi.fn().mockReturnThis(),
+        limit: vi.fn().mockResolvedValue([]),
+      };
+
+      vi.mocked(db.select).mockReturnValue(mockChain as any);
+
+      expect(await getQARun('missing')).toBeUndefined();
+    });
+  });
+
   describe('getGateExecutions', () => {
     it('should fetch gate executions for a run', async () => {
       const mockExecutions = [
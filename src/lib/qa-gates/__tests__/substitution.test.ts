@@ -0,0 +1,42 @@
+import { describe, it, expect } from 'vitest';
+import { substituteVariables } from '../substitution';
+
+describe('substituteVariables', () => {
+  const vars = { HOME: '/home/forge', EMPTY: '' };
+
+  it('should expand known placeholders', () => {
+    expect(
+      substituteVariables('ls ${HOME}/src', vars, { strict: true })
+    ).toBe('ls /home/forge/src');
+  });
+
+  it('should expand a defined but empty variable to an empty string', () => {
+    expect(substituteVariables('a${EMPTY}b', vars, { strict: true })).toBe(
+      'ab'
+    );
+  });
+
+  it('should throw on unknown variables in strict mode', () => {
+    expect(() =>
+      substituteVariables('echo ${MISSING}', vars, { strict: true })
+    ).toThrow('Unknown variable ${MISSING}');
+  });
+
+  it('should expand unknown variables to empty when not strict', () => {
+    expect(
+      substituteVariables('echo [${MISSING}]', vars, { strict: false })
+    ).toBe('echo []');
+  });
+
+  it('should turn $$ into a single dollar sign', () => {
+    expect(
+      substituteVariables("awk '{print $$1}' $${HOME}", vars, { strict: true })
+    ).toBe("awk '{print $1}' ${HOME}");
+  });
+
+  it('should leave bare $NAME references for the shell', () => {
+    expect(substituteVariables('echo $HOME', vars, { strict: true })).toBe(
+      'echo $HOME'
+    );
+  });
+});
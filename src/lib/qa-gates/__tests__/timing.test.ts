@@ -0,0 +1,159 @@
+import { describe, it, expect } from 'vitest';
+import type { GateRunResult } from '../run-report';
+import { buildRunTiming, formatTimingProfile } from '../timing';
+
+function gate(
+  name: string,
+  durationMs: number,
+  overrides: Partial<GateRunResult> = {}
+): GateRunResult {
+  return {
+    name,
+    command: 'true',
+    status: 'passed',
+    severity: 'error',
+    exitCode: 0,
+    durationMs,
+    timeoutMs: null,
+    attempts: 1,
+    attemptDetails: [],
+    stdout: null,
+    stderr: null,
+    ...overrides,
+  };
+}
+
+describe('buildRunTiming', () => {
+  it('should list gates slowest first with retry and backoff time', () => {
+    const attempt = { exitCode: 1, stdout: null, stderr: null };
+    const timing = buildRunTiming(
+      [
+        gate('Lint', 200),
+        gate('Tests', 1600, {
+          attempts: 2,
+          attemptDetails: [
+            { ...attempt, attempt: 1, durationMs: 500, backoffMs: 400 },
+            { ...attempt, attempt: 2, durationMs: 700, exitCode: 0 },
+          ],
+        }),
+      ],
+      []
+    );
+
+    expect(timing.gates).toEqual([
+      {
+        name: 'Tests',
+        status: 'passed',
+        durationMs: 1600,
+        retryMs: 700,
+        backoffMs: 400,
+      },
+      {
+        name: 'Lint',
+        status: 'passed',
+        durationMs: 200,
+        retryMs: 0,
+        backoffMs: 0,
+      },
+    ]);
+    expect(timing.totalGateMs).toBe(1800);
+  });
+
+  it('should follow order stages, one after another', () => {
+    const configGates = [
+      { name: 'Lint', order: 1 },
+      { name: 'Types', order: 1 },
+      { name: 'Tests', order: 2 },
+      { name: 'Docs', order: 2 },
+    ];
+    const timing = buildRunTiming(
+      [
+        gate('Lint', 300),
+        gate('Types', 900),
+        gate('Tests', 2000),
+        gate('Docs', 100),
+      ],
+      configGates
+    );
+
+    expect(timing.criticalPath).toEqual({
+      gates: ['Types', 'Tests'],
+      durationMs: 2900,
+    });
+    expect(timing.unboundedWallClockMs).toBe(2900);
+  });
+
+  it('should follow dependsOn edges through the graph', () => {
+    // Build -> Tests is the longest chain, though Lint's gate is slowest
+    const configGates = [
+      { name: 'beforeAll' },
+      { name: 'Build' },
+      { name: 'Lint' },
+      { name: 'Tests', dependsOn: ['Build'] },
+      { name: 'Package', dependsOn: ['Build'] },
+      { name: 'afterAll' },
+    ];
+    const timing = buildRunTiming(
+      [
+        gate('beforeAll', 50),
+        gate('Build', 1000),
+        gate('Lint', 1500),
+        gate('Tests', 800),
+        gate('Package', 300),
+        gate('afterAll', 20),
+      ],
+      configGates
+    );
+
+    expect(timing.criticalPath).toEqual({
+      gates: ['beforeAll', 'Build', 'Tests', 'afterAll'],
+      durationMs: 1870,
+    });
+  });
+
+  it('should leave skipped gates off the critical path', () => {
+    const configGates = [
+      { name: 'Build' },
+      { name: 'E2E', dependsOn: ['Build'] },
+      { name: 'Report', dependsOn: ['E2E'] },
+    ];
+    const timing = buildRunTiming(
+      [
+        gate('Build', 400),
+        gate('E2E', 0, { status: 'skipped' }),
+        gate('Report', 100),
+      ],
+      configGates
+    );
+
+    expect(timing.criticalPath).toEqual({
+      gates: ['Build', 'Report'],
+      durationMs: 500,
+    });
+  });
+});
+
+describe('formatTimingProfile', () => {
+  it('should print the breakdown, totals and critical path', () => {
+    const timing = buildRunTiming(
+      [gate('Lint', 300), gate('Tests', 2000)],
+      [
+        { name: 'Lint', order: 1 },
+        { name: 'Tests', order: 1 },
+      ]
+    );
+
+    expect(formatTimingProfile(timing, 2100)).toBe(
+      [
+        'Timing profile',
+        'Gate   Duration  Retries  Backoff',
+        'Tests  2.0s      -        -',
+        'Lint   300ms     -        -',
+        '',
+        'Gate time: 2.3s total, 2.1s wall clock',
+        'Critical path: Tests (2.0s)',
+        'Wall clock with unbounded maxParallel: 2.0s',
+      ].join('\n')
+    );
+  });
+});
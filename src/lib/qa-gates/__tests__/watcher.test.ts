@@ -0,0 +1,180 @@
+import { describe, it, expect, vi, beforeEach, afterEach } from 'vitest';
+import fs from 'fs';
+import os from 'os';
+import path from 'path';
+import {
+  coalesce,
+  formatWatchSummary,
+  startWatching,
+  stopWatching,
+} from '../watcher';
+import { orchestrateQAGates } from '../run-orchestrator';
+import { getGateExecutions } from '../status-service';
+import { readRepositoryConfig } from '../config-loader';
+
+vi.mock('@/db', () => {
+  const returning = vi.fn(async () => [{ id: 'run-1' }]);
+  const values = vi.fn(() => ({ returning }));
+  return { db: { insert: vi.fn(() => ({ values })) } };
+});
+vi.mock('@/db/schema', () => ({ qaRuns: {} }));
+vi.mock('../run-orchestrator', () => ({ orchestrateQAGates: vi.fn() }));
+vi.mock('../status-service', () => ({ getGateExecutions: vi.fn() }));
+vi.mock('../config-loader', () => ({ readRepositoryConfig: vi.fn() }));
+
+async function flush() {
+  await new Promise((resolve) => setTimeout(resolve, 0));
+}
+
+describe('coalesce', () => {
+  it('should queue a single follow-up run for triggers during a run', async () => {
+    let release: () => void = () => {};
+    const task = vi.fn(
+      () => new Promise<void>((resolve) => (release = resolve))
+    );
+    const runner = coalesce(task);
+
+    runner.trigger();
+    runner.trigger();
+    runner.trigger();
+    expect(task).toHaveBeenCalledTimes(1);
+    expect(runner.isRunning()).toBe(true);
+
+    release();
+    await flush();
+    expect(task).toHaveBeenCalledTimes(2);
+
+    release();
+    await flush();
+    expect(runner.isRunning()).toBe(false);
+    expect(task).toHaveBeenCalledTimes(2);
+  });
+});
+
+describe('formatWatchSummary', () => {
+  it('should count outcomes and name failed gates', () => {
+    const summary = formatWatchSummary(
+      [
+        { gateName: 'Lint', status: 'passed' },
+        { gateName: 'Tests', status: 'failed' },
+        { gateName: 'E2E', status: 'skipped' },
+      ],
+      3140
+    );
+
+    expect(summary).toBe('1 passed, 1 failed (Tests), 1 skipped in 3.1s');
+  });
+
+  it('should count cached gates when there are any', () => {
+    const summary = formatWatchSummary(
+      [
+        { gateName: 'Lint', status: 'passed' },
+        { gateName: 'Types', status: 'cached' },
+      ],
+      500
+    );
+
+    expect(summary).toBe('1 passed, 1 cached, 0 failed, 0 skipped in 0.5s');
+  });
+
+  it('should count gates a failure cancelled', () => {
+    const summary = formatWatchSummary(
+      [
+        { gateName: 'Lint', status: 'failed' },
+        { gateName: 'Tests', status: 'cancelled' },
+      ],
+      800
+    );
+
+    expect(summary).toBe(
+      '0 passed, 1 failed (Lint), 1 cancelled, 0 skipped in 0.8s'
+    );
+  });
+
+  it('should count warning and info failures separately', () => {
+    const gate = { enabled: true, command: 'true', failOnError: true };
+    const summary = formatWatchSummary(
+      [
+        { gateName: 'Lint', status: 'failed' },
+        { gateName: 'Docs', status: 'failed' },
+        { gateName: 'Size', status: 'failed' },
+      ],
+      1000,
+      [
+        { ...gate, name: 'Lint' },
+        { ...gate, name: 'Docs', failOnError: false },
+        { ...gate, name: 'Size', severity: 'info' },
+      ]
+    );
+
+    expect(summary).toBe(
+      '0 passed, 1 failed (Lint), 1 warning (Docs), 1 info (Size), ' +
+        '0 skipped in 1.0s'
+    );
+  });
+});
+
+describe('startWatching', () => {
+  let repoPath: string;
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    repoPath = fs.mkdtempSync(path.join(os.tmpdir(), 'forge-watch-'));
+    vi.mocked(readRepositoryConfig).mockResolvedValue({
+      qaGates: [
+        { name: 'Fmt', enabled: true, command: 'gofmt -w .', failOnError: true },
+      ],
+    } as any);
+    vi.mocked(getGateExecutions).mockResolvedValue([]);
+  });
+
+  afterEach(() => {
+    stopWatching('repo-1');
+    fs.rmSync(repoPath, { recursive: true, force: true });
+  });
+
+  it("should not re-run for the files a run writes itself", async () => {
+    // Like an onFailure fix rewriting a source file
+    vi.mocked(orchestrateQAGates).mockImplementation(async () => {
+      fs.writeFileSync(path.join(repoPath, 'main.go'), 'package main\n');
+      await new Promise((resolve) => setTimeout(resolve, 50));
+      return 'passed';
+    });
+
+    await startWatching({ repositoryId: 'repo-1', repoPath, debounceMs: 20 });
+    await new Promise((resolve) => setTimeout(resolve, 300));
+
+    expect(orchestrateQAGates).toHaveBeenCalledTimes(1);
+  });
+
+  it('should ignore artifacts and history but re-run for source changes', async () => {
+    vi.mocked(orchestrateQAGates).mockResolvedValue('passed');
+    fs.mkdirSync(path.join(repoPath, 'out'));
+    fs.mkdirSync(path.join(repoPath, '.history'));
+    const wait = () => new Promise((resolve) => setTimeout(resolve, 200));
+
+    await startWatching({
+      repositoryId: 'repo-1',
+      repoPath,
+      artifactsDir: './out/',
+      historyDir: '.history',
+      debounceMs: 20,
+    });
+    await wait();
+    fs.writeFileSync(path.join(repoPath, 'out/lint.sarif'), '{}');
+    fs.appendFileSync(path.join(repoPath, '.history/history.jsonl'), '{}\n');
+    await wait();
+
+    expect(orchestrateQAGates).toHaveBeenCalledTimes(1);
+    expect(orchestrateQAGates).toHaveBeenCalledWith(
+      expect.objectContaining({
+        artifactsDir: './out/',
+        historyDir: '.history',
+      })
+    );
+
+    fs.writeFileSync(path.join(repoPath, 'main.go'), 'package main\n');
+    await wait();
+    expect(orchestrateQAGates).toHaveBeenCalledTimes(2);
+  });
+});
@@ -0,0 +1,73 @@
+import fs from 'fs/promises';
+import path from 'path';
+import type { GateArtifact } from '@/db/schema';
+import type { QAGateConfig } from './config-loader';
+import { walk } from './gate-cache';
+import { matchesGlob } from './glob';
+
+function isInside(file: string, dir?: string): boolean {
+  return dir !== undefined && (file === dir || file.startsWith(`${dir}/`));
+}
+
+/**
+ * Files matching a gate's `artifacts` under `root`, sorted, with their
+ * sizes. Files under `exclude`, a directory relative to `root`, are left
+ * out so earlier copies are not collected again.
+ */
+export async function findArtifacts(
+  gate: Pick<QAGateConfig, 'artifacts'>,
+  root: string,
+  exclude?: string
+): Promise<GateArtifact[]> {
+  const globs = [gate.artifacts ?? []].flat();
+  const files = await walk(root).catch(() => [] as string[]);
+  const matched = files
+    .filter((file) => globs.some((glob) => matchesGlob(file, glob)))
+    .filter((file) => !isInside(file, exclude))
+    .sort();
+
+  return Promise.all(
+    matched.map(async (file) => ({
+      path: file,
+      size: (await fs.stat(path.join(root, file))).size,
+    }))
+  );
+}
+
+/**
+ * Globs in a gate's `artifacts` that matched none of the files found
+ */
+export function findMissingArtifacts(
+  gate: Pick<QAGateConfig, 'artifacts'>,
+  found: GateArtifact[]
+): string[] {
+  return [gate.artifacts ?? []]
+    .flat()
+    .filter((glob) => !found.some((file) => matchesGlob(file.path, glob)));
+}
+
+/**
+ * Directory under `artifactsDir` receiving a gate's artifacts, named after
+ * the gate with anything but letters, digits, `.`, `-` and `_` replaced
+ */
+export function gateArtifactsDir(artifactsDir: string, gateName: string) {
+  return path.join(artifactsDir, gateName.replace(/[^\w.-]+/g, '_'));
+}
+
+/**
+ * Copy artifacts found under `root` into `targetDir`, keeping their
+ * relative paths. The directory is emptied first, so it only ever holds
+ * the latest run's files.
+ */
+export async function copyArtifacts(
+  artifacts: GateArtifact[],
+  root: string,
+  targetDir: string
+): Promise<void> {
+  await fs.rm(targetDir, { recursive: true, force: true });
+  for (const artifact of artifacts) {
+    const target = path.join(targetDir, artifact.path);
+    await fs.mkdir(path.dirname(target), { recursive: true });
+    await fs.copyFile(path.join(root, artifact.path), target);
+  }
+}
@@ -0,0 +1,178 @@
+import type { QAGateConfig } from './config-loader';
+import type {
+  CommandError,
+  ExecOptions,
+  ExecResult,
+  OutputWriters,
+} from './command-executor';
+import { TailBuffer, writeLines } from './output-buffer';
+
+/**
+ * What a builtin gate sees when it runs
+ */
+export interface BuiltinGateContext {
+  gateName: string;
+  /** Absolute working directory, as a command gate would get */
+  cwd: string;
+  env: NodeJS.ProcessEnv;
+  /** Aborted on timeout or cancellation; long checks should stop early */
+  signal: AbortSignal;
+}
+
+export interface BuiltinGateResult {
+  passed: boolean;
+  /** Reported like a command's stdout and stderr */
+  stdout?: string;
+  stderr?: string;
+}
+
+/**
+ * A check that runs inside Forge instead of spawning a process
+ */
+export interface BuiltinGate {
+  run(context: BuiltinGateContext): Promise<BuiltinGateResult>;
+}
+
+/**
+ * Builds a gate from its config `options`; throw to reject them
+ */
+export type BuiltinGateFactory = (options: unknown) => BuiltinGate;
+
+// Force true singleton using global to survive hot-reloads
+const globalForBuiltins = global as typeof globalThis & {
+  forgeBuiltinGates?: Map<string, BuiltinGateFactory>;
+};
+
+const registry = (globalForBuiltins.forgeBuiltinGates ??= new Map());
+
+/**
+ * Make `name` available to gates with `type: "builtin"`. Registering a
+ * name again replaces the earlier factory.
+ */
+export function registerGate(name: string, factory: BuiltinGateFactory): void {
+  registry.set(name, factory);
+}
+
+/**
+ * Remove a registered builtin gate
+ */
+export function unregisterGate(name: string): void {
+  registry.delete(name);
+}
+
+/**
+ * Whether a factory is registered under `name`
+ */
+export function isGateRegistered(name: string): boolean {
+  return registry.has(name);
+}
+
+type BuiltinGateConfig = Partial<
+  Pick<QAGateConfig, 'name' | 'builtin' | 'options'>
+>;
+
+function gateError(
+  message: string,
+  code: number | null,
+  output: ExecResult = { stdout: '', stderr: '' }
+): CommandError {
+  const error: CommandError = new Error(message);
+  error.stdout = output.stdout;
+  error.stderr = output.stderr;
+  error.code = code;
+  return error;
+}
+
+/**
+ * Redact, cap and optionally echo a builtin gate's output the way
+ * captured command output is
+ */
+function captureResult(
+  { stdout = '', stderr = '' }: BuiltinGateResult,
+  options: ExecOptions
+): ExecResult {
+  const capture = (text: string, stream: keyof OutputWriters) => {
+    const masked = options.redact ? options.redact(text) : text;
+    const format = options.streamLine;
+    if (format) {
+      writeLines(
+        masked,
+        (line) => format(line, stream),
+        options.output?.[stream] ?? ((line) => process[stream].write(line))
+      );
+    }
+    const buffer = new TailBuffer(options.maxOutputBytes);
+    buffer.append(masked);
+    return buffer.toString();
+  };
+  return {
+    stdout: capture(stdout, 'stdout'),
+    stderr: capture(stderr, 'stderr'),
+  };
+}
+
+/**
+ * Stop waiting when the timeout elapses or `signal` aborts, aborting the
+ * gate's own signal so it can stop too
+ */
+function supervise(
+  options: ExecOptions,
+  run: (signal: AbortSignal) => Promise<BuiltinGateResult>
+): Promise<BuiltinGateResult> {
+  const controller = new AbortController();
+  let stop: (message: string, timedOut?: boolean) => void = () => {};
+  const stopped = new Promise<never>((_, reject) => {
+    stop = (message, timedOut) => {
+      controller.abort();
+      const error = gateError(message, null);
+      if (timedOut) error.timedOut = true;
+      reject(error);
+    };
+  });
+  const onAbort = () => stop('Gate was cancelled');
+  const timer = options.timeout
+    ? setTimeout(
+        () => stop(`Gate timed out after ${options.timeout}ms`, true),
+        options.timeout
+      )
+    : undefined;
+  if (options.signal?.aborted) onAbort();
+  options.signal?.addEventListener('abort', onAbort, { once: true });
+
+  return Promise.race([run(controller.signal), stopped]).finally(() => {
+    clearTimeout(timer);
+    options.signal?.removeEventListener('abort', onAbort);
+  });
+}
+
+/**
+ * Run a `type: "builtin"` gate through its registered factory. Resolves
+ * like execAsync on a pass and rejects with a CommandError (exit code 1)
+ * on a failure, so output rules, retries, reports and the cache treat it
+ * like any command. Timeouts and cancellation reject with a null code.
+ */
+export async function runBuiltinGate(
+  gate: BuiltinGateConfig,
+  options: ExecOptions
+): Promise<ExecResult> {
+  const factory = gate.builtin ? registry.get(gate.builtin) : undefined;
+  if (!factory) {
+    throw gateError(`Builtin gate "${gate.builtin}" is not registered`, null);
+  }
+
+  const outcome = await supervise(options, (signal) =>
+    Promise.resolve().then(() =>
+      factory(gate.options).run({
+        gateName: gate.name ?? '',
+        cwd: options.cwd,
+        env: options.env ?? process.env,
+        signal,
+      })
+    )
+  );
+  const result = captureResult(outcome, options);
+  if (!outcome.passed) {
+    throw gateError(`Builtin gate "${gate.builtin}" failed`, 1, result);
+  }
+  return result;
+}
@@ -0,0 +1,130 @@
+import type { QAGateConfig } from './config-loader';
+import { execAsync, getContainerPath } from './command-executor';
+import { matchesGlob } from './glob';
+
+// Roots already warned about, so a watcher or retry loop warns only once
+const warnedRoots = new Set<string>();
+
+async function git(root: string, args: string[]): Promise<string> {
+  const { stdout } = await execAsync(['git', ...args], {
+    cwd: root,
+    timeout: 30000,
+  });
+  return stdout.trim();
+}
+
+async function tryGit(root: string, args: string[]): Promise<string | null> {
+  try {
+    return await git(root, args);
+  } catch {
+    return null;
+  }
+}
+
+/**
+ * The merge-base of HEAD with the default branch: origin's HEAD when
+ * known, else a local main or master. Falls back to HEAD.
+ */
+async function defaultBase(root: string): Promise<string> {
+  const candidates = [
+    await tryGit(root, ['symbolic-ref', '--short', 'refs/remotes/origin/HEAD']),
+    'main',
+    'master',
+  ];
+  for (const ref of candidates) {
+    if (!ref) continue;
+    const base = await tryGit(root, ['merge-base', 'HEAD', ref]);
+    if (base) return base;
+  }
+  return 'HEAD';
+}
+
+/**
+ * Files changed since `since` (default: the merge-base with the default
+ * branch), including uncommitted and untracked files. Returns null when
+ * `repoPath` is not a git repository, meaning every file counts as
+ * changed.
+ */
+export async function listChangedFiles(
+  repoPath: string,
+  since?: string
+): Promise<string[] | null> {
+  const root = getContainerPath(repoPath);
+  if ((await tryGit(root, ['rev-parse', '--is-inside-work-tree'])) === null) {
+    if (!warnedRoots.has(root)) {
+      warnedRoots.add(root);
+      console.warn(
+        `${root} is not a git repository; changedFilesGlob gates always run`
+      );
+    }
+    return null;
+  }
+
+  if (since?.startsWith('-')) {
+    throw new Error(`Invalid base ref "${since}"`);
+  }
+  const base = since ?? (await defaultBase(root));
+  // --relative keeps paths relative to the config directory, which may be
+  // a subdirectory of the git work tree
+  const diff = await git(root, [
+    'diff',
+    '--name-only',
+    '--relative',
+    base,
+    '--',
+  ]);
+  const untracked = await git(root, [
+    'ls-files',
+    '--others',
+    '--exclude-standard',
+  ]);
+  return [...diff.split('\n'), ...untracked.split('\n')].filter(Boolean);
+}
+
+/**
+ * Files git tracks or would add, less those `.gitignore` ignores and those
+ * deleted from the working tree, relative to `repoPath`. Returns null when
+ * `repoPath` is not a git repository.
+ */
+export async function listTrackedFiles(
+  repoPath: string
+): Promise<string[] | null> {
+  const root = getContainerPath(repoPath);
+  if ((await tryGit(root, ['rev-parse', '--is-inside-work-tree'])) === null) {
+    return null;
+  }
+  // -z lists names verbatim, where git would otherwise quote unusual ones
+  const list = async (args: string[]) =>
+    (await git(root, ['ls-files', '-z', ...args])).split('\0');
+  const deleted = new Set(await list(['--deleted']));
+  const files = await list(['--cached', '--others', '--exclude-standard']);
+  return [...new Set(files)].filter((file) => file && !deleted.has(file));
+}
+
+/**
+ * Whether any gate needs the changed file list
+ */
+export function usesChangedFiles(gates: QAGateConfig[]): boolean {
+  return gates.some(
+    (gate) => gate.changedFilesGlob !== undefined || gate.filesFromDiff
+  );
+}
+
+/**
+ * Why a gate should be skipped because none of its `changedFilesGlob`
+ * files changed, or null if it should run. Paths are relative to the
+ * repository root. A null file list (not a git repository) never skips.
+ */
+export function unchangedSkipReason(
+  gate: Pick<QAGateConfig, 'changedFilesGlob'>,
+  changedFiles: string[] | null
+): string | null {
+  if (gate.changedFilesGlob === undefined || changedFiles === null) {
+    return null;
+  }
+  const globs = [gate.changedFilesGlob].flat();
+  const changed = changedFiles.some((file) =>
+    globs.some((glob) => matchesGlob(file, glob))
+  );
+  return changed ? null : `No changes matching ${globs.join(', ')}`;
+}
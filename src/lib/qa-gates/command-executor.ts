@@ -1,20 +1,151 @@
 import { spawn } from 'child_process';
 import { existsSync } from 'fs';
+import { readFile } from 'fs/promises';
+import {
+  TailBuffer,
+  createLineWriter,
+  type LineFormatter,
+} from './output-buffer';
+import type { OutputMode } from './output-mode';
+import { createLineRedactor, type Redactor } from './redaction';
+import {
+  DEFAULT_KILL_GRACE_MS,
+  superviseChild,
+  trackChild,
+} from './process-group';
+import {
+  defaultShell,
+  findOnPath,
+  shellInvocation,
+  splitCommandLine,
+  type ShellInvocation,
+  type ShellName,
+} from './shell';
+import { isVerbose } from './status-board';
 
 export interface CommandError extends Error {
   stdout?: string;
   stderr?: string;
   code?: number | null;
+  /** Set when the command was stopped by its timeout */
+  timedOut?: boolean;
+  /** Set when the command was stopped by its abort signal */
+  cancelled?: boolean;
 }
 
+/**
+ * A gate command: a shell string, or an argv array executed without a shell
+ */
+export type GateCommand = string | string[];
+
+/**
+ * Destinations for Forge's own output: gate status lines and streamed
+ * gate output
+ */
+export interface OutputWriters {
+  stdout: (text: string) => void;
+  stderr: (text: string) => void;
+}
+
+/**
+ * What a command reads on stdin: literal text, or a file's contents
+ */
+export type GateStdin = { text: string } | { file: string };
+
 export interface ExecOptions {
   cwd: string;
-  timeout: number;
+  /** Piped to the command's stdin; /dev/null when unset */
+  stdin?: GateStdin;
+  /** Milliseconds; 0 or undefined disables the timeout */
+  timeout?: number;
+  /** Shell for string commands; see shell.ts for the default */
+  shell?: ShellName;
+  /** Aborting kills the command's process group */
+  signal?: AbortSignal;
+  /** Time between SIGTERM and SIGKILL when the command is stopped */
+  killGraceMs?: number;
+  /** Base environment for the command; defaults to the process env */
+  env?: NodeJS.ProcessEnv;
+  /** Bytes kept per stream; older output is dropped. Unlimited if unset */
+  maxOutputBytes?: number;
+  /** When set, output is also written live, each line formatted by it */
+  streamLine?: LineFormatter;
+  /** How much of the streamed output execGateCommand lets through */
+  outputMode?: OutputMode;
+  /** Where streamed output goes; defaults to the process's own streams */
+  output?: OutputWriters;
+  /** Masks secrets in output, line by line, before it is kept or echoed */
+  redact?: Redactor;
 }
 
 export interface ExecResult {
   stdout: string;
   stderr: string;
+  /** Set when a nonzero exit was accepted, e.g. by passIfOutputMatches */
+  exitCode?: number;
+}
+
+/**
+ * Where one stream's output goes: its tail buffer and, when streaming, the
+ * matching output writer. With a redactor, both only see masked whole
+ * lines.
+ */
+function outputSink(
+  buffer: TailBuffer,
+  stream: keyof OutputWriters,
+  options: ExecOptions
+) {
+  const format = options.streamLine;
+  const writer =
+    options.output?.[stream] ?? ((text: string) => process[stream].write(text));
+  const live =
+    format && createLineWriter((line) => format(line, stream), writer);
+  const write = (data: Buffer | string) => {
+    buffer.append(data);
+    live?.write(data);
+  };
+  const redactor =
+    options.redact &&
+    createLineRedactor(options.redact, write, options.maxOutputBytes);
+
+  return {
+    write: redactor?.write ?? write,
+    flush() {
+      redactor?.flush();
+      live?.flush();
+    },
+  };
+}
+
+/**
+ * Capture both streams of a child process into separate tail buffers and
+ * optionally echo them live. Each call gets its own buffers, so commands
+ * running in parallel never share state.
+ */
+function captureOutput(
+  child: ReturnType<typeof spawn>,
+  options: ExecOptions
+): () => ExecResult {
+  const buffers = {
+    stdout: new TailBuffer(options.maxOutputBytes),
+    stderr: new TailBuffer(options.maxOutputBytes),
+  };
+  const sinks = {
+    stdout: outputSink(buffers.stdout, 'stdout', options),
+    stderr: outputSink(buffers.stderr, 'stderr', options),
+  };
+
+  child.stdout?.on('data', (data: Buffer) => sinks.stdout.write(data));
+  child.stderr?.on('data', (data: Buffer) => sinks.stderr.write(data));
+
+  return () => {
+    sinks.stdout.flush();
+    sinks.stderr.flush();
+    return {
+      stdout: buffers.stdout.toString(),
+      stderr: buffers.stderr.toString(),
+    };
+  };
 }
 
 /**
@@ -56,9 +187,26 @@ export function getContainerPath(hostPath: string): string {
   return hostPath;
 }
 
-function getGitSafeEnv() {
+/**
+ * Render a command for logs and execution records.
+ * Argv entries containing whitespace or quotes are single-quoted.
+ */
+export function formatCommand(command: GateCommand): string {
+  if (!Array.isArray(command)) {
+    return command;
+  }
+  return command
+    .map((arg) =>
+      arg === '' || /[\s'"\\$`]/.test(arg)
+        ? `'${arg.replace(/'/g, `'\\''`)}'`
+        : arg
+    )
+    .join(' ');
+}
+
+function getGitSafeEnv(baseEnv: NodeJS.ProcessEnv = process.env) {
   return {
-    ...process.env,
+    ...baseEnv,
     GIT_CONFIG_COUNT: '3',
     GIT_CONFIG_KEY_0: 'safe.directory',
     GIT_CONFIG_VALUE_0: '*',
@@ -71,56 +219,139 @@ function getGitSafeEnv() {
 
 function createCommandError(
   code: number | null,
-  stdout: string,
-  stderr: string
+  { stdout, stderr }: ExecResult,
+  message = `Command failed with exit code ${code}`
 ): CommandError {
-  const error: CommandError = new Error(
-    `Command failed with exit code ${code}`
-  );
+  const error: CommandError = new Error(message);
   error.stdout = stdout;
   error.stderr = stderr;
   error.code = code;
   return error;
 }
 
+/**
+ * The executable and arguments for a command. Argv arrays, and strings
+ * under shell `none`, are spawned directly so arguments are never
+ * re-parsed; other strings go through the shell.
+ */
+function commandInvocation(
+  command: GateCommand,
+  options: ExecOptions
+): ShellInvocation {
+  const shell = options.shell ?? defaultShell();
+  if (Array.isArray(command) || shell === 'none') {
+    const [file, ...args] = Array.isArray(command)
+      ? command
+      : splitCommandLine(command);
+    if (!file) {
+      throw new Error('Command must not be empty');
+    }
+    return { file, args };
+  }
+  if (shell === undefined) {
+    return { file: getBashPath(), args: ['-c', command] };
+  }
+
+  const invocation = shellInvocation(shell, command);
+  const file = findOnPath(invocation.file, options.env);
+  if (!file) {
+    throw new Error(`Shell "${shell}" not found on PATH`);
+  }
+  return { ...invocation, file };
+}
+
+/**
+ * Spawn a command. Each command leads its own process group so it can be
+ * stopped along with its children.
+ */
+function spawnCommand(
+  command: GateCommand,
+  options: ExecOptions,
+  input: string | Buffer | undefined
+) {
+  const { file, args, verbatim } = commandInvocation(command, options);
+  const child = spawn(file, args, {
+    cwd: options.cwd,
+    env: getGitSafeEnv(options.env),
+    // Without input, stdin is /dev/null so nothing waits on a terminal
+    stdio: [input === undefined ? 'ignore' : 'pipe', 'pipe', 'pipe'],
+    detached: process.platform !== 'win32',
+    windowsVerbatimArguments: verbatim,
+  });
+  // A command exiting without reading all of its input is not an error
+  child.stdin?.on('error', () => undefined);
+  child.stdin?.end(input);
+  return child;
+}
+
+/**
+ * The bytes to pipe to a command, reading a `{ file }` input up front so a
+ * missing file fails before anything is spawned
+ */
+async function readStdin(
+  stdin: GateStdin | undefined
+): Promise<string | Buffer | undefined> {
+  if (!stdin) return undefined;
+  if ('text' in stdin) return stdin.text;
+  try {
+    return await readFile(stdin.file);
+  } catch (error) {
+    const reason = error instanceof Error ? error.message : String(error);
+    throw new Error(`Cannot read stdin file ${stdin.file}: ${reason}`);
+  }
+}
+
+function stopMessage(reason: 'timeout' | 'aborted', options: ExecOptions) {
+  return reason === 'timeout'
+    ? `Command timed out after ${options.timeout}ms`
+    : 'Command was cancelled';
+}
+
 /**
  * Execute a command using spawn
- * Works in both Docker/Alpine and NixOS environments
+ * Works in both Docker/Alpine and NixOS environments.
+ * Logs each command under `FORGE_VERBOSE`. On timeout or abort the whole
+ * process group gets SIGTERM, then SIGKILL after `killGraceMs`.
  */
 export async function execAsync(
-  command: string,
+  command: GateCommand,
   options: ExecOptions
 ): Promise<ExecResult> {
+  const verbose = isVerbose();
+  const input = await readStdin(options.stdin);
   return new Promise((resolve, reject) => {
-    console.log(`[execAsync] Running command: ${command}`);
-    console.log(`[execAsync] Working directory: ${options.cwd}`);
-
-    const child = spawn(getBashPath(), ['-c', command], {
-      cwd: options.cwd,
-      env: getGitSafeEnv(),
-      timeout: options.timeout,
-    });
+    if (verbose) {
+      const text = formatCommand(command);
+      const shown = options.redact ? options.redact(text) : text;
+      console.log(`[execAsync] Running command: ${shown}`);
+      console.log(`[execAsync] Working directory: ${options.cwd}`);
+    }
 
-    let stdout = '';
-    let stderr = '';
+    const graceMs = options.killGraceMs ?? DEFAULT_KILL_GRACE_MS;
+    const child = spawnCommand(command, options, input);
+    const collect = captureOutput(child, options);
+    trackChild(child, graceMs);
+    const stopReason = superviseChild(child, { ...options, graceMs });
 
-    child.stdout?.on('data', (data) => {
-      stdout += data.toString();
-    });
-    child.stderr?.on('data', (data) => {
-      stderr += data.toString();
-    });
     child.on('error', (error) => {
       reject(error);
     });
 
     child.on('close', (code) => {
-      if (code === 0) {
-        console.log(`[execAsync] Command succeeded`);
-        resolve({ stdout, stderr });
+      const output = collect();
+      const reason = stopReason();
+      if (code === 0 && !reason) {
+        if (verbose) console.log(`[execAsync] Command succeeded`);
+        resolve(output);
       } else {
-        console.error(`[execAsync] Command failed with exit code ${code}`);
-        reject(createCommandError(code, stdout, stderr));
+        const message = reason ? stopMessage(reason, options) : undefined;
+        if (verbose) {
+          console.error(`[execAsync] ${message ?? `Exit code ${code}`}`);
+        }
+        const error = createCommandError(code, output, message);
+        if (reason === 'timeout') error.timedOut = true;
+        if (reason === 'aborted') error.cancelled = true;
+        reject(error);
       }
     });
   });
@@ -0,0 +1,166 @@
+import type { QAGateConfig } from './config-loader';
+
+/**
+ * A gate's `enabledWhen`: an expression over the environment such as
+ * `env.CI == 'true'`, or `{ envSet: 'CI' }`
+ */
+export type GateCondition = string | { envSet: string };
+
+type Evaluate = (env: NodeJS.ProcessEnv) => boolean;
+
+type Operand =
+  | { kind: 'env'; name: string }
+  | { kind: 'string'; value: string };
+
+type Token = Operand | { kind: 'op'; value: string };
+
+/** Reported as the skip reason of a gate whose condition is false */
+export const DISABLED_BY_CONDITION = 'disabled by condition';
+
+const TOKEN =
+  /\s*(?:(&&|\|\||==|!=|!|\(|\))|env\.([A-Za-z_][A-Za-z0-9_]*)|'([^']*)'|"([^"]*)")/y;
+
+function toToken([, op, name, single, double]: RegExpExecArray): Token {
+  if (op) return { kind: 'op', value: op };
+  if (name) return { kind: 'env', name };
+  return { kind: 'string', value: single ?? double ?? '' };
+}
+
+function tokenize(source: string): Token[] {
+  const pattern = new RegExp(TOKEN.source, 'y');
+  const tokens: Token[] = [];
+  while (source.slice(pattern.lastIndex).trim() !== '') {
+    const rest = source.slice(pattern.lastIndex).trim();
+    const match = pattern.exec(source);
+    if (!match) throw new Error(`Unexpected "${rest}" in condition`);
+    tokens.push(toToken(match));
+  }
+  return tokens;
+}
+
+function describeToken(token: Token): string {
+  if (token.kind === 'env') return `env.${token.name}`;
+  if (token.kind === 'string') return `'${token.value}'`;
+  return `"${token.value}"`;
+}
+
+// Unset variables compare as empty strings
+const valueOf = (operand: Operand, env: NodeJS.ProcessEnv) =>
+  operand.kind === 'env' ? (env[operand.name] ?? '') : operand.value;
+
+/**
+ * Recursive descent over the tokens: `||` binds looser than `&&`, which
+ * binds looser than `!`; parentheses group
+ */
+class ConditionParser {
+  private readonly tokens: Token[];
+  private index = 0;
+
+  constructor(tokens: Token[]) {
+    this.tokens = tokens;
+  }
+
+  parse(): Evaluate {
+    const condition = this.or();
+    if (this.index < this.tokens.length) throw this.unexpected();
+    return condition;
+  }
+
+  private or(): Evaluate {
+    let left = this.and();
+    while (this.take('||')) {
+      const [a, b] = [left, this.and()];
+      left = (env) => a(env) || b(env);
+    }
+    return left;
+  }
+
+  private and(): Evaluate {
+    let left = this.unary();
+    while (this.take('&&')) {
+      const [a, b] = [left, this.unary()];
+      left = (env) => a(env) && b(env);
+    }
+    return left;
+  }
+
+  private unary(): Evaluate {
+    if (this.take('!')) {
+      const inner = this.unary();
+      return (env) => !inner(env);
+    }
+    if (!this.take('(')) return this.comparison();
+    const inner = this.or();
+    if (!this.take(')')) throw new Error('Missing ) in condition');
+    return inner;
+  }
+
+  private comparison(): Evaluate {
+    const left = this.operand();
+    const equal = this.take('==') ? true : this.take('!=') ? false : null;
+    if (equal === null) {
+      if (left.kind === 'string') {
+        throw new Error(`Compare '${left.value}' with == or !=`);
+      }
+      // A bare variable holds when it is set and not empty
+      return (env) => valueOf(left, env) !== '';
+    }
+    const right = this.operand();
+    return (env) => (valueOf(left, env) === valueOf(right, env)) === equal;
+  }
+
+  private operand(): Operand {
+    const token = this.tokens[this.index];
+    if (!token || token.kind === 'op') throw this.unexpected();
+    this.index++;
+    return token;
+  }
+
+  private take(op: string): boolean {
+    const token = this.tokens[this.index];
+    if (token?.kind !== 'op' || token.value !== op) return false;
+    this.index++;
+    return true;
+  }
+
+  private unexpected(): Error {
+    const token = this.tokens[this.index];
+    return new Error(
+      token
+        ? `Unexpected ${describeToken(token)} in condition`
+        : 'Condition ends unexpectedly'
+    );
+  }
+}
+
+/**
+ * Compile an `enabledWhen` expression: `env.NAME` references, quoted
+ * strings, `==`, `!=`, `!`, `&&`, `||` and parentheses. Throws on a
+ * malformed expression.
+ */
+export function parseCondition(source: string): Evaluate {
+  return new ConditionParser(tokenize(source)).parse();
+}
+
+/**
+ * Whether a gate's condition holds in `env`; no condition always holds.
+ * `envSet` holds when the variable is set and not empty.
+ */
+export function conditionHolds(
+  condition: GateCondition | undefined,
+  env: NodeJS.ProcessEnv = process.env
+): boolean {
+  if (condition === undefined) return true;
+  if (typeof condition !== 'string') return Boolean(env[condition.envSet]);
+  return parseCondition(condition)(env);
+}
+
+/**
+ * Why a gate is skipped for its `enabledWhen`, or null when it runs
+ */
+export function conditionSkipReason(
+  gate: Pick<QAGateConfig, 'enabledWhen'>,
+  env: NodeJS.ProcessEnv = process.env
+): string | null {
+  return conditionHolds(gate.enabledWhen, env) ? null : DISABLED_BY_CONDITION;
+}
@@ -0,0 +1,108 @@
+import path from 'path';
+
+type RawConfig = Record<string, unknown>;
+type RawGate = Record<string, unknown>;
+
+/**
+ * Reads and parses a config file, e.g. a base named in `extends`
+ */
+export type ConfigReader = (configPath: string) => Promise<unknown>;
+
+// Guards against long accidental chains even when no file repeats
+const MAX_EXTENDS_DEPTH = 10;
+
+function isObject(value: unknown): value is Record<string, unknown> {
+  return typeof value === 'object' && value !== null && !Array.isArray(value);
+}
+
+function mergeEnv(base: unknown, child: unknown): unknown {
+  if (isObject(base) && isObject(child)) return { ...base, ...child };
+  return child ?? base;
+}
+
+function mergeGate(base: RawGate, child: RawGate): RawGate {
+  const merged = { ...base, ...child };
+  const env = mergeEnv(base.env, child.env);
+  if (env !== undefined) merged.env = env;
+  return merged;
+}
+
+/**
+ * Merge child gates into base gates by `name`: matching gates are merged
+ * field by field, new gates are appended
+ */
+function mergeGates(base: unknown, child: unknown): unknown {
+  if (!Array.isArray(base) || !Array.isArray(child)) return child ?? base;
+
+  const merged = [...base] as RawGate[];
+  for (const gate of child as RawGate[]) {
+    const index = merged.findIndex(
+      (existing) => isObject(existing) && existing.name === gate?.name
+    );
+    if (index === -1) merged.push(gate);
+    else merged[index] = mergeGate(merged[index]!, gate);
+  }
+  return merged;
+}
+
+/**
+ * Overlay a child config on its base. Fields set in the child win; `env`
+ * maps merge key by key and `qaGates` merge by gate name.
+ */
+export function mergeConfigs(base: RawConfig, child: RawConfig): RawConfig {
+  const merged: RawConfig = { ...base, ...child };
+  delete merged.extends;
+
+  const env = mergeEnv(base.env, child.env);
+  if (env !== undefined) merged.env = env;
+  const qaGates = mergeGates(base.qaGates, child.qaGates);
+  if (qaGates !== undefined) merged.qaGates = qaGates;
+
+  return merged;
+}
+
+/**
+ * Resolve a raw config's `extends` chain into a single merged config.
+ * `extends` paths are relative to the file that names them. Circular or
+ * overly deep chains are rejected.
+ */
+export async function resolveExtends(
+  raw: unknown,
+  configPath: string,
+  read: ConfigReader,
+  chain: string[] = [path.resolve(configPath)]
+): Promise<unknown> {
+  if (!isObject(raw) || raw.extends === undefined) return raw;
+  if (typeof raw.extends !== 'string' || raw.extends === '') {
+    throw new Error(`"extends" in ${configPath} must be a file path`);
+  }
+
+  const basePath = path.resolve(path.dirname(configPath), raw.extends);
+  if (chain.includes(basePath)) {
+    const root = path.dirname(chain[0]!);
+    const names = [...chain, basePath].map((p) => path.relative(root, p));
+    throw new Error(`Circular extends: ${names.join(' -> ')}`);
+  }
+  if (chain.length > MAX_EXTENDS_DEPTH) {
+    throw new Error(`extends chain from ${chain[0]} is too deep`);
+  }
+
+  let baseRaw: unknown;
+  try {
+    baseRaw = await read(basePath);
+  } catch (error) {
+    const reason = error instanceof Error ? error.message : String(error);
+    throw new Error(
+      `Cannot load base config ${basePath} extended by ${configPath}: ${reason}`
+    );
+  }
+
+  const base = await resolveExtends(baseRaw, basePath, read, [
+    ...chain,
+    basePath,
+  ]);
+  if (!isObject(base)) {
+    throw new Error(`Base config ${basePath} must be an object`);
+  }
+  return mergeConfigs(base, raw);
+}
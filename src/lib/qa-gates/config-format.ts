@@ -0,0 +1,147 @@
+import fs from 'fs/promises';
+import path from 'path';
+import { getContainerPath } from './command-executor';
+import {
+  CONFIG_FIELDS,
+  locateConfigFile,
+  validateRepositoryConfig,
+} from './config-loader';
+
+type ConfigObject = Record<string, unknown>;
+
+// `version` leads and the gate list closes the file; the rest keep schema
+// order
+const ROOT_FIELDS = [
+  'version',
+  'extends',
+  ...CONFIG_FIELDS.root.filter((f) => f !== 'version' && f !== 'qaGates'),
+  'qaGates',
+];
+
+// Field order of nested objects by the field holding them; `env` maps
+// get their keys sorted
+const NESTED_FIELDS = new Map<string, string[]>([
+  ['beforeAll', CONFIG_FIELDS.runHook],
+  ['afterAll', CONFIG_FIELDS.runHook],
+  ['retryIf', CONFIG_FIELDS.retryIf],
+  ['retryBackoff', CONFIG_FIELDS.retryBackoff],
+  ['notify', CONFIG_FIELDS.notify],
+  ['env', []],
+]);
+
+// Gates without an `order` run last, as the loader sorts them
+const UNORDERED = 999;
+
+const isObject = (value: unknown): value is ConfigObject =>
+  typeof value === 'object' && value !== null && !Array.isArray(value);
+
+/**
+ * Copy `object` with `fields` first, in that order, then any other fields
+ * sorted by name
+ */
+function orderFields(object: ConfigObject, fields: string[]): ConfigObject {
+  const known = fields.filter((field) => field in object);
+  const rest = Object.keys(object)
+    .filter((field) => !fields.includes(field))
+    .sort();
+  return Object.fromEntries(
+    [...known, ...rest].map((field) => [field, object[field]])
+  );
+}
+
+function orderNested(object: ConfigObject): ConfigObject {
+  return Object.fromEntries(
+    Object.entries(object).map(([field, value]) => {
+      const fields = NESTED_FIELDS.get(field);
+      return [
+        field,
+        fields && isObject(value) ? orderFields(value, fields) : value,
+      ];
+    })
+  );
+}
+
+function normalizeGate(gate: ConfigObject, defaultTimeout: unknown) {
+  const timed =
+    gate.timeout === undefined && defaultTimeout !== undefined
+      ? { ...gate, timeout: defaultTimeout }
+      : gate;
+  return orderFields(orderNested(timed), CONFIG_FIELDS.gate);
+}
+
+function compareGates(a: ConfigObject, b: ConfigObject): number {
+  const order = (gate: ConfigObject) =>
+    typeof gate.order === 'number' ? gate.order : UNORDERED;
+  const [nameA, nameB] = [String(a.name), String(b.name)];
+  return (
+    order(a) - order(b) || (nameA < nameB ? -1 : nameA > nameB ? 1 : 0)
+  );
+}
+
+/**
+ * Normalize a raw config: gates sorted by `order` then name, gates
+ * without a timeout given `defaultTimeout`, and every object's fields in
+ * schema order, unknown ones last by name. Normalizing a normalized config
+ * changes nothing.
+ */
+export function normalizeConfig(config: ConfigObject): ConfigObject {
+  // Under `extends`, a gate may take its timeout from the base config
+  const defaultTimeout =
+    'extends' in config ? undefined : config.defaultTimeout;
+  const gates = Array.isArray(config.qaGates)
+    ? {
+        qaGates: config.qaGates
+          .filter(isObject)
+          .map((gate) => normalizeGate(gate, defaultTimeout))
+          .sort(compareGates),
+      }
+    : {};
+  return orderFields(orderNested({ ...config, ...gates }), ROOT_FIELDS);
+}
+
+/**
+ * A raw config as formatted .forge.json text: normalized, indented by two
+ * spaces and ending with a newline
+ */
+export function formatConfigText(config: ConfigObject): string {
+  return `${JSON.stringify(normalizeConfig(config), null, 2)}\n`;
+}
+
+export type FormatResult =
+  | { status: 'changed' | 'unchanged'; configPath: string }
+  | { status: 'invalid'; problems: string[] }
+  | { status: 'unsupported'; reason: string };
+
+/**
+ * Rewrite a repository's .forge.json in its formatted form; with `check`,
+ * only tell whether that would change it. An invalid config is left
+ * alone, and so is a YAML one, since rewriting it would drop its
+ * comments.
+ */
+export async function formatRepositoryConfig(
+  repoPath: string,
+  check = false
+): Promise<FormatResult> {
+  const problems = await validateRepositoryConfig(repoPath);
+  if (problems.length > 0) return { status: 'invalid', problems };
+
+  const configPath = await locateConfigFile(getContainerPath(repoPath));
+  const name = path.basename(configPath);
+  if (!name.endsWith('.json')) {
+    const reason = `${name} can't be formatted without losing its comments`;
+    return { status: 'unsupported', reason };
+  }
+
+  let text: string;
+  try {
+    text = await fs.readFile(configPath, 'utf-8');
+  } catch (error) {
+    if ((error as NodeJS.ErrnoException).code !== 'ENOENT') throw error;
+    return { status: 'unsupported', reason: `No ${name} to format` };
+  }
+
+  const formatted = formatConfigText(JSON.parse(text));
+  if (formatted === text) return { status: 'unchanged', configPath };
+  if (!check) await fs.writeFile(configPath, formatted, 'utf-8');
+  return { status: 'changed', configPath };
+}
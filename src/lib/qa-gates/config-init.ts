@@ -0,0 +1,171 @@
+import fs from 'fs/promises';
+import path from 'path';
+import { dump as dumpYaml } from 'js-yaml';
+import { getContainerPath } from './command-executor';
+import { formatConfigText, normalizeConfig } from './config-format';
+import { validateConfig, type ConfigFormat } from './config-loader';
+import { CONFIG_TEMPLATES } from './config-templates';
+
+type ConfigObject = Record<string, unknown>;
+
+export type ProjectKind =
+  | 'go'
+  | 'rust'
+  | 'python'
+  | 'typescript'
+  | 'javascript'
+  | 'make';
+
+// Files telling a project's kind, checked in order; the first found wins
+const PROJECT_MARKERS: [string, ProjectKind][] = [
+  ['go.mod', 'go'],
+  ['Cargo.toml', 'rust'],
+  ['pyproject.toml', 'python'],
+  ['setup.py', 'python'],
+  ['requirements.txt', 'python'],
+  ['tsconfig.json', 'typescript'],
+  ['package.json', 'javascript'],
+  ['Makefile', 'make'],
+];
+
+// Makefile targets that make good gates, in the order they run
+const MAKE_TARGETS = [
+  { target: 'lint', timeout: '10m', order: 1 },
+  { target: 'test', timeout: '15m', order: 2 },
+];
+
+// The gates a `minimal` starter keeps: its format or lint check and tests
+const MINIMAL_GATES: Record<ProjectKind, string[]> = {
+  go: ['Go Fmt', 'Go Test'],
+  rust: ['Rust Format', 'Cargo Test'],
+  python: ['Ruff', 'Pytest'],
+  typescript: ['ESLint', 'Tests'],
+  javascript: ['ESLint', 'Tests'],
+  make: ['make lint', 'make test'],
+};
+
+async function listTargets(makefile: string): Promise<Set<string>> {
+  const text = await fs.readFile(makefile, 'utf-8').catch(() => '');
+  const targets = [...text.matchAll(/^([A-Za-z][\w-]*)\s*:(?!=)/gm)];
+  return new Set(targets.map((match) => match[1]!));
+}
+
+/**
+ * What kind of project `root` holds, judged by its marker files: go.mod,
+ * Cargo.toml, a package.json, ... A Makefile only counts when it has a
+ * `lint` or `test` target. null when nothing matches.
+ */
+export async function detectProject(root: string): Promise<ProjectKind | null> {
+  const entries = await fs.readdir(root).catch(() => [] as string[]);
+  for (const [file, kind] of PROJECT_MARKERS) {
+    if (!entries.includes(file)) continue;
+    if (kind !== 'make') return kind;
+    const targets = await listTargets(path.join(root, file));
+    if (MAKE_TARGETS.some(({ target }) => targets.has(target))) return kind;
+  }
+  return null;
+}
+
+async function makeStarter(root: string): Promise<ConfigObject> {
+  const targets = await listTargets(path.join(root, 'Makefile'));
+  return {
+    version: '1.0',
+    qaGates: MAKE_TARGETS.filter(({ target }) => targets.has(target)).map(
+      ({ target, timeout, order }) => ({
+        name: `make ${target}`,
+        command: `make ${target}`,
+        timeout,
+        order,
+      })
+    ),
+  };
+}
+
+/**
+ * A starter config for a `kind` project in `root`: the standard gates of
+ * its toolchain, checks before tests, each with a timeout. `minimal` keeps
+ * only the format or lint check and the tests.
+ */
+export async function starterConfig(
+  root: string,
+  kind: ProjectKind,
+  minimal = false
+): Promise<ConfigObject> {
+  const config =
+    kind === 'make' ? await makeStarter(root) : CONFIG_TEMPLATES[kind]!;
+  if (!minimal) return config;
+  const gates = config.qaGates as { name: string }[];
+  return {
+    ...config,
+    qaGates: gates.filter((gate) => MINIMAL_GATES[kind].includes(gate.name)),
+  };
+}
+
+/**
+ * A config as the text of a new config file, normalized like `format`
+ * leaves it
+ */
+export function formatStarterText(
+  config: ConfigObject,
+  format: ConfigFormat
+): string {
+  return format === 'yaml'
+    ? dumpYaml(normalizeConfig(config))
+    : formatConfigText(config);
+}
+
+export interface InitOptions {
+  /** Replace an existing config file */
+  force?: boolean;
+  /** Defaults to JSON */
+  format?: ConfigFormat;
+  /** Only the format or lint check and the tests */
+  minimal?: boolean;
+}
+
+export type InitResult =
+  | {
+      status: 'created';
+      configPath: string;
+      project: ProjectKind;
+      gates: string[];
+    }
+  | { status: 'exists'; configPath: string }
+  | { status: 'undetected' };
+
+// Every name the loader reads a config from
+const CONFIG_FILES = ['.forge.json', '.forge.yaml', '.forge.yml'];
+
+/**
+ * Write a starter .forge.json, or .forge.yaml, for the project detected in
+ * a repository. An existing config file is left alone unless `force` is
+ * set, in which case it is replaced, whatever its name, so one config
+ * file remains. The starter is validated before it is written.
+ */
+export async function initRepositoryConfig(
+  repoPath: string,
+  { force = false, format = 'json', minimal = false }: InitOptions = {}
+): Promise<InitResult> {
+  const root = getContainerPath(repoPath);
+  const entries = await fs.readdir(root).catch(() => [] as string[]);
+  const existing = CONFIG_FILES.filter((name) => entries.includes(name));
+  if (existing[0] && !force) {
+    return { status: 'exists', configPath: path.join(root, existing[0]) };
+  }
+
+  const project = await detectProject(root);
+  if (!project) return { status: 'undetected' };
+  const config = await starterConfig(root, project, minimal);
+  validateConfig(config);
+
+  const configPath = path.join(
+    root,
+    format === 'yaml' ? '.forge.yaml' : '.forge.json'
+  );
+  for (const name of existing) await fs.rm(path.join(root, name));
+  await fs.writeFile(configPath, formatStarterText(config, format), 'utf-8');
+  const gates = (normalizeConfig(config).qaGates as { name: string }[]).map(
+    (gate) => gate.name
+  );
+  return { status: 'created', configPath, project, gates };
+}
@@ -1,6 +1,31 @@
 import fs from 'fs/promises';
 import path from 'path';
+import { load as loadYaml } from 'js-yaml';
 import { z } from 'zod';
+import { mergeConfigs, resolveExtends } from './config-extends';
+import { parseCondition } from './conditions';
+import {
+  expandMatrixGates,
+  matrixInstanceNames,
+  matrixReferences,
+  type GateMatrix,
+} from './matrix';
+import { parseOutputMode } from './output-mode';
+import { isGateRegistered } from './builtin-gates';
+import { parseTimeout } from './duration';
+import { resolveWorkdir } from './gate-resolver';
+import { NOTIFY_WHEN } from './notify';
+import { LOG_FORMATS } from './output-buffer';
+import { parseRedactPattern } from './redaction';
+import { findDependencyCycle } from './scheduler';
+import { SEVERITIES } from './severity';
+import { LOG_LEVELS } from './status-board';
+import {
+  SHELLS,
+  findOnPath,
+  shellInvocation,
+  splitCommandLine,
+} from './shell';
 
 /**
  * Convert host path to container path
@@ -15,30 +40,444 @@ function getContainerPath(hostPath: string): string {
   return hostPath;
 }
 
+/**
+ * Timeout in milliseconds, or a duration string such as "30s" or "5m".
+ * Parsed to milliseconds; 0 or absent means no timeout.
+ */
+const TimeoutSchema = z
+  .union([z.number(), z.string()])
+  .transform((value, ctx) => {
+    try {
+      return parseTimeout(value);
+    } catch (error) {
+      ctx.addIssue({
+        code: z.ZodIssueCode.custom,
+        message: error instanceof Error ? error.message : String(error),
+      });
+      return z.NEVER;
+    }
+  });
+
+/**
+ * A regular expression source, compiled here so bad patterns fail
+ * validation instead of the run
+ */
+const RegexSchema = z.string().superRefine((pattern, ctx) => {
+  try {
+    new RegExp(pattern);
+  } catch (error) {
+    ctx.addIssue({
+      code: z.ZodIssueCode.custom,
+      message: error instanceof Error ? error.message : String(error),
+    });
+  }
+});
+
+const ShellSchema = z.enum(SHELLS);
+
+/**
+ * An `enabledWhen` expression, parsed here so malformed ones fail
+ * validation instead of the run
+ */
+const ConditionExpressionSchema = z.string().superRefine((source, ctx) => {
+  try {
+    parseCondition(source);
+  } catch (error) {
+    ctx.addIssue({
+      code: z.ZodIssueCode.custom,
+      message: error instanceof Error ? error.message : String(error),
+    });
+  }
+});
+
+const EnvSetObject = z.object({ envSet: z.string().min(1) });
+
+const OutputModeSchema = z.string().superRefine((value, ctx) => {
+  try {
+    parseOutputMode(value);
+  } catch (error) {
+    ctx.addIssue({
+      code: z.ZodIssueCode.custom,
+      message: error instanceof Error ? error.message : String(error),
+    });
+  }
+});
+
+/**
+ * A gate's `matrix`: at least one variable, each with at least one value
+ */
+const MatrixSchema = z
+  .record(
+    z
+      .string()
+      .regex(
+        /^[A-Za-z_][A-Za-z0-9_]*$/,
+        'Matrix variable names are letters, digits and _'
+      ),
+    z.array(z.union([z.string(), z.number(), z.boolean()])).min(1)
+  )
+  .refine((matrix) => Object.keys(matrix).length > 0, {
+    message: 'matrix needs at least one variable',
+  });
+
+const ExitCodeSchema = z
+  .number()
+  .int()
+  .min(0, 'Exit codes must not be negative');
+
+const RetryIfObject = z.object({
+  exitCode: z.union([ExitCodeSchema, z.array(ExitCodeSchema)]).optional(),
+  outputMatches: RegexSchema.optional(),
+});
+
+const hasRetryCondition = (retryIf: z.infer<typeof RetryIfObject>) =>
+  retryIf.exitCode !== undefined || retryIf.outputMatches !== undefined;
+
+const RETRY_IF_EMPTY = { message: 'retryIf needs exitCode or outputMatches' };
+
+/**
+ * Schema for the failures worth retrying: any listed exit code, or output
+ * matching the pattern
+ */
+const RetryIfSchema = RetryIfObject.refine(hasRetryCondition, RETRY_IF_EMPTY);
+
+// A literal string, or a regular expression written as /source/flags
+const RedactPatternSchema = z
+  .string()
+  .min(1)
+  .superRefine((pattern, ctx) => {
+    try {
+      parseRedactPattern(pattern);
+    } catch (error) {
+      ctx.addIssue({
+        code: z.ZodIssueCode.custom,
+        message: error instanceof Error ? error.message : String(error),
+      });
+    }
+  });
+
+const CommandSchema = z.union([z.string(), z.array(z.string()).min(1)]);
+
+const RunHookObject = z.object({
+  command: CommandSchema,
+  timeout: TimeoutSchema.optional(),
+  // Whether a failure fails the run: by default beforeAll's does (and
+  // skips the gates), afterAll's doesn't
+  failOnError: z.boolean().optional(),
+});
+
+/**
+ * `beforeAll` / `afterAll`: a command run once around a repository run's
+ * gates, or an object giving it a timeout and failure handling
+ */
+const RunHookSchema = z.union([CommandSchema, RunHookObject]);
+
 /**
  * Schema for a single QA gate configuration
  */
 const QAGateConfigSchema = z.object({
   name: z.string(),
   enabled: z.boolean().default(true),
-  command: z.string(),
-  timeout: z.number().default(60000),
+  // Skip the gate unless this holds for Forge's environment, e.g.
+  // "env.CI == 'true'" or { envSet: 'CI' }; enabled: false still wins
+  enabledWhen: z.union([ConditionExpressionSchema, EnvSetObject]).optional(),
+  // 'builtin' runs a gate registered with registerGate; default 'command'
+  type: z.enum(['command', 'builtin']).optional(),
+  // A shell string, or an argv array spawned directly without a shell.
+  // Builtin gates default to "builtin:<name>", used in logs and reports.
+  command: z.union([z.string(), z.array(z.string()).min(1)]),
+  // Registered name of a builtin gate, and the options its factory gets
+  builtin: z.string().min(1).optional(),
+  options: z.unknown().optional(),
+  timeout: TimeoutSchema.optional(),
   failOnError: z.boolean().default(true),
+  // error | warning | info; overrides failOnError when set
+  severity: z.enum(SEVERITIES).optional(),
   order: z.number().optional(),
+  // Names of gates that must finish first; takes precedence over `order`
+  dependsOn: z.array(z.string()).optional(),
+  env: z.record(z.string()).optional(),
+  // Variable names mapped to value lists: the gate runs once per
+  // combination, with ${matrix.NAME} substituted in its command and env
+  matrix: MatrixSchema.optional(),
+  // Limit ${files:PATTERN} to files changed since the base ref
+  filesFromDiff: z.boolean().optional(),
+  // Piped to the command: text with ${VAR} expanded, or "@path" to send a
+  // file relative to the config file's directory. Unset reads /dev/null.
+  stdin: z.string().optional(),
+  // Skip the gate unless a file matching one of these globs changed
+  changedFilesGlob: z.union([z.string(), z.array(z.string())]).optional(),
+  // Labels for selecting gates, e.g. ?tag=lint
+  tags: z.array(z.string()).optional(),
+  // Working directory relative to the config file's directory
+  workdir: z.string().min(1).optional(),
+  // Matched against stdout and stderr once the command exits: a match
+  // fails a zero exit / passes a nonzero one. failIfOutputMatches wins.
+  failIfOutputMatches: RegexSchema.optional(),
+  passIfOutputMatches: RegexSchema.optional(),
+  // Exit codes counted as success, in place of just 0; [] allows any
+  allowedExitCodes: z.array(ExitCodeSchema).optional(),
+  // Extra attempts a failing gate gets within one repository run
+  retries: z.number().int().min(0).optional(),
+  // Only retry failures that look transient; unset retries any failure
+  retryIf: RetryIfSchema.optional(),
+  // Fix command run after a failure, before the next retry attempt
+  onFailure: z.union([z.string(), z.array(z.string()).min(1)]).optional(),
+  // Override the root output cap / live streaming for this gate
+  maxOutputBytes: z.number().int().positive().optional(),
+  streamOutput: z.boolean().optional(),
+  // full | tail:N | head:N | onFailureOnly: how much streamed output shows
+  outputMode: OutputModeSchema.optional(),
+  // Shell for a string command; overrides the root shell
+  shell: ShellSchema.optional(),
+  // Reuse the last passing result while these files, the command and its
+  // env are unchanged
+  cacheInputs: z.union([z.string(), z.array(z.string())]).optional(),
+  // Files the gate produces, recorded (and copied) once it has run; with
+  // artifactsRequired a glob matching nothing fails a passing gate
+  artifacts: z.union([z.string(), z.array(z.string())]).optional(),
+  artifactsRequired: z.boolean().optional(),
 });
 
+/**
+ * Give builtin gates written without a command the display command
+ * "builtin:<name>"
+ */
+function withBuiltinCommand(gate: unknown): unknown {
+  if (typeof gate !== 'object' || gate === null) return gate;
+  const { type, builtin, command } = gate as Record<string, unknown>;
+  if (type !== 'builtin' || command !== undefined) return gate;
+  return { ...gate, command: `builtin:${builtin ?? ''}` };
+}
+
+/**
+ * Schema for the delay between retry attempts
+ */
+const RetryBackoffSchema = z.object({
+  strategy: z.enum(['fixed', 'linear', 'exponential']).default('fixed'),
+  baseDelayMs: z.number().min(0).default(0),
+  maxDelayMs: z.number().min(0).optional(),
+  jitter: z.boolean().optional(),
+});
+
+/**
+ * Schema for the webhook called when a repository run finishes
+ */
+const NotifySchema = z.object({
+  // ${VAR} placeholders expand, so tokens can stay out of the file
+  webhookURL: z.string().min(1),
+  when: z.enum(NOTIFY_WHEN).default('failure'),
+  // JSON body; {{name}} placeholders in its strings are filled in
+  payload: z.unknown().optional(),
+});
+
+interface ReferencingConfig {
+  qaGates: {
+    name: string;
+    dependsOn?: string[];
+    type?: string;
+    builtin?: string;
+    matrix?: GateMatrix;
+  }[];
+}
+
+/**
+ * Reject duplicate gate names and builtin gates that don't name their
+ * `builtin`. Returns the names gates can depend on: every gate's, and
+ * those of matrix gates' instances.
+ */
+function collectGateNames(config: ReferencingConfig, ctx: z.RefinementCtx) {
+  const names = new Set<string>();
+
+  config.qaGates.forEach((gate, index) => {
+    if (gate.type === 'builtin' && !gate.builtin) {
+      ctx.addIssue({
+        code: z.ZodIssueCode.custom,
+        path: ['qaGates', index, 'builtin'],
+        message: `Builtin gate "${gate.name}" needs a builtin name`,
+      });
+    }
+    if (names.has(gate.name)) {
+      ctx.addIssue({
+        code: z.ZodIssueCode.custom,
+        path: ['qaGates', index, 'name'],
+        message: `Duplicate gate name "${gate.name}"`,
+      });
+    }
+    names.add(gate.name);
+  });
+  for (const name of config.qaGates.flatMap(matrixInstanceNames)) {
+    names.add(name);
+  }
+  return names;
+}
+
+/**
+ * Reject duplicate gate names, dependencies on unknown gates, dependency
+ * cycles and builtin gates that don't name their `builtin`
+ */
+function validateGateReferences(
+  config: ReferencingConfig,
+  ctx: z.RefinementCtx
+) {
+  const names = collectGateNames(config, ctx);
+
+  config.qaGates.forEach((gate, index) => {
+    for (const dependency of gate.dependsOn ?? []) {
+      if (names.has(dependency)) continue;
+      ctx.addIssue({
+        code: z.ZodIssueCode.custom,
+        path: ['qaGates', index, 'dependsOn'],
+        message: `Gate "${gate.name}" depends on unknown gate "${dependency}"`,
+      });
+    }
+  });
+
+  const cycle = findDependencyCycle(config.qaGates);
+  if (cycle) {
+    ctx.addIssue({
+      code: z.ZodIssueCode.custom,
+      path: ['qaGates'],
+      message: `Dependency cycle between gates: ${cycle.join(' -> ')}`,
+    });
+  }
+}
+
+/**
+ * Reject `${matrix.NAME}` references to variables a gate's matrix doesn't
+ * define
+ */
+function validateMatrices(
+  config: {
+    qaGates: Pick<QAGateConfig, 'name' | 'command' | 'env' | 'matrix'>[];
+  },
+  ctx: z.RefinementCtx
+) {
+  config.qaGates.forEach((gate, index) => {
+    for (const name of matrixReferences(gate)) {
+      if (gate.matrix && name in gate.matrix) continue;
+      ctx.addIssue({
+        code: z.ZodIssueCode.custom,
+        path: ['qaGates', index, 'matrix'],
+        message:
+          `Gate "${gate.name}" uses \${matrix.${name}}, ` +
+          "which its matrix doesn't define",
+      });
+    }
+  });
+}
+
 /**
  * Schema for the .forge.json configuration file
  */
-const ForgeConfigSchema = z.object({
-  qaGates: z.array(QAGateConfigSchema),
+const ForgeConfigObject = z.object({
+  qaGates: z.array(z.preprocess(withBuiltinCommand, QAGateConfigSchema)),
+  // Setup and teardown around a repository run's gates
+  beforeAll: RunHookSchema.optional(),
+  afterAll: RunHookSchema.optional(),
   maxRetries: z.number().default(3).optional(),
+  // 'all' re-runs every gate on retry; 'failed' keeps gates that already
+  // passed ahead of the first failure
+  retryMode: z.enum(['all', 'failed']).optional(),
+  retryBackoff: RetryBackoffSchema.optional(),
+  // Timeout for gates without their own; a gate's explicit 0 stays unbounded
+  defaultTimeout: TimeoutSchema.optional(),
+  // Keep running later stages after an error-severity gate fails; the run
+  // still fails once every stage has run
+  continueOnFailure: z.boolean().optional(),
+  // Upper bound on gates running at once
+  maxParallel: z.number().int().positive().optional(),
   version: z.string().default('1.0').optional(),
+  // Environment applied to every gate; gate-level env takes precedence
+  env: z.record(z.string()).optional(),
+  // Fail on unknown ${VAR} placeholders (default) instead of expanding to ''
+  strictEnv: z.boolean().optional(),
+  // Default working directory for gates without their own workdir
+  workdir: z.string().min(1).optional(),
+  // Write a JSON RunResult here after every repository run
+  reportJson: z.string().min(1).optional(),
+  // Write Prometheus text-format metrics here after every repository run
+  metricsOut: z.string().min(1).optional(),
+  // Write a JUnit XML report here after every repository run
+  junit: z.string().min(1).optional(),
+  // Bytes of stdout and of stderr kept per gate; earlier output is dropped
+  maxOutputBytes: z.number().int().positive().optional(),
+  // Echo gate output live to Forge's own stdout/stderr while it runs
+  streamOutput: z.boolean().optional(),
+  // Streamed lines as `[gate] line` text or as JSON objects
+  logFormat: z.enum(LOG_FORMATS).optional(),
+  // quiet | normal | verbose | debug; a run's own logLevel overrides it
+  logLevel: z.enum(LOG_LEVELS).optional(),
+  // Between SIGTERM and SIGKILL when a gate is stopped
+  shutdownGraceMs: z.number().int().min(0).optional(),
+  // Shell for string commands; 'none' splits them into argv instead
+  shell: ShellSchema.optional(),
+  // Masked as *** in gate output, reports and logs
+  redact: z.array(RedactPatternSchema).optional(),
+  // Names of env variables whose values are masked like `redact` patterns
+  secretEnv: z.array(z.string().min(1)).optional(),
+  notify: NotifySchema.optional(),
 });
 
-export type QAGateConfig = z.infer<typeof QAGateConfigSchema>;
+const ForgeConfigSchema = ForgeConfigObject.superRefine(validateGateReferences)
+  .superRefine(validateMatrices)
+  .transform((config) => ({
+    ...config,
+    qaGates: expandMatrixGates<QAGateConfig>(config.qaGates),
+  }));
+
+/**
+ * Same rules as ForgeConfigSchema, but unknown fields are errors too. The
+ * loader tolerates unknown fields; validation reports them as likely typos.
+ */
+const StrictForgeConfigSchema = ForgeConfigObject.extend({
+  qaGates: z.array(
+    z.preprocess(
+      withBuiltinCommand,
+      QAGateConfigSchema.extend({
+        retryIf: RetryIfObject.strict()
+          .refine(hasRetryCondition, RETRY_IF_EMPTY)
+          .optional(),
+        enabledWhen: z
+          .union([ConditionExpressionSchema, EnvSetObject.strict()])
+          .optional(),
+      }).strict()
+    )
+  ),
+  beforeAll: z.union([CommandSchema, RunHookObject.strict()]).optional(),
+  afterAll: z.union([CommandSchema, RunHookObject.strict()]).optional(),
+  retryBackoff: RetryBackoffSchema.strict().optional(),
+  notify: NotifySchema.strict().optional(),
+})
+  .strict()
+  .superRefine(validateGateReferences)
+  .superRefine(validateMatrices);
+
+/**
+ * Fields of each config object in the order the schemas declare them,
+ * which is the order formatted configs are written in
+ */
+export const CONFIG_FIELDS = {
+  root: Object.keys(ForgeConfigObject.shape),
+  gate: Object.keys(QAGateConfigSchema.shape),
+  runHook: Object.keys(RunHookObject.shape),
+  retryIf: Object.keys(RetryIfObject.shape),
+  retryBackoff: Object.keys(RetryBackoffSchema.shape),
+  notify: Object.keys(NotifySchema.shape),
+};
+
+export type QAGateConfig = z.infer<typeof QAGateConfigSchema> & {
+  /** Set on a matrix gate's instances: the name of the gate in the config */
+  matrixOf?: string;
+};
+export type RunHook = z.infer<typeof RunHookSchema>;
 export type ForgeConfig = z.infer<typeof ForgeConfigSchema>;
 
+/**
+ * Root-level settings that apply to every gate in a run
+ */
+export type GateSettings = Omit<ForgeConfig, 'qaGates'>;
+
 /**
  * Default configuration for repositories without .forge.json
  */
@@ -73,14 +512,204 @@ const DEFAULT_CONFIG: ForgeConfig = {
   ],
 };
 
-async function loadConfigFromFile(configPath: string): Promise<ForgeConfig> {
-  await fs.access(configPath);
-  const configContent = await fs.readFile(configPath, 'utf-8');
-  const config = ForgeConfigSchema.parse(JSON.parse(configContent));
+async function isDirectory(dir: string): Promise<boolean> {
+  try {
+    return (await fs.stat(dir)).isDirectory();
+  } catch {
+    return false;
+  }
+}
+
+/**
+ * Describe every gate whose working directory does not exist
+ */
+async function findMissingWorkdirs(
+  config: ForgeConfig,
+  root: string
+): Promise<string[]> {
+  const problems: string[] = [];
+  for (const gate of config.qaGates) {
+    if (!gate.workdir && !config.workdir) continue;
+    const dir = resolveWorkdir(gate, root, config);
+    if (!(await isDirectory(dir))) {
+      problems.push(
+        `Working directory "${dir}" for gate "${gate.name}" does not exist`
+      );
+    }
+  }
+  return problems;
+}
+
+/**
+ * Describe every gate whose shell is not installed, or whose command
+ * can't be split into arguments under shell `none`
+ */
+function findShellProblems(config: ForgeConfig): string[] {
+  const problems: string[] = [];
+  for (const gate of config.qaGates) {
+    const shell = gate.shell ?? config.shell;
+    const command = gate.type === 'builtin' ? undefined : gate.command;
+    const commands = [command, gate.onFailure].filter(
+      (command): command is string => typeof command === 'string'
+    );
+    if (!shell || commands.length === 0) continue;
+
+    if (shell !== 'none') {
+      if (!findOnPath(shellInvocation(shell, '').file)) {
+        problems.push(
+          `Shell "${shell}" for gate "${gate.name}" not found on PATH`
+        );
+      }
+      continue;
+    }
+    try {
+      commands.forEach(splitCommandLine);
+    } catch (error) {
+      problems.push(`${(error as Error).message} of gate "${gate.name}"`);
+    }
+  }
+  return problems;
+}
+
+/**
+ * Describe every builtin gate whose name nothing has registered
+ */
+function findUnregisteredBuiltins(config: ForgeConfig): string[] {
+  return config.qaGates
+    .filter((gate) => gate.type === 'builtin' && gate.builtin)
+    .filter((gate) => !isGateRegistered(gate.builtin!))
+    .map(
+      (gate) =>
+        `Builtin "${gate.builtin}" of gate "${gate.name}" is not registered`
+    );
+}
+
+/**
+ * Problems with what a parsed config refers to: working directories,
+ * shells and builtin gates
+ */
+async function findSetupProblems(
+  config: ForgeConfig,
+  configDir: string
+): Promise<string[]> {
+  return [
+    ...(await findMissingWorkdirs(config, configDir)),
+    ...findShellProblems(config),
+    ...findUnregisteredBuiltins(config),
+  ];
+}
+
+/**
+ * Render a zod issue as "path: message", e.g. "qaGates[1].timeout: ..."
+ */
+function formatIssue(issue: z.ZodIssue): string {
+  let where = '';
+  for (const key of issue.path) {
+    if (typeof key === 'number') where += `[${key}]`;
+    else where += where ? `.${key}` : key;
+  }
+  return where ? `${where}: ${issue.message}` : issue.message;
+}
+
+export type ConfigFormat = 'json' | 'yaml';
+
+// Config file names, in lookup order
+const CONFIG_FILE_NAMES = ['.forge.json', '.forge.yaml', '.forge.yml'];
+
+function formatFromPath(file: string): ConfigFormat {
+  return /\.ya?ml$/i.test(file) ? 'yaml' : 'json';
+}
+
+/**
+ * Parse config text as JSON or YAML. Without a format, text starting with
+ * `{` is taken as JSON and anything else as YAML, for configs that arrive
+ * without a file name.
+ */
+export function parseConfigText(text: string, format?: ConfigFormat): unknown {
+  const detected =
+    format ?? (text.trimStart().startsWith('{') ? 'json' : 'yaml');
+  return detected === 'json' ? JSON.parse(text) : loadYaml(text);
+}
+
+/**
+ * Path of the config file in `dir`: .forge.json, .forge.yaml or .forge.yml.
+ * Falls back to the .forge.json path when there is none, so a missing file
+ * is reported the usual way. Throws when more than one exists, since it
+ * would be ambiguous which one applies.
+ */
+export async function locateConfigFile(dir: string): Promise<string> {
+  const entries = await fs.readdir(dir).catch(() => [] as string[]);
+  const found = CONFIG_FILE_NAMES.filter((name) => entries.includes(name));
+  if (found.length > 1) {
+    throw new Error(
+      `Found ${found.join(' and ')} in ${dir}; keep only one config file`
+    );
+  }
+  return path.join(dir, found[0] ?? CONFIG_FILE_NAMES[0]!);
+}
+
+async function readConfigFile(configPath: string): Promise<unknown> {
+  const text = await fs.readFile(configPath, 'utf-8');
+  return parseConfigText(text, formatFromPath(configPath));
+}
+
+// Force true singleton using global to survive hot-reloads
+const globalForWarnings = global as typeof globalThis & {
+  forgeTimeoutWarnings?: Set<string>;
+};
+
+// Config path and gate name of every missing-timeout warning already shown
+const timeoutWarnings = (globalForWarnings.forgeTimeoutWarnings ??= new Set());
+
+/**
+ * Give every gate a concrete timeout: its own, else `defaultTimeout`, else
+ * 0 (none). Gates left with no timeout at all get a warning, since they can
+ * hang a run forever. A config is loaded several times per run, so each
+ * gate of each config file is warned about once.
+ */
+function resolveTimeouts(config: ForgeConfig, configPath: string): void {
+  for (const gate of config.qaGates) {
+    const key = `${configPath}\0${gate.name}`;
+    if (
+      gate.timeout === undefined &&
+      config.defaultTimeout === undefined &&
+      !timeoutWarnings.has(key)
+    ) {
+      timeoutWarnings.add(key);
+      console.warn(
+        `Gate "${gate.name}" has no timeout and may run forever; ` +
+          'set timeout or defaultTimeout'
+      );
+    }
+    gate.timeout = gate.timeout ?? config.defaultTimeout ?? 0;
+  }
+}
+
+/**
+ * Merge `extends`, parse and check a raw config as if it were read from
+ * `configPath`
+ */
+async function prepareConfig(
+  raw: unknown,
+  configPath: string
+): Promise<ForgeConfig> {
+  const config = ForgeConfigSchema.parse(
+    await resolveExtends(raw, configPath, readConfigFile)
+  );
+  const problems = await findSetupProblems(config, path.dirname(configPath));
+  if (problems.length > 0) {
+    throw new Error(problems.join('\n'));
+  }
+  resolveTimeouts(config, configPath);
   config.qaGates.sort((a, b) => (a.order ?? 999) - (b.order ?? 999));
   return config;
 }
 
+async function loadConfigFromFile(configPath: string): Promise<ForgeConfig> {
+  await fs.access(configPath);
+  return prepareConfig(await readConfigFile(configPath), configPath);
+}
+
 function handleConfigError(
   error: unknown,
   configPath: string,
@@ -93,7 +722,7 @@ function handleConfigError(
     error.code === 'ENOENT'
   ) {
     console.log(
-      `ℹ️ No .forge.json found in ${containerPath}, using default config`
+      `ℹ️ No .forge.json or .forge.yaml found in ${containerPath}, using default config`
     );
     return DEFAULT_CONFIG;
   }
@@ -108,15 +737,17 @@ function handleConfigError(
 }
 
 /**
- * Load QA gate configuration from repository's .forge.json file with timeout
+ * Load QA gate configuration from repository's .forge.json (or .forge.yaml)
+ * file with timeout
  */
 export async function loadRepositoryConfig(
   repoPath: string
 ): Promise<ForgeConfig> {
   const containerPath = getContainerPath(repoPath);
-  const configPath = path.join(containerPath, '.forge.json');
+  let configPath = path.join(containerPath, CONFIG_FILE_NAMES[0]!);
 
   try {
+    configPath = await locateConfigFile(containerPath);
     const result = await Promise.race([
       loadConfigFromFile(configPath),
       new Promise<never>((_, reject) =>
@@ -130,6 +761,290 @@ export async function loadRepositoryConfig(
   }
 }
 
+/**
+ * Load a repository's config file without falling back on errors.
+ * A missing file still yields the default config, but an invalid or
+ * ambiguous one throws so callers can report it.
+ */
+export async function readRepositoryConfig(
+  repoPath: string
+): Promise<ForgeConfig> {
+  const configPath = await locateConfigFile(getContainerPath(repoPath));
+
+  try {
+    return await loadConfigFromFile(configPath);
+  } catch (error) {
+    if (
+      error &&
+      typeof error === 'object' &&
+      'code' in error &&
+      error.code === 'ENOENT'
+    ) {
+      return DEFAULT_CONFIG;
+    }
+    throw error;
+  }
+}
+
+/**
+ * Stands for the inline `config` among `configPath` entries, as `-` stands
+ * for stdin on a command line
+ */
+export const STDIN_CONFIG_PATH = '-';
+
+// How problems name the inline config
+const STDIN_LABEL = '<stdin>';
+
+export interface RunConfigSource {
+  /**
+   * Config files to use instead of the repository's, relative to its root.
+   * Several merge left to right, like `extends`.
+   */
+  configPath?: string | string[];
+  /** Config object, or JSON or YAML text, to use instead of any file */
+  config?: unknown;
+}
+
+/**
+ * Check a run request body's `configPath` and `config`. Returns a message
+ * for the first problem found.
+ */
+export function parseRunConfigSource(
+  body: unknown
+): RunConfigSource | string {
+  if (!body || typeof body !== 'object') return 'Expected a JSON object';
+  const { configPath, config } = body as Record<string, unknown>;
+  const entries = typeof configPath === 'string' ? [configPath] : configPath;
+  if (
+    entries !== undefined &&
+    !(Array.isArray(entries) && entries.every((e) => typeof e === 'string'))
+  ) {
+    return 'configPath must be a string or an array of strings';
+  }
+  if (entries?.includes(STDIN_CONFIG_PATH) && config === undefined) {
+    return `configPath "${STDIN_CONFIG_PATH}" needs an inline config`;
+  }
+  return { configPath: entries, config };
+}
+
+// The config's layers in merge order; an inline config not placed with
+// `-` goes last
+function runConfigEntries({ configPath, config }: RunConfigSource): string[] {
+  const entries = configPath === undefined ? [] : [configPath].flat();
+  if (config !== undefined && !entries.includes(STDIN_CONFIG_PATH)) {
+    entries.push(STDIN_CONFIG_PATH);
+  }
+  return entries;
+}
+
+interface ConfigLayer {
+  raw: unknown;
+  /** Path `extends` and working directories resolve against */
+  configPath: string;
+  /** Names the layer in problems */
+  label: string;
+}
+
+function describeConfigError(error: unknown): string[] {
+  if (error instanceof z.ZodError) return error.issues.map(formatIssue);
+  const message = error instanceof Error ? error.message : String(error);
+  return message.split('\n');
+}
+
+function labelProblems(label: string, problems: string[]): string[] {
+  return problems.map((problem) => `${label}: ${problem}`);
+}
+
+async function readLayerText(
+  root: string,
+  entry: string,
+  config: unknown
+): Promise<ConfigLayer> {
+  if (entry === STDIN_CONFIG_PATH) {
+    const raw = typeof config === 'string' ? parseConfigText(config) : config;
+    const configPath = path.join(root, CONFIG_FILE_NAMES[0]!);
+    return { raw, configPath, label: STDIN_LABEL };
+  }
+  const configPath = path.resolve(root, entry);
+  try {
+    await fs.access(configPath);
+  } catch {
+    throw new Error('Config file not found');
+  }
+  return { raw: await readConfigFile(configPath), configPath, label: entry };
+}
+
+/**
+ * Read one layer of a run's config and merge its own `extends`. Errors
+ * name the layer, `<stdin>` for the inline config.
+ */
+async function readConfigLayer(
+  root: string,
+  entry: string,
+  config: unknown
+): Promise<ConfigLayer> {
+  const label = entry === STDIN_CONFIG_PATH ? STDIN_LABEL : entry;
+  try {
+    const layer = await readLayerText(root, entry, config);
+    const raw = await resolveExtends(
+      layer.raw,
+      layer.configPath,
+      readConfigFile
+    );
+    return { ...layer, raw };
+  } catch (error) {
+    const problems = labelProblems(label, describeConfigError(error));
+    throw new Error(problems.join('\n'));
+  }
+}
+
+function isConfigObject(raw: unknown): raw is Record<string, unknown> {
+  return typeof raw === 'object' && raw !== null && !Array.isArray(raw);
+}
+
+/**
+ * Merge a run's config layers left to right. Later layers override earlier
+ * ones as a child overrides its `extends` base; the last layer's location
+ * resolves working directories.
+ */
+async function mergeRunConfig(
+  root: string,
+  source: RunConfigSource
+): Promise<ConfigLayer> {
+  const [first, ...rest] = runConfigEntries(source);
+  let merged = await readConfigLayer(root, first!, source.config);
+  for (const entry of rest) {
+    const layer = await readConfigLayer(root, entry, source.config);
+    const [base, child] = [merged.raw, layer.raw];
+    if (!isConfigObject(base) || !isConfigObject(child)) {
+      const culprit = isConfigObject(base) ? layer : merged;
+      throw new Error(`${culprit.label}: config must be an object`);
+    }
+    merged = {
+      raw: mergeConfigs(base, child),
+      configPath: layer.configPath,
+      label: `${merged.label} + ${layer.label}`,
+    };
+  }
+  return merged;
+}
+
+function hasRunConfig(source: RunConfigSource): boolean {
+  return runConfigEntries(source).length > 0;
+}
+
+/**
+ * Load the config for a remotely triggered run: an inline config, given
+ * config files, both merged, or the repository's own. Throws on any
+ * problem instead of falling back, naming the file or `<stdin>` it came
+ * from. An inline config resolves `extends` and working directories
+ * against the repository root.
+ */
+export async function loadRunConfig(
+  repoPath: string,
+  source: RunConfigSource
+): Promise<ForgeConfig> {
+  if (!hasRunConfig(source)) return readRepositoryConfig(repoPath);
+
+  const layer = await mergeRunConfig(getContainerPath(repoPath), source);
+  try {
+    return await prepareConfig(layer.raw, layer.configPath);
+  } catch (error) {
+    const problems = labelProblems(layer.label, describeConfigError(error));
+    throw new Error(problems.join('\n'));
+  }
+}
+
+/**
+ * Check a config object against every rule, including unknown fields.
+ * Returns all problems found rather than stopping at the first; an empty
+ * list means the config is valid.
+ */
+export function findConfigProblems(config: unknown): string[] {
+  const result = StrictForgeConfigSchema.safeParse(config);
+  return result.success ? [] : result.error.issues.map(formatIssue);
+}
+
+/**
+ * Read and parse a config file for validation, turning read and syntax
+ * errors into problems. A missing file parses as `undefined`.
+ */
+async function readConfigForValidation(
+  configPath: string
+): Promise<{ raw?: unknown; problems: string[] }> {
+  const format = formatFromPath(configPath);
+  try {
+    // An empty YAML file parses to undefined; keep it distinct from missing
+    return { raw: (await readConfigFile(configPath)) ?? null, problems: [] };
+  } catch (error) {
+    if (error && typeof error === 'object' && 'code' in error) {
+      return { problems: error.code === 'ENOENT' ? [] : [String(error)] };
+    }
+    const label = format === 'json' ? 'JSON' : 'YAML';
+    return { problems: [`Invalid ${label}: ${(error as Error).message}`] };
+  }
+}
+
+/**
+ * Validate a repository's config file without running anything: syntax,
+ * schema rules and working directories. A missing file is valid, since
+ * the defaults apply.
+ */
+export async function validateRepositoryConfig(
+  repoPath: string
+): Promise<string[]> {
+  let configPath: string;
+  try {
+    configPath = await locateConfigFile(getContainerPath(repoPath));
+  } catch (error) {
+    return [(error as Error).message];
+  }
+
+  const { raw, problems: readProblems } =
+    await readConfigForValidation(configPath);
+  if (readProblems.length > 0 || raw === undefined) return readProblems;
+
+  let merged: unknown;
+  try {
+    merged = await resolveExtends(raw, configPath, readConfigFile);
+  } catch (error) {
+    return [(error as Error).message];
+  }
+
+  const problems = findConfigProblems(merged);
+  if (problems.length > 0) return problems;
+
+  const config = ForgeConfigSchema.parse(merged);
+  return findSetupProblems(config, path.dirname(configPath));
+}
+
+/**
+ * Validate a run's config as validateRepositoryConfig validates the
+ * repository's file, naming the file or `<stdin>` problems come from.
+ * Without `configPath` or `config`, validates the repository's file.
+ */
+export async function validateRunConfig(
+  repoPath: string,
+  source: RunConfigSource
+): Promise<string[]> {
+  if (!hasRunConfig(source)) return validateRepositoryConfig(repoPath);
+
+  let layer: ConfigLayer;
+  try {
+    layer = await mergeRunConfig(getContainerPath(repoPath), source);
+  } catch (error) {
+    return describeConfigError(error);
+  }
+
+  const problems = findConfigProblems(layer.raw);
+  if (problems.length === 0) {
+    const config = ForgeConfigSchema.parse(layer.raw);
+    const configDir = path.dirname(layer.configPath);
+    problems.push(...(await findSetupProblems(config, configDir)));
+  }
+  return labelProblems(layer.label, problems);
+}
+
 /**
  * Get enabled QA gates from repository configuration
  */
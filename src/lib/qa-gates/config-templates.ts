@@ -100,8 +100,10 @@ export const GO_CONFIG: ForgeConfig = {
     {
       name: 'Go Fmt',
       enabled: true,
-      command: 'go fmt ./...',
-      timeout: 30000,
+      command: 'gofmt -l .',
+      // gofmt lists the files it would change and exits 0 either way
+      failIfOutputMatches: '\\S',
+      timeout: 60000,
       failOnError: true,
       order: 1,
     },
@@ -109,17 +111,25 @@ export const GO_CONFIG: ForgeConfig = {
       name: 'Go Vet',
       enabled: true,
       command: 'go vet ./...',
-      timeout: 60000,
+      timeout: 300000,
       failOnError: true,
-      order: 2,
+      order: 1,
+    },
+    {
+      name: 'Go Lint',
+      enabled: true,
+      command: 'golangci-lint run',
+      timeout: 600000,
+      failOnError: true,
+      order: 1,
     },
     {
       name: 'Go Test',
       enabled: true,
       command: 'go test ./...',
-      timeout: 300000,
+      timeout: 900000,
       failOnError: true,
-      order: 3,
+      order: 2,
     },
   ],
 };
@@ -0,0 +1,50 @@
+const UNIT_MS: Record<string, number> = {
+  ns: 1e-6,
+  us: 1e-3,
+  'µs': 1e-3,
+  ms: 1,
+  s: 1000,
+  m: 60_000,
+  h: 3_600_000,
+};
+
+const SEGMENT = /(\d+(?:\.\d*)?|\.\d+)(ns|us|µs|ms|s|m|h)/y;
+
+/**
+ * Parse a Go-style duration string ("1500ms", "2m", "1h30m") into
+ * milliseconds. A bare "0" is accepted; any other value needs a unit.
+ */
+export function parseDuration(input: string): number {
+  const text = input.trim();
+  if (text === '0') return 0;
+
+  const sign = text.startsWith('-') ? -1 : 1;
+  const body = text.replace(/^[-+]/, '');
+  if (body.length === 0) {
+    throw new Error(`Invalid duration "${input}"`);
+  }
+
+  let total = 0;
+  SEGMENT.lastIndex = 0;
+
+  while (SEGMENT.lastIndex < body.length) {
+    const match = SEGMENT.exec(body);
+    if (!match) {
+      throw new Error(`Invalid duration "${input}"`);
+    }
+    total += Number(match[1]) * UNIT_MS[match[2]!]!;
+  }
+  return sign * Math.round(total);
+}
+
+/**
+ * Normalize a timeout from config: numbers are milliseconds, strings are
+ * durations. Negative values are rejected.
+ */
+export function parseTimeout(value: number | string): number {
+  const ms = typeof value === 'number' ? value : parseDuration(value);
+  if (ms < 0) {
+    throw new Error(`Timeout must not be negative, got ${value}`);
+  }
+  return ms;
+}
@@ -0,0 +1,43 @@
+import type { QARunStatus } from '@/db/schema';
+
+/**
+ * Process exit codes for scripts wrapping a repository run, so CI can
+ * branch on why it failed. Forge never exits on a run's outcome itself;
+ * RunResult.exitCode and the API carry the code for the caller to use.
+ */
+export const EXIT_CODES = {
+  /** Every error-severity gate passed */
+  passed: 0,
+  /** An error-severity gate failed */
+  failed: 1,
+  /** The config or gate selection is invalid, so nothing ran */
+  configError: 2,
+  /** The run was cancelled, as shells report SIGINT */
+  interrupted: 130,
+} as const;
+
+export type ExitCode = (typeof EXIT_CODES)[keyof typeof EXIT_CODES];
+
+/**
+ * The exit code for a run's status; null while it is still going. A
+ * cancelled run exits 130 even when it is recorded as failed.
+ */
+export function runExitCode(
+  status: QARunStatus | 'planned',
+  interrupted = false
+): ExitCode | null {
+  if (status === 'cancelled' || (interrupted && status === 'failed')) {
+    return EXIT_CODES.interrupted;
+  }
+  if (status === 'passed') return EXIT_CODES.passed;
+  if (status === 'failed') return EXIT_CODES.failed;
+  return null;
+}
+
+/**
+ * The exit code for checking a config before a run: 2 when validation
+ * found any problem
+ */
+export function validationExitCode(problems: string[]): ExitCode {
+  return problems.length > 0 ? EXIT_CODES.configError : EXIT_CODES.passed;
+}
@@ -0,0 +1,122 @@
+import path from 'path';
+import type { QAGateConfig } from './config-loader';
+import type { GateCommand } from './command-executor';
+import { matchesGlob } from './glob';
+import { defaultShell, quoteArgument, type ShellName } from './shell';
+
+const FILESET = /\$\{files:([^}]+)\}/g;
+const WHOLE_FILESET = /^\$\{files:([^}]+)\}$/;
+
+/**
+ * The file lists `${files:PATTERN}` draws from, relative to the repository
+ * root
+ */
+export interface FileLists {
+  /** Tracked and not ignored files; null outside a git repository */
+  tracked: string[] | null;
+  /** Changed since the base ref, for `filesFromDiff`; null when all are */
+  changed: string[] | null;
+}
+
+type FilesetGate = Pick<QAGateConfig, 'command' | 'filesFromDiff'>;
+
+/**
+ * The patterns of a command's `${files:PATTERN}` placeholders
+ */
+export function filesetPatterns(command: GateCommand): string[] {
+  const patterns = [command]
+    .flat()
+    .flatMap((text) => [...text.matchAll(FILESET)].map((match) => match[1]!));
+  return [...new Set(patterns)];
+}
+
+/**
+ * Whether any gate needs the tracked file list
+ */
+export function usesFilesets(gates: Pick<QAGateConfig, 'command'>[]): boolean {
+  return gates.some((gate) => filesetPatterns(gate.command).length > 0);
+}
+
+function selectFiles(
+  pattern: string,
+  gate: FilesetGate,
+  files: FileLists
+): string[] {
+  const matching = (files.tracked ?? []).filter((file) =>
+    matchesGlob(file, pattern)
+  );
+  if (!gate.filesFromDiff || files.changed === null) return matching;
+  const changed = new Set(files.changed);
+  return matching.filter((file) => changed.has(file));
+}
+
+/**
+ * Why a gate is skipped because one of its `${files:PATTERN}` placeholders
+ * matches no file, or null if it should run. Outside a git repository it
+ * runs, and fails to resolve its command.
+ */
+export function emptyFilesetSkipReason(
+  gate: FilesetGate,
+  files: FileLists
+): string | null {
+  if (files.tracked === null) return null;
+  const empty = filesetPatterns(gate.command).find(
+    (pattern) => selectFiles(pattern, gate, files).length === 0
+  );
+  if (empty === undefined) return null;
+  return gate.filesFromDiff
+    ? `No changed files matching ${empty}`
+    : `No files matching ${empty}`;
+}
+
+interface ExpandFilesetsParams {
+  gate: FilesetGate;
+  files: FileLists;
+  /** Directory the file lists are relative to */
+  root: string;
+  /** The command's working directory, which inserted paths are relative to */
+  cwd: string;
+  /** Shell quoting for string commands; the platform default when unset */
+  shell?: ShellName;
+}
+
+/**
+ * Replace `${files:PATTERN}` placeholders by the files matching PATTERN,
+ * changed ones only under `filesFromDiff`: quoted and space-separated in a
+ * string command, one entry each in an argv command, where the
+ * placeholder must be a whole entry. Throws outside a git repository.
+ */
+export function expandFilesets(
+  command: GateCommand,
+  { gate, files, root, cwd, shell }: ExpandFilesetsParams
+): GateCommand {
+  const list = (pattern: string) => {
+    if (files.tracked === null) {
+      throw new Error(`\${files:${pattern}} needs a git repository`);
+    }
+    return selectFiles(pattern, gate, files).map((file) => {
+      const relative = path.relative(cwd, path.join(root, file));
+      // Keep a leading dash from reading as an option
+      return relative.startsWith('-') ? `./${relative}` : relative;
+    });
+  };
+
+  if (!Array.isArray(command)) {
+    const quoting = shell ?? defaultShell() ?? 'sh';
+    return command.replace(FILESET, (_match, pattern: string) =>
+      list(pattern)
+        .map((file) => quoteArgument(file, quoting))
+        .join(' ')
+    );
+  }
+  return command.flatMap((arg) => {
+    const whole = WHOLE_FILESET.exec(arg);
+    if (whole) return list(whole[1]!);
+    if (filesetPatterns(arg).length > 0) {
+      throw new Error(
+        `\${files:...} must be a whole argument of an argv command`
+      );
+    }
+    return [arg];
+  });
+}
@@ -0,0 +1,172 @@
+import { createHash } from 'crypto';
+import fs from 'fs/promises';
+import path from 'path';
+import type { QAGateConfig } from './config-loader';
+import { execAsync, type ExecResult } from './command-executor';
+import type { ResolvedGate } from './gate-resolver';
+import { matchesGlob } from './glob';
+
+/**
+ * Default directory, under the config root, holding one entry per cached
+ * gate
+ */
+export const CACHE_DIR = '.forge-cache';
+
+export interface CacheEntry {
+  gateName: string;
+  key: string;
+  stdout: string;
+  stderr: string;
+  exitCode: number;
+  /** RFC 3339 */
+  createdAt: string;
+}
+
+// Directories never worth hashing when walking a tree outside git
+const SKIPPED_DIRS = new Set(['.git', CACHE_DIR, 'node_modules']);
+
+/**
+ * Every file under `root`, relative to it, except those under `.git`,
+ * `.forge-cache` and `node_modules`
+ */
+export async function walk(root: string, dir = ''): Promise<string[]> {
+  const entries = await fs.readdir(path.join(root, dir), {
+    withFileTypes: true,
+  });
+  const files: string[] = [];
+  for (const entry of entries) {
+    const relative = dir ? `${dir}/${entry.name}` : entry.name;
+    if (entry.isDirectory() && !SKIPPED_DIRS.has(entry.name)) {
+      files.push(...(await walk(root, relative)));
+    } else if (entry.isFile()) {
+      files.push(relative);
+    }
+  }
+  return files;
+}
+
+/**
+ * Tracked and untracked files under `root`, leaving out what .gitignore
+ * ignores. Outside a git repository, every file except those under
+ * `.git`, `.forge-cache` and `node_modules`.
+ */
+async function listFiles(root: string): Promise<string[]> {
+  try {
+    const { stdout } = await execAsync(
+      ['git', 'ls-files', '--cached', '--others', '--exclude-standard'],
+      { cwd: root, timeout: 30000 }
+    );
+    return stdout.split('\n').filter(Boolean);
+  } catch {
+    return walk(root);
+  }
+}
+
+/**
+ * Files matching a gate's `cacheInputs`, relative to `root` and sorted
+ */
+export async function listCacheInputs(
+  gate: Pick<QAGateConfig, 'cacheInputs'>,
+  root: string
+): Promise<string[]> {
+  const globs = [gate.cacheInputs ?? []].flat();
+  const files = await listFiles(root);
+  return files
+    .filter((file) => globs.some((glob) => matchesGlob(file, glob)))
+    .sort();
+}
+
+/**
+ * Hash everything a gate's result depends on: its resolved command,
+ * shell, working directory, environment and stdin, a builtin gate's
+ * options, and the path and contents of every input file, including a
+ * `stdin` file. Files listed but since deleted hash as missing.
+ */
+export async function computeCacheKey(
+  gate: Pick<QAGateConfig, 'cacheInputs' | 'options'>,
+  resolved: ResolvedGate,
+  root: string
+): Promise<string> {
+  const hash = createHash('sha256');
+  const { stdin } = resolved;
+  const env = Object.entries(resolved.env).sort(([a], [b]) =>
+    a < b ? -1 : a > b ? 1 : 0
+  );
+  hash.update(
+    JSON.stringify({
+      command: resolved.command,
+      shell: resolved.shell ?? null,
+      cwd: path.relative(root, resolved.cwd),
+      env,
+      options: gate.options ?? null,
+      // Only when set, so keys of gates without stdin stay as they were
+      ...(stdin && 'text' in stdin && { stdin: stdin.text }),
+    })
+  );
+
+  const files = await listCacheInputs(gate, root);
+  if (stdin && 'file' in stdin) files.push(path.relative(root, stdin.file));
+  for (const file of files) {
+    hash.update(`\0${file}\0`);
+    try {
+      hash.update(await fs.readFile(path.join(root, file)));
+    } catch {
+      hash.update('\0missing');
+    }
+  }
+  return hash.digest('hex');
+}
+
+function entryPath(cacheDir: string, gateName: string): string {
+  const name = createHash('sha256').update(gateName).digest('hex');
+  return path.join(cacheDir, `${name.slice(0, 16)}.json`);
+}
+
+/**
+ * The stored result of a gate's last passing run if it was stored in
+ * `cacheDir` under `key`, else null. An unreadable entry counts as a miss.
+ */
+export async function readCacheEntry(
+  cacheDir: string,
+  gateName: string,
+  key: string
+): Promise<CacheEntry | null> {
+  try {
+    const entry = JSON.parse(
+      await fs.readFile(entryPath(cacheDir, gateName), 'utf-8')
+    ) as CacheEntry;
+    return entry.key === key && entry.gateName === gateName ? entry : null;
+  } catch {
+    return null;
+  }
+}
+
+/**
+ * Store a gate's passing result in `cacheDir` under `key`, replacing its
+ * previous entry. The file is replaced atomically; a failure to write is
+ * logged rather than failing the gate.
+ */
+export async function writeCacheEntry(
+  cacheDir: string,
+  gateName: string,
+  key: string,
+  { stdout, stderr, exitCode = 0 }: ExecResult
+): Promise<void> {
+  const target = entryPath(cacheDir, gateName);
+  const temporary = `${target}.${process.pid}.tmp`;
+  const entry: CacheEntry = {
+    gateName,
+    key,
+    stdout,
+    stderr,
+    exitCode,
+    createdAt: new Date().toISOString(),
+  };
+  try {
+    await fs.mkdir(path.dirname(target), { recursive: true });
+    await fs.writeFile(temporary, JSON.stringify(entry) + '\n', 'utf-8');
+    await fs.rename(temporary, target);
+  } catch (error) {
+    console.warn(`Could not cache the result of gate "${gateName}":`, error);
+  }
+}
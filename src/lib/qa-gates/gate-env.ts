@@ -0,0 +1,15 @@
+export type EnvMap = Record<string, string>;
+
+/**
+ * Build the environment for a single gate.
+ * Precedence (lowest to highest): inherited process env, config-level env,
+ * gate-level env. A fresh object is returned on every call so variables set
+ * for one gate never leak into the next.
+ */
+export function buildGateEnv(
+  gateEnv?: EnvMap,
+  configEnv?: EnvMap,
+  baseEnv: NodeJS.ProcessEnv = process.env
+): NodeJS.ProcessEnv {
+  return { ...baseEnv, ...configEnv, ...gateEnv };
+}
@@ -1,131 +1,560 @@
-import { db } from '@/db';
-import { qaGateExecutions } from '@/db/schema';
-import { eq } from 'drizzle-orm';
-import type { QAGateConfig } from './config-loader';
+import path from 'path';
+import type { GateArtifact, GateAttempt } from '@/db/schema';
 import {
-  execAsync,
+  copyArtifacts,
+  findArtifacts,
+  findMissingArtifacts,
+  gateArtifactsDir,
+} from './artifacts';
+import type { GateSettings, QAGateConfig } from './config-loader';
+import {
+  formatCommand,
   getContainerPath,
   type CommandError,
+  type ExecOptions,
+  type ExecResult,
+  type GateCommand,
+  type OutputWriters,
 } from './command-executor';
+import {
+  CACHE_DIR,
+  computeCacheKey,
+  readCacheEntry,
+  writeCacheEntry,
+} from './gate-cache';
+import {
+  fixGate,
+  resolveGate,
+  resolveOutputOptions,
+  type ResolvedGate,
+} from './gate-resolver';
+import type { FileLists } from './filesets';
+import { writeLines } from './output-buffer';
+import { createOutputDisplay } from './output-mode';
+import { execGateCommand, isRetryable } from './output-rules';
+import {
+  computeBackoffDelay,
+  sleep,
+  type RetryBackoffConfig,
+} from './retry-backoff';
+import { databaseRunStore, type RunStore } from './run-store';
+import { formatElapsed } from './status-board';
 
 export interface GateExecutionResult {
   id: string;
   gateName: string;
-  status: 'passed' | 'failed';
+  status:
+    | 'passed'
+    | 'failed'
+    | 'skipped'
+    | 'cached'
+    | 'timedout'
+    | 'cancelled';
   duration: number;
+  /** Why a skipped gate did not run */
+  reason?: string;
+  /** Attempt the outcome came from, out of the attempts the gate had */
+  attempt?: number;
+  maxAttempts?: number;
+}
+
+interface RetriedError extends CommandError {
+  /** Failed attempts before the final one */
+  previousAttempts?: GateAttempt[];
 }
 
 interface ExecuteGateParams {
   runId: string;
   gate: QAGateConfig;
   repoPath: string;
+  settings?: GateSettings;
+  /** Files `${files:PATTERN}` expands to */
+  files?: FileLists;
+  /** Run `cacheInputs` gates even on a cache hit, refreshing the entry */
+  noCache?: boolean;
+  /** Cache directory, relative to the repository root */
+  cacheDir?: string;
+  /** Where `artifacts` are copied, relative to the repository root */
+  artifactsDir?: string;
+  /** Aborting stops the gate's command */
+  signal?: AbortSignal;
+  /** Where streamed output goes; defaults to the process's own streams */
+  output?: OutputWriters;
+  /** Log the resolved command, env and timings to stderr (`logLevel: debug`) */
+  debug?: boolean;
+  /** Defaults to the database */
+  store?: RunStore;
 }
 
+type DebugLog = (message: string) => void;
+
 /**
- * Create a gate execution record in the database
+ * Write `[debug] <gate>: message` lines to stderr at the debug log level;
+ * a no-op otherwise
  */
-async function createGateExecution(runId: string, gate: QAGateConfig) {
-  return (
-    await db
-      .insert(qaGateExecutions)
-      .values({
-        runId,
-        gateName: gate.name,
-        command: gate.command,
-        status: 'running',
-        order: gate.order || 0,
-      })
-      .returning()
-  )[0];
+function debugLogger({ gate, debug, output }: ExecuteGateParams): DebugLog {
+  if (!debug) return () => undefined;
+  const write = output?.stderr ?? ((text) => process.stderr.write(text));
+  return (message) => write(`[debug] ${gate.name}: ${message}\n`);
+}
+
+/**
+ * Log what a gate is about to run with. Only env set by the config or the
+ * gate is listed, with `redact` and `secretEnv` values masked.
+ */
+function logResolvedGate(
+  log: DebugLog,
+  { gate, settings }: ExecuteGateParams,
+  { command, env, cwd, shell, redact = (text) => text }: ResolvedGate
+) {
+  const timeout = gate.timeout ? formatElapsed(gate.timeout) : 'none';
+  log(`command ${redact(formatCommand(command))}`);
+  log(`cwd ${cwd}, shell ${shell ?? 'default'}, timeout ${timeout}`);
+  const names = Object.keys({ ...settings?.env, ...gate.env });
+  if (names.length === 0) return;
+  log(`env ${names.map((name) => redact(`${name}=${env[name]}`)).join(' ')}`);
+}
+
+/**
+ * Create a gate execution record
+ */
+async function createGateExecution(
+  store: RunStore,
+  runId: string,
+  gate: QAGateConfig
+) {
+  return store.insertExecution({
+    runId,
+    gateName: gate.name,
+    command: formatCommand(gate.command),
+    status: 'running',
+    order: gate.order || 0,
+    timeout: gate.timeout || null,
+  });
+}
+
+interface GateSuccess {
+  result: ExecResult;
+  duration: number;
+  /** `cached` when the result was replayed from the cache */
+  status: 'passed' | 'cached';
+  /** Failed attempts before the passing one */
+  previous: GateAttempt[];
+  /** Set when the gate declares `artifacts` */
+  artifacts?: GateArtifact[];
 }
 
 /**
  * Update gate execution with success status
  */
 async function updateGateSuccess(
+  store: RunStore,
   executionId: string,
-  stdout: string,
-  stderr: string,
-  duration: number
+  { result, duration, status, previous, artifacts }: GateSuccess
 ) {
-  await db
-    .update(qaGateExecutions)
-    .set({
-      status: 'passed',
-      output: stdout,
-      error: stderr || null,
-      exitCode: 0,
-      duration,
-      completedAt: new Date(),
-    })
-    .where(eq(qaGateExecutions.id, executionId));
+  const { stdout, stderr, exitCode = 0 } = result;
+  await store.updateExecution(executionId, {
+    status,
+    output: stdout,
+    error: stderr || null,
+    exitCode,
+    duration,
+    completedAt: new Date(),
+    ...(previous.length > 0 && { previousAttempts: previous }),
+    ...(artifacts && { artifacts }),
+  });
+}
+
+/**
+ * A failure's stderr. When Forge stopped the command, e.g. on timeout, the
+ * reason follows whatever it wrote before being killed.
+ */
+function failureStderr(error: CommandError): string {
+  if (typeof error.code === 'number') return error.stderr || error.message;
+  return [error.stderr?.trimEnd(), error.message].filter(Boolean).join('\n');
+}
+
+/**
+ * A failed gate's status: `timedout` when its timeout stopped it,
+ * `cancelled` when its abort signal did
+ */
+function failureStatus(error: CommandError) {
+  if (error.timedOut) return 'timedout';
+  return error.cancelled ? 'cancelled' : 'failed';
 }
 
 /**
- * Update gate execution with failure status
+ * Update gate execution with failure status, as given by failureStatus()
  */
 async function updateGateFailure(
+  store: RunStore,
   executionId: string,
-  error: CommandError,
-  duration: number
+  error: RetriedError,
+  duration: number,
+  artifacts?: GateArtifact[]
 ) {
-  await db
-    .update(qaGateExecutions)
-    .set({
-      status: 'failed',
-      output: error.stdout || null,
-      error: error.stderr || error.message,
-      exitCode: typeof error.code === 'number' ? error.code : 1,
-      duration,
-      completedAt: new Date(),
-    })
-    .where(eq(qaGateExecutions.id, executionId));
+  const previous = error.previousAttempts ?? [];
+  await store.updateExecution(executionId, {
+    status: failureStatus(error),
+    output: error.stdout || null,
+    error: failureStderr(error),
+    exitCode: typeof error.code === 'number' ? error.code : 1,
+    duration,
+    completedAt: new Date(),
+    ...(previous.length > 0 && { previousAttempts: previous }),
+    ...(artifacts && { artifacts }),
+  });
+}
+
+function failedAttempt(
+  attempt: number,
+  error: CommandError,
+  durationMs: number
+): GateAttempt {
+  return {
+    attempt,
+    exitCode: typeof error.code === 'number' ? error.code : 1,
+    durationMs,
+    stdout: error.stdout || null,
+    stderr: failureStderr(error),
+    ...(error.timedOut && { timedOut: true }),
+  };
+}
+
+interface RetryPlan {
+  backoff?: RetryBackoffConfig;
+  /** Runs the gate's `onFailure` fix; unset when it has none */
+  fix?: () => Promise<void>;
+}
+
+/**
+ * Run a gate's `onFailure` fix ahead of its next attempt. A failing fix is
+ * logged and ends the gate's retries.
+ */
+async function applyFix(
+  gate: QAGateConfig,
+  fix: RetryPlan['fix']
+): Promise<boolean> {
+  if (!fix) return true;
+  try {
+    await fix();
+    return true;
+  } catch (error) {
+    const { stderr, message } = error as CommandError;
+    const reason = stderr?.trim() || message;
+    console.error(
+      `onFailure command for gate "${gate.name}" failed: ${reason}`
+    );
+    return false;
+  }
+}
+
+/**
+ * Run a gate's command until it passes, its `retries` are used up or it
+ * fails in a way `retryIf` doesn't retry, running its `onFailure` fix and
+ * waiting `retryBackoff` between attempts. Every attempt captures its
+ * output afresh, so `maxOutputBytes` caps each one separately. A final
+ * failure carries the attempts before it.
+ */
+async function execWithRetries(
+  gate: QAGateConfig,
+  command: GateCommand,
+  options: ExecOptions,
+  plan: RetryPlan,
+  log: DebugLog
+): Promise<{ result: ExecResult; previous: GateAttempt[] }> {
+  const maxAttempts = (gate.retries ?? 0) + 1;
+  const previous: GateAttempt[] = [];
+
+  for (let attempt = 1; ; attempt++) {
+    const startedAt = Date.now();
+    try {
+      const result = await execGateCommand(gate, command, options);
+      return { result, previous };
+    } catch (error) {
+      const failure = error as RetriedError;
+      const retry =
+        attempt < maxAttempts &&
+        !options.signal?.aborted &&
+        isRetryable(gate, failure) &&
+        (await applyFix(gate, plan.fix));
+      if (!retry) {
+        failure.previousAttempts = previous;
+        throw failure;
+      }
+      const failed = failedAttempt(attempt, failure, Date.now() - startedAt);
+      previous.push(failed);
+      const delay = computeBackoffDelay(plan.backoff, attempt);
+      log(`attempt ${attempt} failed, retrying in ${formatElapsed(delay)}`);
+      const waitedFrom = Date.now();
+      // Cancelled while waiting: the next attempt is stopped right away
+      await sleep(delay, options.signal).catch(() => undefined);
+      failed.backoffMs = Date.now() - waitedFrom;
+    }
+  }
+}
+
+/**
+ * Echo a cached result's output as a live run would have streamed it,
+ * shaped by the gate's `outputMode` like a passing run
+ */
+function replayOutput(result: ExecResult, options: ExecOptions) {
+  const { streamLine } = options;
+  if (!streamLine) return;
+  const display = createOutputDisplay(options);
+  const { output } = display.options;
+  for (const stream of ['stdout', 'stderr'] as const) {
+    writeLines(
+      result[stream],
+      (line) => streamLine(line, stream),
+      output?.[stream] ?? ((text) => process[stream].write(text))
+    );
+  }
+  display.finish(false);
+}
+
+/**
+ * Runs a gate's `onFailure` fix as a plain command gate, in the gate's
+ * environment; undefined when the gate has no fix
+ */
+function failureFix(
+  { gate, settings, signal, files }: ExecuteGateParams,
+  root: string
+): RetryPlan['fix'] {
+  const fix = fixGate(gate);
+  if (!fix) return undefined;
+  return async () => {
+    const { command, ...resolved } = resolveGate({
+      gate: fix,
+      root,
+      settings,
+      files,
+    });
+    await execGateCommand(fix, command, {
+      ...resolved,
+      timeout: fix.timeout,
+      signal,
+      killGraceMs: settings?.shutdownGraceMs,
+    });
+  };
+}
+
+/**
+ * Run a gate's command, or replay its stored result when it sets
+ * `cacheInputs` and nothing the cache key covers has changed. Passing
+ * results of `cacheInputs` gates are stored for the next run.
+ */
+async function runOrReplay(
+  params: ExecuteGateParams,
+  root: string
+): Promise<Omit<GateSuccess, 'duration' | 'status'> & { cached: boolean }> {
+  const { gate, settings, noCache, signal, output } = params;
+  const log = debugLogger(params);
+  const { command, ...resolved } = resolveGate({
+    gate,
+    root,
+    settings,
+    files: params.files,
+  });
+  logResolvedGate(log, params, { command, ...resolved });
+  const cacheDir = path.resolve(root, params.cacheDir ?? CACHE_DIR);
+  const key = gate.cacheInputs
+    ? await computeCacheKey(gate, { command, ...resolved }, root)
+    : null;
+  const entry =
+    key && !noCache ? await readCacheEntry(cacheDir, gate.name, key) : null;
+  const outputOptions = resolveOutputOptions(gate, settings);
+  if (key) log(`cache ${entry ? 'hit' : 'miss'} for key ${key}`);
+  if (entry) {
+    replayOutput(entry, { ...resolved, ...outputOptions, output });
+    return { result: entry, cached: true, previous: [] };
+  }
+
+  // Execute command with timeout using container path
+  const { result, previous } = await execWithRetries(
+    gate,
+    command,
+    {
+      ...resolved,
+      timeout: gate.timeout,
+      signal,
+      killGraceMs: settings?.shutdownGraceMs,
+      ...outputOptions,
+      output,
+    },
+    { backoff: settings?.retryBackoff, fix: failureFix(params, root) },
+    log
+  );
+  if (key) await writeCacheEntry(cacheDir, gate.name, key, result);
+  return { result, cached: false, previous };
+}
+
+type GateOutcome = Awaited<ReturnType<typeof runOrReplay>>;
+
+interface CollectedOutcome {
+  outcome: GateOutcome | RetriedError;
+  artifacts?: GateArtifact[];
+}
+
+/**
+ * Record the files a gate declares in `artifacts`, copying them into
+ * `artifactsDir` when set. A failed copy is logged, not fatal.
+ */
+async function collectArtifacts(
+  { gate, artifactsDir }: ExecuteGateParams,
+  root: string
+): Promise<GateArtifact[] | undefined> {
+  if (!gate.artifacts) return undefined;
+  const dir = artifactsDir ? path.resolve(root, artifactsDir) : undefined;
+  const exclude = dir && path.relative(root, dir).split(path.sep).join('/');
+  const found = await findArtifacts(gate, root, exclude);
+  if (!dir) return found;
+
+  try {
+    await copyArtifacts(found, root, gateArtifactsDir(dir, gate.name));
+  } catch (error) {
+    console.error(`Failed to copy artifacts of gate "${gate.name}":`, error);
+  }
+  return found;
+}
+
+function missingArtifactsError(
+  missing: string[],
+  { result, previous }: GateOutcome
+): RetriedError {
+  const reason = `Expected artifacts not found: ${missing.join(', ')}`;
+  const error: RetriedError = new Error(reason);
+  error.stdout = result.stdout;
+  error.stderr = [result.stderr.trimEnd(), reason].filter(Boolean).join('\n');
+  error.code = result.exitCode ?? 0;
+  error.previousAttempts = previous;
+  return error;
+}
+
+/**
+ * Run the gate and collect its artifacts, whatever the outcome. With
+ * `artifactsRequired`, a passing gate fails unless every `artifacts` glob
+ * matched a file.
+ */
+async function runAndCollect(
+  params: ExecuteGateParams,
+  root: string
+): Promise<CollectedOutcome> {
+  const outcome = await runOrReplay(params, root).catch(
+    (error: RetriedError) => error
+  );
+  const artifacts = await collectArtifacts(params, root);
+  if (
+    outcome instanceof Error ||
+    !artifacts ||
+    !params.gate.artifactsRequired
+  ) {
+    return { outcome, artifacts };
+  }
+  const missing = findMissingArtifacts(params.gate, artifacts);
+  if (missing.length === 0) return { outcome, artifacts };
+  return { outcome: missingArtifactsError(missing, outcome), artifacts };
 }
 
 /**
  * Execute a single QA gate
  */
-export async function executeGate({
-  runId,
-  gate,
-  repoPath,
-}: ExecuteGateParams): Promise<GateExecutionResult> {
+export async function executeGate(
+  params: ExecuteGateParams
+): Promise<GateExecutionResult> {
+  const result = await recordGateExecution(params);
+  const { status, duration, attempt, maxAttempts } = result;
+  const attempts = `attempt ${attempt} of ${maxAttempts}`;
+  debugLogger(params)(`${status} in ${formatElapsed(duration)}, ${attempts}`);
+  return result;
+}
+
+/**
+ * Run a gate and record its execution, whatever the outcome
+ */
+async function recordGateExecution(
+  params: ExecuteGateParams
+): Promise<GateExecutionResult> {
+  const { runId, gate, repoPath, store = databaseRunStore } = params;
   const gateStartTime = Date.now();
   const execPath = getContainerPath(repoPath);
 
   // Create gate execution record
-  const execution = await createGateExecution(runId, gate);
+  const execution = await createGateExecution(store, runId, gate);
 
   if (!execution) {
-    throw new Error(`Failed to create gate execution record for gate "${gate.name}"`);
+    throw new Error(
+      `Failed to create gate execution record for gate "${gate.name}"`
+    );
   }
 
-  try {
-    // Execute command with timeout using container path
-    const { stdout, stderr } = await execAsync(gate.command, {
-      cwd: execPath,
-      timeout: gate.timeout,
-    });
-
-    const duration = Date.now() - gateStartTime;
-    await updateGateSuccess(execution.id, stdout, stderr, duration);
-
-    return {
-      id: execution.id,
-      gateName: gate.name,
-      status: 'passed',
-      duration,
-    };
-  } catch (error) {
-    const duration = Date.now() - gateStartTime;
-    const commandError = error as CommandError;
-    await updateGateFailure(execution.id, commandError, duration);
+  const maxAttempts = (gate.retries ?? 0) + 1;
+  const { outcome, artifacts } = await runAndCollect(params, execPath);
+  const duration = Date.now() - gateStartTime;
+  if (outcome instanceof Error) {
+    await updateGateFailure(store, execution.id, outcome, duration, artifacts);
 
     return {
       id: execution.id,
       gateName: gate.name,
-      status: 'failed',
+      status: failureStatus(outcome),
       duration,
+      attempt: (outcome.previousAttempts?.length ?? 0) + 1,
+      maxAttempts,
     };
   }
+
+  const { result, cached, previous } = outcome;
+  const status = cached ? 'cached' : 'passed';
+  await updateGateSuccess(store, execution.id, {
+    result,
+    duration,
+    status,
+    previous,
+    artifacts,
+  });
+
+  return {
+    id: execution.id,
+    gateName: gate.name,
+    status,
+    duration,
+    attempt: previous.length + 1,
+    maxAttempts,
+  };
+}
+
+interface SkipGateParams
+  extends Pick<ExecuteGateParams, 'runId' | 'gate' | 'store'> {
+  /** Stored as the gate's output */
+  reason?: string;
+}
+
+/**
+ * Record a gate that was not executed, e.g. because a dependency failed
+ */
+export async function skipGate({
+  runId,
+  gate,
+  reason,
+  store = databaseRunStore,
+}: SkipGateParams): Promise<GateExecutionResult> {
+  const execution = await store.insertExecution({
+    runId,
+    gateName: gate.name,
+    command: formatCommand(gate.command),
+    status: 'skipped',
+    output: reason ?? null,
+    order: gate.order || 0,
+    duration: 0,
+    completedAt: new Date(),
+  });
+
+  return {
+    id: execution?.id ?? '',
+    gateName: gate.name,
+    status: 'skipped',
+    duration: 0,
+    reason,
+  };
 }
@@ -0,0 +1,95 @@
+import type { QAGateConfig } from './config-loader';
+
+export interface GateFilter {
+  /** Run only these gates */
+  only?: string[];
+  /** Never run these gates */
+  skip?: string[];
+  /** Run only gates carrying at least one of these tags */
+  tags?: string[];
+  /** Drop gates whose dependencies were filtered out instead of failing */
+  skipDependents?: boolean;
+}
+
+function list(value: string | null): string[] {
+  if (!value) return [];
+  return value
+    .split(',')
+    .map((item) => item.trim())
+    .filter(Boolean);
+}
+
+/**
+ * Read a filter from query parameters:
+ * `?only=a,b&skip=c&tag=lint&skipDeps=true`
+ */
+export function parseGateFilter(params: URLSearchParams): GateFilter {
+  return {
+    only: list(params.get('only')),
+    skip: list(params.get('skip')),
+    tags: list(params.get('tag')),
+    skipDependents: params.get('skipDeps') === 'true',
+  };
+}
+
+// A matrix gate's instances answer to their own names and the gate's
+const names = (gate: QAGateConfig) =>
+  gate.matrixOf ? [gate.name, gate.matrixOf] : [gate.name];
+
+function matches(gate: QAGateConfig, filter: GateFilter): boolean {
+  const { only = [], skip = [], tags = [] } = filter;
+  const named = (list: string[]) => names(gate).some((n) => list.includes(n));
+  if (only.length > 0 && !named(only)) return false;
+  if (named(skip)) return false;
+  return tags.length === 0 || tags.some((tag) => gate.tags?.includes(tag));
+}
+
+/**
+ * Find a selected gate depending on an enabled gate that was filtered out
+ */
+function findBrokenDependency(
+  selected: QAGateConfig[],
+  enabled: QAGateConfig[]
+): [QAGateConfig, string] | null {
+  const names = new Set(selected.map((gate) => gate.name));
+  for (const gate of selected) {
+    const missing = gate.dependsOn?.find(
+      (dep) => !names.has(dep) && enabled.some((g) => g.name === dep)
+    );
+    if (missing) return [gate, missing];
+  }
+  return null;
+}
+
+/**
+ * Select the enabled gates a run should execute, in config order.
+ * A selected gate whose dependency was filtered out is an error, unless
+ * `skipDependents` is set, which drops it (and its own dependents) too.
+ */
+export function filterGates(
+  gates: QAGateConfig[],
+  filter: GateFilter = {}
+): QAGateConfig[] {
+  for (const name of [...(filter.only ?? []), ...(filter.skip ?? [])]) {
+    if (!gates.some((gate) => names(gate).includes(name))) {
+      throw new Error(`Unknown gate "${name}" in filter`);
+    }
+  }
+
+  const enabled = gates.filter((gate) => gate.enabled);
+  let selected = enabled.filter((gate) => matches(gate, filter));
+
+  for (;;) {
+    const broken = findBrokenDependency(selected, enabled);
+    if (!broken) return selected;
+
+    const [gate, dependency] = broken;
+    if (!filter.skipDependents) {
+      throw new Error(
+        `Gate "${gate.name}" depends on "${dependency}", which is filtered ` +
+          `out; select it too or set skipDeps to skip its dependents`
+      );
+    }
+    selected = selected.filter((g) => g !== gate);
+  }
+}
@@ -0,0 +1,224 @@
+import path from 'path';
+import type { GateSettings, QAGateConfig } from './config-loader';
+import type {
+  ExecOptions,
+  GateCommand,
+  GateStdin,
+} from './command-executor';
+import {
+  DEFAULT_MAX_OUTPUT_BYTES,
+  LOG_FORMATS,
+  gateLineFormatter,
+  type LogFormat,
+} from './output-buffer';
+import { expandFilesets, type FileLists } from './filesets';
+import { buildGateEnv, type EnvMap } from './gate-env';
+import { parseOutputMode } from './output-mode';
+import { createRedactor, type Redactor } from './redaction';
+import type { ShellName } from './shell';
+import { substituteVariables, type VariableLookup } from './substitution';
+
+export interface ResolvedGate {
+  command: GateCommand;
+  env: NodeJS.ProcessEnv;
+  /** Absolute working directory for the command */
+  cwd: string;
+  shell?: ShellName;
+  /** Piped to the command; unset when the gate sets no `stdin` */
+  stdin?: GateStdin;
+  /** Masks `redact` patterns and `secretEnv` values; unset if none */
+  redact?: Redactor;
+}
+
+interface ResolveGateParams {
+  gate: QAGateConfig;
+  /** Directory containing the config file, exposed as ${FORGE_ROOT} */
+  root: string;
+  settings?: GateSettings;
+  baseEnv?: NodeJS.ProcessEnv;
+  /** Expands `${files:PATTERN}`; placeholders stay as written without */
+  files?: FileLists;
+}
+
+function substituteEnv(
+  env: EnvMap | undefined,
+  vars: VariableLookup,
+  strict: boolean
+): EnvMap | undefined {
+  if (!env) return undefined;
+  return Object.fromEntries(
+    Object.entries(env).map(([key, value]) => [
+      key,
+      substituteVariables(value, vars, { strict }),
+    ])
+  );
+}
+
+function substituteCommand(
+  command: GateCommand,
+  vars: VariableLookup,
+  strict: boolean
+): GateCommand {
+  if (Array.isArray(command)) {
+    return command.map((arg) => substituteVariables(arg, vars, { strict }));
+  }
+  return substituteVariables(command, vars, { strict });
+}
+
+/**
+ * A gate's `stdin`: `@path` names a file relative to the config file's
+ * directory, anything else is text with `${VAR}` placeholders expanded
+ */
+function resolveStdin(
+  stdin: string | undefined,
+  root: string,
+  vars: VariableLookup,
+  strict: boolean
+): GateStdin | undefined {
+  if (stdin === undefined) return undefined;
+  if (stdin.startsWith('@')) {
+    return { file: path.resolve(root, stdin.slice(1)) };
+  }
+  return { text: substituteVariables(stdin, vars, { strict }) };
+}
+
+/**
+ * Working directory for a gate: its own `workdir`, else the config-level
+ * `workdir`, resolved against the config file's directory
+ */
+export function resolveWorkdir(
+  gate: Pick<QAGateConfig, 'workdir'>,
+  root: string,
+  settings?: Pick<GateSettings, 'workdir'>
+): string {
+  return path.resolve(root, gate.workdir ?? settings?.workdir ?? '.');
+}
+
+/**
+ * The plain command gate running a gate's `onFailure` fix. It keeps the
+ * gate's name, env, workdir, shell and timeout, but none of its output
+ * rules, allowed exit codes, stdin or matrix. null without a fix.
+ */
+export function fixGate(gate: QAGateConfig): QAGateConfig | null {
+  if (!gate.onFailure) return null;
+  const { name, env, workdir, shell, timeout } = gate;
+  return {
+    name,
+    enabled: true,
+    command: gate.onFailure,
+    failOnError: true,
+    env,
+    workdir,
+    shell,
+    timeout,
+  };
+}
+
+/**
+ * Format of streamed output lines: FORGE_LOG_FORMAT when it names one,
+ * else the config's `logFormat`, else text
+ */
+export function resolveLogFormat(
+  settings?: Pick<GateSettings, 'logFormat'>,
+  env: NodeJS.ProcessEnv = process.env
+): LogFormat {
+  const fromEnv = LOG_FORMATS.find((format) => format === env.FORGE_LOG_FORMAT);
+  return fromEnv ?? settings?.logFormat ?? 'text';
+}
+
+/**
+ * Output capture options for a gate: its own `maxOutputBytes` and
+ * `streamOutput`, else the config-level values, and its `outputMode`. The
+ * verbose and debug log levels stream output unless the gate sets
+ * `streamOutput: false`. Streamed lines carry the gate name, as a prefix
+ * or a JSON field, so parallel gates stay readable.
+ */
+export function resolveOutputOptions(
+  gate: Pick<
+    QAGateConfig,
+    'name' | 'maxOutputBytes' | 'streamOutput' | 'outputMode'
+  >,
+  settings?: Pick<
+    GateSettings,
+    'maxOutputBytes' | 'streamOutput' | 'logFormat' | 'logLevel'
+  >
+): Pick<ExecOptions, 'maxOutputBytes' | 'streamLine' | 'outputMode'> {
+  const verbose =
+    settings?.logLevel === 'verbose' || settings?.logLevel === 'debug';
+  const stream =
+    gate.streamOutput ?? (verbose || (settings?.streamOutput ?? false));
+  return {
+    maxOutputBytes:
+      gate.maxOutputBytes ??
+      settings?.maxOutputBytes ??
+      DEFAULT_MAX_OUTPUT_BYTES,
+    streamLine: stream
+      ? gateLineFormatter(gate.name, resolveLogFormat(settings))
+      : undefined,
+    ...(gate.outputMode && { outputMode: parseOutputMode(gate.outputMode) }),
+  };
+}
+
+/**
+ * Whether any of `gates` streams its output live, writing between status
+ * lines
+ */
+export function streamsOutput(
+  gates: Parameters<typeof resolveOutputOptions>[0][],
+  settings?: Parameters<typeof resolveOutputOptions>[1]
+): boolean {
+  return gates.some(
+    (gate) => resolveOutputOptions(gate, settings).streamLine !== undefined
+  );
+}
+
+/**
+ * Resolve a gate's command, environment, working directory, shell, stdin
+ * and redactor before execution.
+ * `${VAR}` placeholders in the command, env and text `stdin` expand
+ * against the process env, the config-level env and the built-ins
+ * `${FORGE_ROOT}` and `${FORGE_GATE_NAME}`. Gate env values are expanded
+ * first, so the command also sees the gate's own env. `${files:PATTERN}`
+ * then expands to the matching files in `files`.
+ */
+export function resolveGate({
+  gate,
+  root,
+  settings,
+  baseEnv = process.env,
+  files,
+}: ResolveGateParams): ResolvedGate {
+  const strict = settings?.strictEnv ?? true;
+  const builtins = { FORGE_ROOT: root, FORGE_GATE_NAME: gate.name };
+  const inherited = {
+    ...buildGateEnv(undefined, settings?.env, baseEnv),
+    ...builtins,
+  };
+
+  try {
+    const gateEnv = substituteEnv(gate.env, inherited, strict);
+    const env = {
+      ...buildGateEnv(gateEnv, settings?.env, baseEnv),
+      ...builtins,
+    };
+    const command = substituteCommand(gate.command, env, strict);
+    const cwd = resolveWorkdir(gate, root, settings);
+    const shell = gate.shell ?? settings?.shell;
+    return {
+      command: files
+        ? expandFilesets(command, { gate, files, root, cwd, shell })
+        : command,
+      env,
+      cwd,
+      shell,
+      stdin: resolveStdin(gate.stdin, root, env, strict),
+      redact: createRedactor(
+        settings?.redact,
+        settings?.secretEnv?.map((name) => env[name])
+      ),
+    };
+  } catch (error) {
+    const message = error instanceof Error ? error.message : String(error);
+    throw new Error(`${message} in gate "${gate.name}"`);
+  }
+}
@@ -0,0 +1,57 @@
+import { globToRegExp } from './glob';
+
+interface IgnoreRule {
+  pattern: RegExp;
+  negated: boolean;
+  dirOnly: boolean;
+}
+
+function parseRule(line: string): IgnoreRule | null {
+  let text = line.trim();
+  if (!text || text.startsWith('#')) return null;
+
+  const negated = text.startsWith('!');
+  if (negated) text = text.slice(1);
+  const dirOnly = text.endsWith('/');
+  if (dirOnly) text = text.slice(0, -1);
+
+  // Patterns containing a slash are anchored to the root; others match at
+  // any depth
+  const anchored = text.includes('/');
+  text = text.replace(/^\//, '');
+  const prefix = anchored ? '^' : '^(?:.*/)?';
+  return {
+    pattern: new RegExp(`${prefix}${globToRegExp(text)}$`),
+    negated,
+    dirOnly,
+  };
+}
+
+/**
+ * Build a matcher from `.gitignore` contents. Paths are relative to the
+ * directory holding the file, with `/` separators. A path is ignored when
+ * it or any parent directory matches; later `!` rules re-include.
+ * `.git/` is always ignored.
+ */
+export function createIgnoreMatcher(
+  text: string
+): (relativePath: string) => boolean {
+  const rules = text.split(/\r?\n/).flatMap((line) => parseRule(line) ?? []);
+
+  const matches = (target: string, isDir: boolean) => {
+    let ignored = false;
+    for (const rule of rules) {
+      if (rule.dirOnly && !isDir) continue;
+      if (rule.pattern.test(target)) ignored = !rule.negated;
+    }
+    return ignored;
+  };
+
+  return (relativePath) => {
+    const parts = relativePath.split('/').filter(Boolean);
+    if (parts[0] === '.git') return true;
+    return parts.some((_, index) =>
+      matches(parts.slice(0, index + 1).join('/'), index < parts.length - 1)
+    );
+  };
+}
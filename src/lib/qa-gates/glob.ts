@@ -0,0 +1,34 @@
+/**
+ * Translate a glob into a regular expression source. `*` and `?` stay
+ * within one path segment; `**` crosses directories.
+ */
+export function globToRegExp(glob: string): string {
+  let source = '';
+  for (let i = 0; i < glob.length; i++) {
+    const char = glob[i]!;
+    if (char === '*' && glob[i + 1] === '*') {
+      // "**/" matches zero or more directories, a trailing "**" everything
+      source += glob[i + 2] === '/' ? '(?:.*/)?' : '.*';
+      i += glob[i + 2] === '/' ? 2 : 1;
+    } else if (char === '*') {
+      source += '[^/]*';
+    } else if (char === '?') {
+      source += '[^/]';
+    } else {
+      source += char.replace(/[.+^${}()|[\]\\]/g, '\\$&');
+    }
+  }
+  return source;
+}
+
+/**
+ * Match a `/`-separated relative path against a glob. Globs containing a
+ * slash match the whole path; others match the file name at any depth,
+ * as in `.gitignore`.
+ */
+export function matchesGlob(filePath: string, glob: string): boolean {
+  const anchored = glob.includes('/');
+  const prefix = anchored ? '^' : '^(?:.*/)?';
+  const source = globToRegExp(glob.replace(/^\//, ''));
+  return new RegExp(`${prefix}${source}$`).test(filePath);
+}
@@ -0,0 +1,196 @@
+import fs from 'fs/promises';
+import path from 'path';
+import { sleep } from './retry-backoff';
+import type { RunResult } from './run-report';
+import { formatColumns, formatElapsed } from './status-board';
+
+/** The file under `historyDir` runs are appended to, one RunResult a line */
+export const HISTORY_FILE = 'history.jsonl';
+
+/** Runs a summary covers unless told otherwise */
+export const DEFAULT_HISTORY_RUNS = 20;
+
+const LOCK_RETRY_MS = 25;
+const LOCK_TIMEOUT_MS = 10000;
+// A lock older than this was left behind by a process that died holding it
+const STALE_LOCK_MS = 30000;
+
+async function removeStaleLock(lockPath: string) {
+  try {
+    const { mtimeMs } = await fs.stat(lockPath);
+    if (Date.now() - mtimeMs > STALE_LOCK_MS) {
+      await fs.rm(lockPath, { force: true });
+    }
+  } catch {
+    // Released meanwhile
+  }
+}
+
+/**
+ * Run `fn` holding `lockPath`, a file only one process can create at a
+ * time. Waits for another holder, up to LOCK_TIMEOUT_MS.
+ */
+async function withFileLock<T>(
+  lockPath: string,
+  fn: () => Promise<T>
+): Promise<T> {
+  const deadline = Date.now() + LOCK_TIMEOUT_MS;
+  for (;;) {
+    try {
+      await (await fs.open(lockPath, 'wx')).close();
+      break;
+    } catch (error) {
+      if ((error as NodeJS.ErrnoException).code !== 'EEXIST') throw error;
+      if (Date.now() > deadline) {
+        throw new Error(`Timed out waiting for the lock ${lockPath}`);
+      }
+      await removeStaleLock(lockPath);
+      await sleep(LOCK_RETRY_MS);
+    }
+  }
+
+  try {
+    return await fn();
+  } finally {
+    await fs.rm(lockPath, { force: true });
+  }
+}
+
+/**
+ * Append a finished run to `historyDir`'s HISTORY_FILE as one JSON line.
+ * Relative paths resolve against `root`. The file is only ever appended
+ * to, under a lock file, so concurrent runs never interleave their lines.
+ */
+export async function appendRunHistory(
+  historyDir: string,
+  root: string,
+  result: RunResult
+): Promise<void> {
+  const dir = path.resolve(root, historyDir);
+  const file = path.join(dir, HISTORY_FILE);
+  await fs.mkdir(dir, { recursive: true });
+  await withFileLock(`${file}.lock`, () =>
+    fs.appendFile(file, `${JSON.stringify(result)}\n`, 'utf-8')
+  );
+}
+
+/**
+ * The last `last` runs in `historyDir`, oldest first; none when there is
+ * no history yet. Lines that don't parse, such as one cut short by a
+ * crash, are left out.
+ */
+export async function readRunHistory(
+  historyDir: string,
+  root: string,
+  last = DEFAULT_HISTORY_RUNS
+): Promise<RunResult[]> {
+  let text: string;
+  try {
+    text = await fs.readFile(
+      path.join(path.resolve(root, historyDir), HISTORY_FILE),
+      'utf-8'
+    );
+  } catch (error) {
+    if ((error as NodeJS.ErrnoException).code === 'ENOENT') return [];
+    throw error;
+  }
+
+  const runs = text.split('\n').flatMap((line): RunResult[] => {
+    try {
+      const run = JSON.parse(line) as RunResult;
+      return Array.isArray(run?.gates) ? [run] : [];
+    } catch {
+      return [];
+    }
+  });
+  return runs.slice(-last);
+}
+
+export interface GateHistory {
+  name: string;
+  /** Runs reporting the gate */
+  runs: number;
+  /** Passed, cached passes included */
+  passed: number;
+  /** Failed or timed out */
+  failed: number;
+  skipped: number;
+  /** passed / (passed + failed); null when the gate never ran */
+  passRate: number | null;
+  /** Over the runs that executed the gate; null when none did */
+  averageDurationMs: number | null;
+  /** Both passed and failed within the runs summarized */
+  flaky: boolean;
+}
+
+export interface HistorySummary {
+  /** Runs summarized */
+  runs: number;
+  /** Flaky gates first, then by name */
+  gates: GateHistory[];
+}
+
+function gateHistory(name: string, results: RunResult['gates']): GateHistory {
+  const count = (...statuses: string[]) =>
+    results.filter((gate) => statuses.includes(gate.status)).length;
+  const passed = count('passed', 'cached');
+  const failed = count('failed', 'timedout');
+  const executed = results.filter((gate) =>
+    ['passed', 'failed', 'timedout'].includes(gate.status)
+  );
+  const total = executed.reduce((sum, gate) => sum + gate.durationMs, 0);
+  return {
+    name,
+    runs: results.length,
+    passed,
+    failed,
+    skipped: count('skipped'),
+    passRate: passed + failed > 0 ? passed / (passed + failed) : null,
+    averageDurationMs: executed.length > 0 ? total / executed.length : null,
+    flaky: passed > 0 && failed > 0,
+  };
+}
+
+/**
+ * Per-gate pass rates and average durations over `runs`, flagging gates
+ * that both passed and failed as flaky
+ */
+export function summarizeHistory(runs: RunResult[]): HistorySummary {
+  const byGate = new Map<string, RunResult['gates']>();
+  for (const gate of runs.flatMap((run) => run.gates)) {
+    byGate.set(gate.name, [...(byGate.get(gate.name) ?? []), gate]);
+  }
+  const gates = [...byGate].map(([name, results]) =>
+    gateHistory(name, results)
+  );
+  gates.sort(
+    (a, b) => Number(b.flaky) - Number(a.flaky) || a.name.localeCompare(b.name)
+  );
+  return { runs: runs.length, gates };
+}
+
+/**
+ * The history summary as a table, then the flaky gates to look at
+ */
+export function formatHistory(summary: HistorySummary): string {
+  if (summary.runs === 0) return 'No runs recorded yet';
+  const rows = summary.gates.map((gate) => [
+    gate.name,
+    String(gate.runs),
+    gate.passRate === null ? '-' : `${Math.round(gate.passRate * 100)}%`,
+    gate.averageDurationMs === null
+      ? '-'
+      : formatElapsed(gate.averageDurationMs),
+    gate.flaky ? 'flaky' : '',
+  ]);
+  const flaky = summary.gates.filter((gate) => gate.flaky);
+  const plural = summary.runs === 1 ? '' : 's';
+  return [
+    `History of the last ${summary.runs} run${plural}`,
+    formatColumns([['Gate', 'Runs', 'Pass rate', 'Avg duration', ''], ...rows]),
+    '',
+    flaky.length > 0
+      ? `Flaky: ${flaky.map((gate) => gate.name).join(', ')}`
+      : 'No flaky gates',
+  ].join('\n');
+}
@@ -0,0 +1,39 @@
+/**
+ * Entry point for embedding Forge's QA gates in other tooling: load a
+ * config, build a Runner and react to each gate as it finishes.
+ */
+export { Runner, type RunnerOptions } from './repository-runner';
+export type { GateHooks } from './run-orchestrator';
+export {
+  loadRunConfig,
+  readRepositoryConfig,
+  validateConfig,
+  validateRunConfig,
+  type ForgeConfig,
+  type QAGateConfig,
+  type RunConfigSource,
+} from './config-loader';
+export {
+  formatConfigText,
+  formatRepositoryConfig,
+  type FormatResult,
+} from './config-format';
+export type { GateFilter } from './gate-filter';
+export type { OutputWriters } from './command-executor';
+export {
+  createMemoryRunStore,
+  databaseRunStore,
+  type GateExecution,
+  type RunStore,
+} from './run-store';
+export type { GateRunResult, RunResult } from './run-report';
+export type { GateTiming, RunTiming } from './timing';
+export { EXIT_CODES, type ExitCode } from './exit-codes';
+export {
+  registerGate,
+  unregisterGate,
+  type BuiltinGate,
+  type BuiltinGateContext,
+  type BuiltinGateFactory,
+  type BuiltinGateResult,
+} from './builtin-gates';
@@ -0,0 +1,186 @@
+import fs from 'fs/promises';
+import path from 'path';
+import type { RunResult } from './run-report';
+import { isFailure } from './severity';
+import { formatElapsed } from './status-board';
+
+export interface JUnitTestCase {
+  name: string;
+  status: string;
+  /** Milliseconds */
+  duration?: number | null;
+  /** The gate's timeout in milliseconds, named in a `timedout` failure */
+  timeout?: number | null;
+  command?: string;
+  exitCode?: number | null;
+  stdout?: string | null;
+  stderr?: string | null;
+  /** Number of attempts when the gate was retried; only the last counts */
+  attempts?: number;
+}
+
+export interface JUnitSuite {
+  name: string;
+  timestamp?: Date | null;
+  cases: JUnitTestCase[];
+}
+
+const FAILURE_TAIL_LINES = 20;
+
+// Characters XML 1.0 cannot carry: C0 controls other than tab/newline/CR,
+// the noncharacters U+FFFE/U+FFFF, and unpaired surrogates
+const INVALID_XML_CHARS =
+  // eslint-disable-next-line no-control-regex
+  /[\u0000-\u0008\u000B\u000C\u000E-\u001F\uFFFE\uFFFF]|[\uD800-\uDBFF](?![\uDC00-\uDFFF])|(?<![\uD800-\uDBFF])[\uDC00-\uDFFF]/g;
+
+/**
+ * Make text safe for XML: characters XML 1.0 cannot carry become U+FFFD,
+ * then markup characters are escaped. Invalid UTF-8 from a command has
+ * already been decoded to U+FFFD by the time it reaches us.
+ */
+export function escapeXml(text: string): string {
+  return text
+    .replace(INVALID_XML_CHARS, '\uFFFD')
+    .replace(/&/g, '&amp;')
+    .replace(/</g, '&lt;')
+    .replace(/>/g, '&gt;')
+    .replace(/"/g, '&quot;')
+    .replace(/'/g, '&apos;');
+}
+
+function seconds(ms: number | null | undefined): string {
+  return ((ms ?? 0) / 1000).toFixed(3);
+}
+
+function lastLines(text: string, count: number): string {
+  return text.trimEnd().split('\n').slice(-count).join('\n');
+}
+
+function failureMessage(testCase: JUnitTestCase): [string, string] {
+  if (testCase.status !== 'timedout') {
+    return [`Exit code ${testCase.exitCode ?? 1}`, 'exit-code'];
+  }
+  const { timeout } = testCase;
+  const after = timeout ? ` after ${formatElapsed(timeout)}` : '';
+  return [`Timed out${after}`, 'timeout'];
+}
+
+function renderOutcome(testCase: JUnitTestCase): string[] {
+  if (testCase.status === 'passed' || testCase.status === 'cached') return [];
+  if (isFailure(testCase.status)) {
+    const [message, type] = failureMessage(testCase);
+    const output = testCase.stderr || testCase.stdout || '';
+    return [
+      `      <failure message="${message}" type="${type}">` +
+        `${escapeXml(lastLines(output, FAILURE_TAIL_LINES))}</failure>`,
+    ];
+  }
+  const messages: Record<string, string> = {
+    skipped: 'Skipped',
+    cancelled: 'Cancelled',
+  };
+  const message = messages[testCase.status] ?? 'Gate did not finish';
+  return [`      <skipped message="${message}"/>`];
+}
+
+function renderProperties(testCase: JUnitTestCase): string[] {
+  const properties: [string, string][] = [];
+  if (testCase.command) properties.push(['command', testCase.command]);
+  if (testCase.attempts) {
+    properties.push(['attempts', String(testCase.attempts)]);
+  }
+  if (properties.length === 0) return [];
+
+  return [
+    '      <properties>',
+    ...properties.map(
+      ([name, value]) =>
+        `        <property name="${name}" value="${escapeXml(value)}"/>`
+    ),
+    '      </properties>',
+  ];
+}
+
+function renderTestCase(suite: string, testCase: JUnitTestCase): string {
+  const lines = [
+    `    <testcase name="${escapeXml(testCase.name)}" ` +
+      `classname="${escapeXml(suite)}" time="${seconds(testCase.duration)}">`,
+    ...renderProperties(testCase),
+    ...renderOutcome(testCase),
+  ];
+  if (testCase.stdout) {
+    lines.push(`      <system-out>${escapeXml(testCase.stdout)}</system-out>`);
+  }
+  if (testCase.stderr) {
+    lines.push(`      <system-err>${escapeXml(testCase.stderr)}</system-err>`);
+  }
+  lines.push('    </testcase>');
+  return lines.join('\n');
+}
+
+/**
+ * Serialize gate results as a JUnit `testsuites` document with one
+ * `testcase` per gate, for CI systems that render test reports
+ */
+export function formatJUnitReport(suite: JUnitSuite): string {
+  const { cases } = suite;
+  const failures = cases.filter((c) => isFailure(c.status)).length;
+  const skipped = cases.filter(
+    (c) =>
+      c.status !== 'passed' && c.status !== 'cached' && !isFailure(c.status)
+  ).length;
+  const time = seconds(cases.reduce((sum, c) => sum + (c.duration ?? 0), 0));
+  const counts =
+    `tests="${cases.length}" failures="${failures}" errors="0" ` +
+    `skipped="${skipped}" time="${time}"`;
+  const name = escapeXml(suite.name);
+  const timestamp = suite.timestamp
+    ? ` timestamp="${suite.timestamp.toISOString()}"`
+    : '';
+
+  return [
+    '<?xml version="1.0" encoding="UTF-8"?>',
+    `<testsuites name="${name}" ${counts}>`,
+    `  <testsuite name="${name}" ${counts}${timestamp}>`,
+    ...cases.map((testCase) => renderTestCase(suite.name, testCase)),
+    '  </testsuite>',
+    '</testsuites>',
+    '',
+  ].join('\n');
+}
+
+/**
+ * A RunResult as a JUnit suite, its gates in the RunResult's config order
+ */
+export function junitSuiteOf(name: string, result: RunResult): JUnitSuite {
+  return {
+    name,
+    timestamp: new Date(result.startedAt),
+    cases: result.gates.map((gate) => ({
+      name: gate.name,
+      status: gate.status,
+      duration: gate.durationMs,
+      timeout: gate.timeoutMs,
+      command: gate.command,
+      exitCode: gate.exitCode,
+      stdout: gate.stdout,
+      stderr: gate.stderr,
+      ...(gate.attempts > 1 && { attempts: gate.attempts }),
+    })),
+  };
+}
+
+/**
+ * Write a run as a JUnit XML report named after the directory `root`.
+ * Relative paths resolve against `root`.
+ */
+export async function writeJUnitReport(
+  reportPath: string,
+  root: string,
+  result: RunResult
+): Promise<void> {
+  const target = path.resolve(root, reportPath);
+  const xml = formatJUnitReport(junitSuiteOf(path.basename(root), result));
+  await fs.mkdir(path.dirname(target), { recursive: true });
+  await fs.writeFile(target, xml + '\n', 'utf-8');
+}
@@ -0,0 +1,126 @@
+/**
+ * A gate's `matrix`: variable names mapped to the values the gate runs
+ * with, one instance per combination
+ */
+export type GateMatrix = Record<string, (string | number | boolean)[]>;
+
+interface MatrixGate {
+  name: string;
+  command: string | string[];
+  env?: Record<string, string>;
+  dependsOn?: string[];
+  matrix?: GateMatrix;
+  /** Set on instances: the name of the gate they were expanded from */
+  matrixOf?: string;
+}
+
+const MATRIX_PLACEHOLDER = /\$\{matrix\.([A-Za-z_][A-Za-z0-9_]*)\}/g;
+
+/**
+ * Every combination of the matrix's values, the first variable varying
+ * slowest
+ */
+export function matrixCombinations(
+  matrix: GateMatrix
+): Record<string, string>[] {
+  return Object.entries(matrix).reduce<Record<string, string>[]>(
+    (combinations, [name, values]) =>
+      combinations.flatMap((combination) =>
+        values.map((value) => ({ ...combination, [name]: String(value) }))
+      ),
+    [{}]
+  );
+}
+
+/**
+ * An instance's name: the gate's, followed by its values, as in
+ * `Go test [os=linux,tags=integration]`
+ */
+export function matrixInstanceName(
+  name: string,
+  values: Record<string, string>
+): string {
+  const pairs = Object.entries(values).map(([key, value]) => `${key}=${value}`);
+  return `${name} [${pairs.join(',')}]`;
+}
+
+/**
+ * Names of a gate's matrix instances; none without a `matrix`
+ */
+export function matrixInstanceNames(
+  gate: Pick<MatrixGate, 'name' | 'matrix'>
+): string[] {
+  if (!gate.matrix) return [];
+  return matrixCombinations(gate.matrix).map((values) =>
+    matrixInstanceName(gate.name, values)
+  );
+}
+
+/**
+ * The `${matrix.NAME}` variables a gate's command and env refer to
+ */
+export function matrixReferences(
+  gate: Pick<MatrixGate, 'command' | 'env'>
+): string[] {
+  const texts = [gate.command, Object.values(gate.env ?? {})].flat();
+  const names = texts.flatMap((text) =>
+    [...text.matchAll(MATRIX_PLACEHOLDER)].map((match) => match[1]!)
+  );
+  return [...new Set(names)];
+}
+
+function substituteMatrix(text: string, values: Record<string, string>) {
+  return text.replace(
+    MATRIX_PLACEHOLDER,
+    (match, name: string) => values[name] ?? match
+  );
+}
+
+function expandGate<T extends MatrixGate>(gate: T): T[] {
+  if (!gate.matrix) return [gate];
+  const { matrix: _matrix, ...rest } = gate;
+  return matrixCombinations(gate.matrix).map((values) => {
+    return {
+      ...rest,
+      name: matrixInstanceName(gate.name, values),
+      command: Array.isArray(gate.command)
+        ? gate.command.map((arg) => substituteMatrix(arg, values))
+        : substituteMatrix(gate.command, values),
+      ...(gate.env && {
+        env: Object.fromEntries(
+          Object.entries(gate.env).map(([key, value]) => [
+            key,
+            substituteMatrix(value, values),
+          ])
+        ),
+      }),
+      matrixOf: gate.name,
+    } as T;
+  });
+}
+
+/**
+ * Replace every gate with a `matrix` by one instance per combination of
+ * its values, in place, with `${matrix.NAME}` substituted in the command
+ * and env. Instances keep the rest of the gate, `order` included, and a
+ * dependency on the gate becomes one on each of its instances.
+ */
+export function expandMatrixGates<T extends MatrixGate>(gates: T[]): T[] {
+  const expanded = gates.map(expandGate);
+  const instances = new Map(
+    gates.map((gate, index) => [
+      gate.name,
+      expanded[index]!.map((instance) => instance.name),
+    ])
+  );
+  return expanded.flat().map((gate) =>
+    gate.dependsOn
+      ? {
+          ...gate,
+          dependsOn: gate.dependsOn.flatMap(
+            (name) => instances.get(name) ?? [name]
+          ),
+        }
+      : gate
+  );
+}
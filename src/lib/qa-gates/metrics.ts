@@ -0,0 +1,111 @@
+import fs from 'fs/promises';
+import path from 'path';
+import type { GateOutcome, RunResult } from './run-report';
+
+/** Content type of the Prometheus text exposition format */
+export const METRICS_CONTENT_TYPE = 'text/plain; version=0.0.4; charset=utf-8';
+
+// Every outcome a finished gate can have, one forge_gate_status series each
+const STATUSES: GateOutcome[] = [
+  'passed',
+  'failed',
+  'skipped',
+  'timedout',
+  'cancelled',
+  'cached',
+];
+
+/**
+ * Escape a label value: backslash, double quote and newline are the only
+ * characters the text format requires escaping
+ */
+export function escapeLabelValue(value: string): string {
+  return value
+    .replace(/\\/g, '\\\\')
+    .replace(/"/g, '\\"')
+    .replace(/\n/g, '\\n');
+}
+
+function labels(values: Record<string, string>): string {
+  const pairs = Object.entries(values).map(
+    ([name, value]) => `${name}="${escapeLabelValue(value)}"`
+  );
+  return `{${pairs.join(',')}}`;
+}
+
+function metric(
+  name: string,
+  type: 'gauge' | 'counter',
+  help: string,
+  samples: [Record<string, string>, number][]
+): string[] {
+  return [
+    `# HELP ${name} ${help}`,
+    `# TYPE ${name} ${type}`,
+    ...samples.map(([values, value]) => {
+      const suffix = Object.keys(values).length > 0 ? labels(values) : '';
+      return `${name}${suffix} ${value}`;
+    }),
+  ];
+}
+
+/**
+ * Render a run as Prometheus text-format metrics: per-gate duration,
+ * attempts and status (1 for the gate's outcome, 0 for the others), plus
+ * the run's duration
+ */
+export function formatMetrics(result: RunResult): string {
+  const seconds = (ms: number) => ms / 1000;
+  const lines = [
+    ...metric(
+      'forge_gate_duration_seconds',
+      'gauge',
+      'Duration of the gate in the last run.',
+      result.gates.map((gate) => [
+        { gate: gate.name },
+        seconds(gate.durationMs),
+      ])
+    ),
+    ...metric(
+      'forge_gate_attempts',
+      'gauge',
+      'Attempts the gate took in the last run.',
+      result.gates.map((gate) => [{ gate: gate.name }, gate.attempts])
+    ),
+    ...metric(
+      'forge_gate_status',
+      'gauge',
+      'Outcome of the gate in the last run; 1 for the current status.',
+      result.gates.flatMap((gate) =>
+        STATUSES.map<[Record<string, string>, number]>((status) => [
+          { gate: gate.name, status },
+          gate.status === status ? 1 : 0,
+        ])
+      )
+    ),
+    ...metric(
+      'forge_run_duration_seconds',
+      'gauge',
+      'Duration of the last run.',
+      [[{}, seconds(result.durationMs)]]
+    ),
+  ];
+  return lines.join('\n') + '\n';
+}
+
+/**
+ * Write a run's metrics, e.g. for node_exporter's textfile collector.
+ * Relative paths resolve against `root`. The file is replaced atomically
+ * so a scrape never reads it half-written.
+ */
+export async function writeMetrics(
+  metricsPath: string,
+  root: string,
+  result: RunResult
+): Promise<void> {
+  const target = path.resolve(root, metricsPath);
+  const temporary = `${target}.${process.pid}.tmp`;
+  await fs.mkdir(path.dirname(target), { recursive: true });
+  await fs.writeFile(temporary, formatMetrics(result), 'utf-8');
+  await fs.rename(temporary, target);
+}
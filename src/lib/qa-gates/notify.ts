@@ -0,0 +1,141 @@
+import type { GateSettings } from './config-loader';
+import { buildGateEnv } from './gate-env';
+import { sleep } from './retry-backoff';
+import type { RunResult } from './run-report';
+import { isFailure } from './severity';
+import { formatElapsed } from './status-board';
+import { substituteVariables } from './substitution';
+
+export const NOTIFY_WHEN = ['always', 'failure', 'success'] as const;
+export type NotifyWhen = (typeof NOTIFY_WHEN)[number];
+
+export type NotifyConfig = NonNullable<GateSettings['notify']>;
+
+/** How long each webhook request may take */
+export const NOTIFY_TIMEOUT_MS = 5000;
+const RETRY_DELAY_MS = 1000;
+
+// {{name}} placeholders in payload strings
+const FIELD_PATTERN = /\{\{\s*(\w+)\s*\}\}/g;
+
+/**
+ * Whether a finished run's status calls for a notification
+ */
+export function shouldNotify(
+  when: NotifyWhen,
+  status: RunResult['status']
+): boolean {
+  if (when === 'always') return true;
+  return when === 'failure' ? status === 'failed' : status === 'passed';
+}
+
+/**
+ * Values payload templates can use as `{{name}}`
+ */
+export function payloadFields(result: RunResult): Record<string, string> {
+  const failed = result.gates.filter((gate) => isFailure(gate.status));
+  return {
+    runId: result.runId,
+    status: result.status,
+    failedGates: failed.map((gate) => gate.name).join(', ') || 'none',
+    failedCount: String(failed.length),
+    gateCount: String(result.totals.gates),
+    duration: formatElapsed(result.durationMs),
+    durationMs: String(result.durationMs),
+  };
+}
+
+/**
+ * The payload sent when `notify.payload` is unset; `text` suits Slack
+ * incoming webhooks
+ */
+export function defaultPayload(result: RunResult): Record<string, unknown> {
+  const fields = payloadFields(result);
+  const failures =
+    result.status === 'failed' ? `; failed: ${fields.failedGates}` : '';
+  return {
+    text: `Forge QA run ${result.status} in ${fields.duration}${failures}`,
+    runId: result.runId,
+    status: result.status,
+    failedGates: result.gates
+      .filter((gate) => isFailure(gate.status))
+      .map((gate) => gate.name),
+    durationMs: result.durationMs,
+  };
+}
+
+/**
+ * Fill the `{{name}}` placeholders of every string in a payload template,
+ * at any depth. Unknown names are left as they are.
+ */
+export function renderPayload(template: unknown, result: RunResult): unknown {
+  const fields = payloadFields(result);
+  const render = (value: unknown): unknown => {
+    if (typeof value === 'string') {
+      return value.replace(FIELD_PATTERN, (match, name: string) =>
+        name in fields ? fields[name]! : match
+      );
+    }
+    if (Array.isArray(value)) return value.map(render);
+    if (value && typeof value === 'object') {
+      return Object.fromEntries(
+        Object.entries(value).map(([key, item]) => [key, render(item)])
+      );
+    }
+    return value;
+  };
+  return render(template);
+}
+
+async function post(url: string, body: string): Promise<void> {
+  const response = await fetch(url, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body,
+    signal: AbortSignal.timeout(NOTIFY_TIMEOUT_MS),
+  });
+  if (!response.ok) {
+    throw new Error(`Webhook responded ${response.status}`);
+  }
+}
+
+/**
+ * POST a run's notification when `notify.when` matches its status,
+ * retrying once. `${VAR}` placeholders in the URL expand against the
+ * config-level env and the process env. Never throws: a failed
+ * notification is logged and the run's outcome stands.
+ */
+export async function sendNotification(
+  notify: NotifyConfig,
+  result: RunResult,
+  settings?: Pick<GateSettings, 'env'>,
+  baseEnv: NodeJS.ProcessEnv = process.env
+): Promise<void> {
+  if (!shouldNotify(notify.when, result.status)) return;
+
+  let url: string;
+  try {
+    const env = buildGateEnv(undefined, settings?.env, baseEnv);
+    url = substituteVariables(notify.webhookURL, env, { strict: true });
+  } catch (error) {
+    console.error('Not sending QA run notification:', error);
+    return;
+  }
+
+  const payload = notify.payload
+    ? renderPayload(notify.payload, result)
+    : defaultPayload(result);
+  const body = JSON.stringify(payload);
+  for (let attempt = 1; attempt <= 2; attempt++) {
+    try {
+      await post(url, body);
+      return;
+    } catch (error) {
+      if (attempt === 2) {
+        console.error('Error sending QA run notification:', error);
+      } else {
+        await sleep(RETRY_DELAY_MS);
+      }
+    }
+  }
+}
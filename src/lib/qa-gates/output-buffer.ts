@@ -0,0 +1,125 @@
+/**
+ * Default cap on captured gate output per stream: 1 MiB
+ */
+export const DEFAULT_MAX_OUTPUT_BYTES = 1024 * 1024;
+
+/**
+ * Collects a stream's output, keeping only the last `limit` bytes so a
+ * chatty command cannot exhaust memory. The tail is kept because that is
+ * where failures are usually reported.
+ */
+export class TailBuffer {
+  private chunks: Buffer[] = [];
+  private size = 0;
+  private readonly limit: number;
+  /** Bytes dropped from the head */
+  dropped = 0;
+
+  constructor(limit = Infinity) {
+    this.limit = limit;
+  }
+
+  append(chunk: Buffer | string): void {
+    const data = typeof chunk === 'string' ? Buffer.from(chunk) : chunk;
+    this.chunks.push(data);
+    this.size += data.length;
+
+    while (this.size > this.limit && this.chunks.length > 0) {
+      const excess = this.size - this.limit;
+      const head = this.chunks[0]!;
+      if (head.length <= excess) {
+        this.chunks.shift();
+        this.size -= head.length;
+        this.dropped += head.length;
+      } else {
+        this.chunks[0] = head.subarray(excess);
+        this.size -= excess;
+        this.dropped += excess;
+      }
+    }
+  }
+
+  get truncated(): boolean {
+    return this.dropped > 0;
+  }
+
+  /**
+   * The captured text, prefixed with a marker line when the head was
+   * dropped. A multi-byte character cut at the boundary decodes as U+FFFD.
+   */
+  toString(): string {
+    const text = Buffer.concat(this.chunks).toString('utf-8');
+    if (!this.truncated) return text;
+    return `[... ${this.dropped} bytes truncated ...]\n${text}`;
+  }
+}
+
+/**
+ * One of a command's output streams
+ */
+export type OutputStream = 'stdout' | 'stderr';
+
+/**
+ * How streamed gate output is written: `[gate] line`, or one JSON object
+ * per line
+ */
+export const LOG_FORMATS = ['text', 'json'] as const;
+export type LogFormat = (typeof LOG_FORMATS)[number];
+
+/**
+ * Renders one complete line of output, without its newline
+ */
+export type LineFormatter = (line: string, stream: OutputStream) => string;
+
+/**
+ * Formatter for a gate's streamed lines: `[name] line` as text, or
+ * `{"gate","stream","line","ts"}` as JSON, stamped when the line completes
+ */
+export function gateLineFormatter(
+  gate: string,
+  format: LogFormat = 'text'
+): LineFormatter {
+  if (format === 'json') {
+    return (line, stream) =>
+      JSON.stringify({ gate, stream, line, ts: new Date().toISOString() });
+  }
+  return (line) => `[${gate}] ${line}`;
+}
+
+/**
+ * Wrap a writer so that every line it receives is formatted, keeping
+ * partial lines until they are completed. Used to tell apart the live
+ * output of gates running in parallel.
+ */
+export function createLineWriter(
+  format: (line: string) => string,
+  write: (text: string) => void
+): { write: (chunk: Buffer | string) => void; flush: () => void } {
+  let pending = '';
+
+  return {
+    write(chunk) {
+      const lines = (pending + chunk.toString()).split('\n');
+      pending = lines.pop() ?? '';
+      for (const line of lines) write(`${format(line)}\n`);
+    },
+    flush() {
+      if (pending) write(`${format(pending)}\n`);
+      pending = '';
+    },
+  };
+}
+
+/**
+ * Write output that is already complete, such as a builtin gate's result
+ * or one replayed from the cache, a formatted line at a time
+ */
+export function writeLines(
+  text: string,
+  format: (line: string) => string,
+  write: (text: string) => void
+): void {
+  const writer = createLineWriter(format, write);
+  writer.write(text);
+  writer.flush();
+}
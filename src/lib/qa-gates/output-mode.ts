@@ -0,0 +1,130 @@
+import type { ExecOptions, OutputWriters } from './command-executor';
+import type { OutputStream } from './output-buffer';
+
+/**
+ * How much of a gate's streamed output is shown: all of it, its first or
+ * last N lines, or nothing unless the gate fails. Captured output, and so
+ * reports, keep everything up to `maxOutputBytes` either way.
+ */
+export type OutputMode =
+  | { kind: 'full' }
+  | { kind: 'onFailureOnly' }
+  | { kind: 'head' | 'tail'; lines: number };
+
+const MODE = /^(?:(full|onFailureOnly)|(head|tail):([1-9]\d*))$/;
+
+/**
+ * Parse a gate's `outputMode`: `full`, `tail:N`, `head:N` or
+ * `onFailureOnly`. Throws on anything else.
+ */
+export function parseOutputMode(value: string): OutputMode {
+  const match = MODE.exec(value);
+  if (!match) {
+    throw new Error(
+      `Invalid outputMode "${value}": use full, tail:N, head:N or ` +
+        'onFailureOnly'
+    );
+  }
+  const [, whole, kind, lines] = match;
+  if (whole === 'full' || whole === 'onFailureOnly') return { kind: whole };
+  return { kind: kind as 'head' | 'tail', lines: Number(lines) };
+}
+
+/**
+ * The line standing in for output a mode left out
+ */
+export function omittedMarker(count: number): string {
+  return `… (${count} line${count === 1 ? '' : 's'} omitted) …`;
+}
+
+interface HeldLine {
+  stream: OutputStream;
+  text: string;
+}
+
+/**
+ * Lines held back until the gate finishes, the oldest dropped past `limit`
+ * lines or `maxBytes` bytes
+ */
+class HeldLines {
+  readonly lines: HeldLine[] = [];
+  omitted = 0;
+  private bytes = 0;
+  private readonly limit: number;
+  private readonly maxBytes: number;
+
+  constructor(limit: number, maxBytes: number) {
+    this.limit = limit;
+    this.maxBytes = maxBytes;
+  }
+
+  push(line: HeldLine) {
+    this.lines.push(line);
+    this.bytes += line.text.length;
+    while (this.lines.length > this.limit || this.bytes > this.maxBytes) {
+      this.bytes -= this.lines.shift()!.text.length;
+      this.omitted++;
+    }
+  }
+}
+
+export interface OutputDisplay {
+  /** `options` with its output writers shaped by the gate's mode */
+  options: ExecOptions;
+  /** Write what the mode held back, once the gate's outcome is known */
+  finish(failed: boolean): void;
+}
+
+/**
+ * Shape a gate's streamed output by its `outputMode`. The writers see one
+ * formatted line per call: `head:N` passes the first N lines through,
+ * `tail:N` holds the last N and `onFailureOnly` holds everything, capped
+ * like captured output, for finish(). A marker line tells how many lines
+ * were left out.
+ */
+export function createOutputDisplay(options: ExecOptions): OutputDisplay {
+  const { outputMode: mode, streamLine } = options;
+  if (!streamLine || !mode || mode.kind === 'full') {
+    return { options, finish: () => undefined };
+  }
+
+  const target = (stream: OutputStream) =>
+    options.output?.[stream] ?? ((text: string) => process[stream].write(text));
+  const marker = (count: number) => {
+    if (count > 0) {
+      target('stdout')(`${streamLine(omittedMarker(count), 'stdout')}\n`);
+    }
+  };
+
+  if (mode.kind === 'head') {
+    let shown = 0;
+    let omitted = 0;
+    const write = (stream: OutputStream) => (text: string) => {
+      if (shown++ < mode.lines) target(stream)(text);
+      else omitted++;
+    };
+    return {
+      options: { ...options, output: writers(write) },
+      finish: () => marker(omitted),
+    };
+  }
+
+  const limit = mode.kind === 'tail' ? mode.lines : Infinity;
+  const held = new HeldLines(limit, options.maxOutputBytes ?? Infinity);
+  const hold = (stream: OutputStream) => (text: string) =>
+    held.push({ stream, text });
+  return {
+    options: { ...options, output: writers(hold) },
+    finish(failed) {
+      if (mode.kind === 'onFailureOnly' && !failed) return;
+      marker(held.omitted);
+      for (const { stream, text } of held.lines) target(stream)(text);
+    },
+  };
+}
+
+function writers(
+  write: (stream: OutputStream) => (text: string) => void
+): OutputWriters {
+  return { stdout: write('stdout'), stderr: write('stderr') };
+}
@@ -0,0 +1,147 @@
+import type { QAGateConfig } from './config-loader';
+import {
+  execAsync,
+  type CommandError,
+  type ExecOptions,
+  type ExecResult,
+  type GateCommand,
+} from './command-executor';
+import { runBuiltinGate } from './builtin-gates';
+import { createOutputDisplay } from './output-mode';
+import { isVerbose } from './status-board';
+
+type OutputRules = Pick<
+  QAGateConfig,
+  'failIfOutputMatches' | 'passIfOutputMatches' | 'allowedExitCodes'
+>;
+
+type ExecutableGate = OutputRules &
+  Partial<Pick<QAGateConfig, 'name' | 'type' | 'builtin' | 'options'>>;
+
+function matches(
+  pattern: string | undefined,
+  output: { stdout?: string; stderr?: string }
+): boolean {
+  if (pattern === undefined) return false;
+  const combined = `${output.stdout ?? ''}\n${output.stderr ?? ''}`;
+  return new RegExp(pattern, 'm').test(combined);
+}
+
+/**
+ * Whether the gate takes `code` as success: it is listed in
+ * `allowedExitCodes`, or that list is empty. Without the field only 0 is.
+ */
+function isAllowedExitCode(
+  allowed: number[] | undefined,
+  code: number
+): boolean {
+  if (allowed === undefined) return code === 0;
+  return allowed.length === 0 || allowed.includes(code);
+}
+
+function ruleError(reason: string, result: ExecResult): CommandError {
+  const error: CommandError = new Error(reason);
+  error.stdout = result.stdout;
+  error.stderr = [result.stderr.trimEnd(), reason].filter(Boolean).join('\n');
+  error.code = result.exitCode ?? 0;
+  return error;
+}
+
+/**
+ * The failed command's result when the gate's rules let it pass anyway:
+ * an allowed exit code or `passIfOutputMatches`, unless
+ * `failIfOutputMatches` also matches. Null otherwise.
+ */
+function toleratedResult(
+  gate: ExecutableGate,
+  failure: CommandError
+): ExecResult | null {
+  const code = failure.code;
+  if (typeof code !== 'number') return null;
+  if (matches(gate.failIfOutputMatches, failure)) return null;
+
+  const rule = isAllowedExitCode(gate.allowedExitCodes, code)
+    ? 'allowedExitCodes'
+    : matches(gate.passIfOutputMatches, failure)
+      ? 'passIfOutputMatches'
+      : null;
+  if (!rule) return null;
+
+  if (isVerbose()) {
+    console.log(`[execGateCommand] Exit code ${code} tolerated by ${rule}`);
+  }
+  return {
+    stdout: failure.stdout ?? '',
+    stderr: failure.stderr ?? '',
+    exitCode: code,
+  };
+}
+
+/**
+ * Whether a failure is worth another attempt. Without `retryIf` every
+ * failure is; with it, only an exit code it lists or output matching its
+ * `outputMatches`.
+ */
+export function isRetryable(
+  { retryIf }: Pick<QAGateConfig, 'retryIf'>,
+  failure: CommandError
+): boolean {
+  if (!retryIf) return true;
+  const codes = [retryIf.exitCode ?? []].flat();
+  if (typeof failure.code === 'number' && codes.includes(failure.code)) {
+    return true;
+  }
+  return matches(retryIf.outputMatches, failure);
+}
+
+async function judgeGateCommand(
+  gate: ExecutableGate,
+  command: GateCommand,
+  options: ExecOptions
+): Promise<ExecResult> {
+  let result: ExecResult;
+  try {
+    result =
+      gate.type === 'builtin'
+        ? await runBuiltinGate(gate, options)
+        : await execAsync(command, options);
+  } catch (error) {
+    const tolerated = toleratedResult(gate, error as CommandError);
+    if (!tolerated) throw error;
+    return tolerated;
+  }
+
+  if (matches(gate.failIfOutputMatches, result)) {
+    const pattern = gate.failIfOutputMatches!;
+    throw ruleError(`Output matched failIfOutputMatches /${pattern}/`, result);
+  }
+  if (!isAllowedExitCode(gate.allowedExitCodes, result.exitCode ?? 0)) {
+    throw ruleError('Exit code 0 is not in allowedExitCodes', result);
+  }
+  return result;
+}
+
+/**
+ * Run a gate command, or a builtin gate's registered check, and decide
+ * pass/fail from its exit code and output.
+ * `failIfOutputMatches` turns a zero exit into a failure and takes
+ * precedence; otherwise an exit code in `allowedExitCodes`, or output
+ * matching `passIfOutputMatches`, passes the gate. Commands that were
+ * killed or could not start always fail. Streamed output is shown as the
+ * gate's `outputMode` says, which may wait for that outcome.
+ */
+export async function execGateCommand(
+  gate: ExecutableGate,
+  command: GateCommand,
+  options: ExecOptions
+): Promise<ExecResult> {
+  const display = createOutputDisplay(options);
+  try {
+    const result = await judgeGateCommand(gate, command, display.options);
+    display.finish(false);
+    return result;
+  } catch (error) {
+    display.finish(true);
+    throw error;
+  }
+}
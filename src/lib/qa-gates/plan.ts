@@ -0,0 +1,192 @@
+import type { ForgeConfig, QAGateConfig } from './config-loader';
+import { formatCommand, getContainerPath } from './command-executor';
+import { conditionSkipReason } from './conditions';
+import { filterGates, type GateFilter } from './gate-filter';
+import { resolveGate, resolveWorkdir } from './gate-resolver';
+import { RUN_RESULT_SCHEMA_VERSION, type RunResult } from './run-report';
+import {
+  groupByDependencies,
+  groupByOrder,
+  hasDependencies,
+} from './scheduler';
+import { gateSeverity, summarizeBySeverity, type Severity } from './severity';
+
+export interface PlannedGate {
+  name: string;
+  /** Resolved command as it would be logged; unresolved if `error` is set */
+  command: string;
+  cwd: string;
+  /** Milliseconds; null means no timeout */
+  timeout: number | null;
+  failOnError: boolean;
+  severity: Severity;
+  dependsOn: string[];
+  /** Set when `enabledWhen` doesn't hold, so the run would skip the gate */
+  skipReason?: string;
+  error?: string;
+}
+
+export interface ExecutionPlan {
+  mode: 'stages' | 'graph';
+  maxParallel: number | null;
+  retry: Pick<ForgeConfig, 'maxRetries' | 'retryMode' | 'retryBackoff'>;
+  /** Gates grouped into steps; gates within a step may run in parallel */
+  steps: PlannedGate[][];
+  errors: string[];
+}
+
+function planGate(
+  gate: QAGateConfig,
+  config: ForgeConfig,
+  root: string
+): PlannedGate {
+  const skipReason = conditionSkipReason(gate);
+  const planned = {
+    name: gate.name,
+    cwd: resolveWorkdir(gate, root, config),
+    timeout: gate.timeout || null,
+    failOnError: gate.failOnError,
+    severity: gateSeverity(gate),
+    dependsOn: gate.dependsOn ?? [],
+    ...(skipReason && { skipReason }),
+  };
+
+  try {
+    const { command } = resolveGate({ gate, root, settings: config });
+    return { ...planned, command: formatCommand(command) };
+  } catch (error) {
+    return {
+      ...planned,
+      command: formatCommand(gate.command),
+      error: error instanceof Error ? error.message : String(error),
+    };
+  }
+}
+
+function selectGates(
+  config: ForgeConfig,
+  filter: GateFilter
+): { gates: QAGateConfig[]; error?: string } {
+  try {
+    return { gates: filterGates(config.qaGates, filter) };
+  } catch (error) {
+    const message = error instanceof Error ? error.message : String(error);
+    return { gates: [], error: message };
+  }
+}
+
+/**
+ * Work out what a run would do without executing anything: which gates
+ * run, in which steps, with their resolved commands. Filter and
+ * placeholder errors are collected in `errors` instead of thrown.
+ */
+export function planQAGates(
+  config: ForgeConfig,
+  repoPath: string,
+  filter: GateFilter = {}
+): ExecutionPlan {
+  const root = getContainerPath(repoPath);
+  const { gates, error } = selectGates(config, filter);
+  const graph = hasDependencies(gates);
+  const groups = graph ? groupByDependencies(gates) : groupByOrder(gates);
+  const steps = groups.map((group) =>
+    group.map((gate) => planGate(gate, config, root))
+  );
+
+  return {
+    mode: graph ? 'graph' : 'stages',
+    maxParallel: config.maxParallel ?? null,
+    retry: {
+      maxRetries: config.maxRetries,
+      retryMode: config.retryMode,
+      retryBackoff: config.retryBackoff,
+    },
+    steps,
+    errors: [
+      ...(error ? [error] : []),
+      ...steps.flat().flatMap((gate) => (gate.error ? [gate.error] : [])),
+    ],
+  };
+}
+
+function formatHeader(plan: ExecutionPlan): string[] {
+  const mode = plan.mode === 'graph' ? 'dependency graph' : 'by order';
+  const parallel = plan.maxParallel ?? 'unlimited';
+  const retries = plan.retry.maxRetries ?? 3;
+  const retryMode = plan.retry.retryMode ?? 'all';
+  return [
+    `Execution plan (${mode}, max parallel: ${parallel})`,
+    `Retries: ${retries}, mode ${retryMode}`,
+  ];
+}
+
+function formatGate(gate: PlannedGate): string[] {
+  const timeout = gate.timeout ? `${gate.timeout}ms` : 'none';
+  const after =
+    gate.dependsOn.length > 0 ? `, after ${gate.dependsOn.join(', ')}` : '';
+  const lines = [
+    `  ${gate.name}: ${gate.command}`,
+    `    cwd ${gate.cwd}, timeout ${timeout}, ` +
+      `severity ${gate.severity}${after}`,
+  ];
+  if (gate.skipReason) lines.push(`    skipped: ${gate.skipReason}`);
+  if (gate.error) lines.push(`    error: ${gate.error}`);
+  return lines;
+}
+
+/**
+ * Render a plan as human-readable text
+ */
+export function formatPlan(plan: ExecutionPlan): string {
+  const lines = formatHeader(plan);
+
+  plan.steps.forEach((step, index) => {
+    const parallel = step.length > 1 ? ' (parallel)' : '';
+    lines.push('', `Step ${index + 1}${parallel}:`);
+    lines.push(...step.flatMap(formatGate));
+  });
+
+  return lines.join('\n') + '\n';
+}
+
+/**
+ * Express a plan in the RunResult shape, every gate `planned`
+ */
+export function planToRunResult(plan: ExecutionPlan): RunResult {
+  const planned = plan.steps.flat();
+  const gates = planned.map((gate) => ({
+    name: gate.name,
+    command: gate.command,
+    status: 'planned' as const,
+    severity: gate.severity,
+    exitCode: null,
+    durationMs: 0,
+    timeoutMs: gate.timeout,
+    attempts: 0,
+    attemptDetails: [],
+    stdout: null,
+    stderr: null,
+  }));
+
+  return {
+    schemaVersion: RUN_RESULT_SCHEMA_VERSION,
+    runId: '',
+    status: 'planned',
+    startedAt: new Date().toISOString(),
+    finishedAt: null,
+    durationMs: 0,
+    exitCode: null,
+    totals: {
+      gates: gates.length,
+      passed: 0,
+      failed: 0,
+      skipped: 0,
+      timedout: 0,
+      cancelled: 0,
+      cached: 0,
+      planned: gates.length,
+    },
+    severities: summarizeBySeverity(gates, planned),
+    gates,
+  };
+}
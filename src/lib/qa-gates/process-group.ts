@@ -0,0 +1,163 @@
+import { spawn, type ChildProcess } from 'child_process';
+
+/**
+ * How long a process group gets between SIGTERM and SIGKILL by default
+ */
+export const DEFAULT_KILL_GRACE_MS = 5000;
+
+/** Exit codes used when Forge itself is interrupted, as shells do */
+const SHUTDOWN_EXIT_CODES = { SIGINT: 130, SIGTERM: 143 } as const;
+
+// Force true singleton using global to survive hot-reloads
+const globalForProcesses = global as typeof globalThis & {
+  forgeChildren?: Map<ChildProcess, number>;
+  forgeShutdownInstalled?: boolean;
+  forgeShutdownDrains?: Set<() => Promise<void>>;
+};
+
+// Running children and their grace periods
+const children = (globalForProcesses.forgeChildren ??= new Map());
+// What SIGTERM waits for before stopping children
+const drains = (globalForProcesses.forgeShutdownDrains ??= new Set());
+
+/**
+ * Kill a child's process tree on Windows, where children aren't started
+ * in a process group of their own
+ */
+function killTree(child: ChildProcess, pid: number) {
+  const taskkill = spawn('taskkill', ['/T', '/F', '/PID', String(pid)], {
+    stdio: 'ignore',
+    windowsHide: true,
+  });
+  taskkill.once('error', () => child.kill('SIGKILL'));
+}
+
+function signalGroup(child: ChildProcess, signal: NodeJS.Signals) {
+  if (child.pid === undefined) return;
+  if (process.platform === 'win32') {
+    killTree(child, child.pid);
+    return;
+  }
+  try {
+    // A negative pid addresses the whole process group
+    process.kill(-child.pid, signal);
+  } catch {
+    // No group to signal, e.g. a child that wasn't detached: signal the
+    // child itself, which is a no-op once it is gone
+    child.kill(signal);
+  }
+}
+
+/**
+ * Send SIGTERM to a child's process group, then SIGKILL if it has not
+ * exited after `graceMs`
+ */
+export function terminateGroup(child: ChildProcess, graceMs: number): void {
+  if (child.exitCode !== null || child.signalCode !== null) return;
+  signalGroup(child, 'SIGTERM');
+  const timer = setTimeout(() => signalGroup(child, 'SIGKILL'), graceMs);
+  timer.unref();
+  child.once('close', () => clearTimeout(timer));
+}
+
+/**
+ * Tear down every running child, then exit with 130 (SIGINT) or 143
+ * (SIGTERM)
+ */
+async function stopChildren(signal: keyof typeof SHUTDOWN_EXIT_CODES) {
+  console.log(`[process-group] ${signal} received, stopping gate commands`);
+  const running = [...children.entries()];
+  const longestGrace = Math.max(0, ...running.map(([, grace]) => grace));
+
+  await Promise.race([
+    Promise.all(
+      running.map(
+        ([child, grace]) =>
+          new Promise((resolve) => {
+            child.once('close', resolve);
+            terminateGroup(child, grace);
+          })
+      )
+    ),
+    new Promise((resolve) => setTimeout(resolve, longestGrace + 1000)),
+  ]);
+  process.exit(SHUTDOWN_EXIT_CODES[signal]);
+}
+
+/**
+ * On SIGTERM, wait for the registered drains first; a second SIGTERM stops
+ * waiting. SIGINT stops children straight away.
+ */
+async function shutdown(signal: keyof typeof SHUTDOWN_EXIT_CODES) {
+  if (signal === 'SIGTERM' && drains.size > 0) {
+    console.log(
+      '[process-group] SIGTERM received, waiting for running QA runs; ' +
+        'send it again to stop them'
+    );
+    process.once('SIGTERM', () => void stopChildren('SIGTERM'));
+    await Promise.allSettled([...drains].map((drain) => drain()));
+  }
+  await stopChildren(signal);
+}
+
+function installShutdownHandlers() {
+  if (globalForProcesses.forgeShutdownInstalled) return;
+  globalForProcesses.forgeShutdownInstalled = true;
+
+  for (const signal of ['SIGINT', 'SIGTERM'] as const) {
+    process.once(signal, () => void shutdown(signal));
+  }
+}
+
+/**
+ * Make SIGTERM wait for `drain` to settle before stopping gate commands
+ * and exiting, for a graceful shutdown
+ */
+export function drainOnShutdown(drain: () => Promise<void>): void {
+  installShutdownHandlers();
+  drains.add(drain);
+}
+
+/**
+ * Register a child started in its own process group so that Forge being
+ * interrupted tears the whole group down
+ */
+export function trackChild(child: ChildProcess, graceMs: number): void {
+  installShutdownHandlers();
+  children.set(child, graceMs);
+  child.once('close', () => children.delete(child));
+  child.once('error', () => children.delete(child));
+}
+
+interface SuperviseOptions {
+  /** Milliseconds; 0 or undefined disables the timeout */
+  timeout?: number;
+  signal?: AbortSignal;
+  graceMs: number;
+}
+
+/**
+ * Kill a child's process group when its timeout elapses or `signal` is
+ * aborted. Returns a getter for why it was killed, if it was.
+ */
+export function superviseChild(
+  child: ChildProcess,
+  { timeout, signal, graceMs }: SuperviseOptions
+): () => 'timeout' | 'aborted' | null {
+  let reason: 'timeout' | 'aborted' | null = null;
+  const kill = (why: 'timeout' | 'aborted') => {
+    reason ??= why;
+    terminateGroup(child, graceMs);
+  };
+
+  const timer = timeout ? setTimeout(() => kill('timeout'), timeout) : null;
+  const onAbort = () => kill('aborted');
+  if (signal?.aborted) onAbort();
+  signal?.addEventListener('abort', onAbort, { once: true });
+
+  child.once('close', () => {
+    if (timer) clearTimeout(timer);
+    signal?.removeEventListener('abort', onAbort);
+  });
+  return () => reason;
+}
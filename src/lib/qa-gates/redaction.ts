@@ -0,0 +1,78 @@
+import { DEFAULT_MAX_OUTPUT_BYTES } from './output-buffer';
+
+/** What every redacted match is replaced with */
+export const REDACTED = '***';
+
+const REGEX_LITERAL = /^\/(.+)\/([a-z]*)$/s;
+
+function literal(text: string): RegExp {
+  return new RegExp(text.replace(/[.*+?^${}()|[\]\\]/g, '\\$&'), 'g');
+}
+
+/**
+ * Compile one `redact` entry. `/source/flags` is a regular expression;
+ * anything else is matched literally. Throws on an invalid expression.
+ */
+export function parseRedactPattern(pattern: string): RegExp {
+  const regex = REGEX_LITERAL.exec(pattern);
+  if (!regex) return literal(pattern);
+  const flags = regex[2]!.includes('g') ? regex[2]! : `${regex[2]}g`;
+  return new RegExp(regex[1]!, flags);
+}
+
+export type Redactor = (text: string) => string;
+
+/**
+ * Build a function masking every `redact` pattern and every secret value
+ * with `***`, or undefined when there is nothing to mask. Longer secrets
+ * are masked first so one containing another is masked whole.
+ */
+export function createRedactor(
+  patterns: string[] = [],
+  secrets: (string | undefined)[] = []
+): Redactor | undefined {
+  const values = [...new Set(secrets)]
+    .filter((value): value is string => Boolean(value))
+    .sort((a, b) => b.length - a.length);
+  const expressions = [
+    ...values.map(literal),
+    ...patterns.map(parseRedactPattern),
+  ];
+  if (expressions.length === 0) return undefined;
+
+  return (text) =>
+    expressions.reduce(
+      (masked, expression) => masked.replace(expression, REDACTED),
+      text
+    );
+}
+
+/**
+ * Wrap a writer so that it only ever receives redacted whole lines. A
+ * partial line is held until its newline arrives, so a secret split
+ * across two chunks is still masked. A line growing past `maxPending`
+ * characters is redacted and written as it is, so output without
+ * newlines can't pile up.
+ */
+export function createLineRedactor(
+  redact: Redactor,
+  write: (text: string) => void,
+  maxPending = DEFAULT_MAX_OUTPUT_BYTES
+): { write: (chunk: Buffer | string) => void; flush: () => void } {
+  let pending = '';
+  const flush = () => {
+    if (pending) write(redact(pending));
+    pending = '';
+  };
+
+  return {
+    write(chunk) {
+      const text = pending + chunk.toString();
+      const end = text.lastIndexOf('\n') + 1;
+      pending = text.slice(end);
+      if (end > 0) write(redact(text.slice(0, end)));
+      if (pending.length > maxPending) flush();
+    },
+    flush,
+  };
+}
@@ -0,0 +1,110 @@
+import { randomUUID } from 'crypto';
+import type { OutputWriters } from './command-executor';
+import type { ForgeConfig, QAGateConfig } from './config-loader';
+import { filterGates, type GateFilter } from './gate-filter';
+import { orchestrateQAGates, type GateHooks } from './run-orchestrator';
+import { buildRunResult, type RunResult } from './run-report';
+import { withRunHooks } from './run-hooks';
+import { createMemoryRunStore, type RunStore } from './run-store';
+import { promoteWarnings } from './severity';
+import type { LogLevel } from './status-board';
+
+export interface RunnerOptions extends GateHooks {
+  /** Repository the gates run in */
+  repoPath: string;
+  /** Selects the gates to run, as the run endpoint's query does */
+  filter?: GateFilter;
+  /** Status lines and streamed output; defaults to Forge's own streams */
+  output?: OutputWriters;
+  /** Overrides the config's `maxParallel` */
+  maxParallel?: number;
+  /** Cache directory, relative to the repository; `.forge-cache` if unset */
+  cacheDir?: string;
+  /** Copy gates' `artifacts` here, relative to the repository; unset skips */
+  artifactsDir?: string;
+  /** Append the run to the history here, relative to the repository */
+  historyDir?: string;
+  /**
+   * Base ref for `changedFilesGlob` and `filesFromDiff`; defaults to the
+   * merge-base
+   */
+  since?: string;
+  /** Treat warning-severity gates as errors */
+  strict?: boolean;
+  /** Overrides the config's `continueOnFailure` */
+  continueOnFailure?: boolean;
+  /** Run `cacheInputs` gates even when their cached result is current */
+  noCache?: boolean;
+  /** false skips the `notify` webhook */
+  notify?: boolean;
+  /** Overrides the config's `logLevel` */
+  logLevel?: LogLevel;
+  /** Print a timing profile after the summary; the result has `timing` */
+  profile?: boolean;
+  /** Where executions are recorded; a fresh in-memory store per run */
+  store?: RunStore;
+  /** ID of the run to record; a fresh UUID per run */
+  runId?: string;
+}
+
+/**
+ * Runs a repository's gates in-process and returns the RunResult, for
+ * tooling that embeds Forge instead of calling its API. Reports, metrics
+ * and notifications configured in `config` still apply. The filter is
+ * checked up front, so an unknown gate name throws from the constructor;
+ * like a config failing to load, that is exit code 2 (EXIT_CODES).
+ */
+export class Runner {
+  private readonly config: ForgeConfig;
+  private readonly options: RunnerOptions;
+  private readonly gates: QAGateConfig[];
+
+  constructor(config: ForgeConfig, options: RunnerOptions) {
+    this.config = config;
+    this.options = options;
+    this.gates = filterGates(config.qaGates, options.filter);
+  }
+
+  /**
+   * Run the selected gates once. Aborting `signal` stops the running gates
+   * and skips the rest, failing the run with exit code 130. Never exits the
+   * process; the result's `exitCode` is for the caller to use.
+   */
+  async run(signal?: AbortSignal): Promise<RunResult> {
+    const {
+      runId = randomUUID(),
+      store = createMemoryRunStore(),
+      filter: _filter,
+      maxParallel = this.config.maxParallel,
+      onGateStart,
+      onGateFinish,
+      ...options
+    } = this.options;
+    const startedAt = new Date();
+
+    const status = await orchestrateQAGates({
+      ...options,
+      runId,
+      gates: this.gates,
+      settings: { ...this.config, maxParallel },
+      signal,
+      store,
+      hooks: { onGateStart, onGateFinish },
+    });
+
+    const completedAt = new Date();
+    const gates = options.strict ? promoteWarnings(this.gates) : this.gates;
+    return buildRunResult(
+      {
+        id: runId,
+        status,
+        startedAt,
+        completedAt,
+        duration: completedAt.getTime() - startedAt.getTime(),
+        interrupted: signal?.aborted,
+      },
+      await store.listExecutions(runId),
+      withRunHooks(gates, this.config)
+    );
+  }
+}
@@ -0,0 +1,63 @@
+export interface RetryBackoffConfig {
+  strategy: 'fixed' | 'linear' | 'exponential';
+  baseDelayMs: number;
+  maxDelayMs?: number;
+  jitter?: boolean;
+}
+
+function rawDelay(backoff: RetryBackoffConfig, attempt: number): number {
+  switch (backoff.strategy) {
+    case 'linear':
+      return backoff.baseDelayMs * attempt;
+    case 'exponential':
+      return backoff.baseDelayMs * 2 ** (attempt - 1);
+    default:
+      return backoff.baseDelayMs;
+  }
+}
+
+/**
+ * Delay to wait after the given (1-based) failed attempt before retrying.
+ * A missing config or zero base delay means retry immediately. With
+ * jitter, the delay is drawn from the upper half of the computed value.
+ */
+export function computeBackoffDelay(
+  backoff: RetryBackoffConfig | undefined,
+  attempt: number,
+  random: () => number = Math.random
+): number {
+  if (!backoff || backoff.baseDelayMs <= 0) return 0;
+
+  const delay = Math.min(
+    rawDelay(backoff, attempt),
+    backoff.maxDelayMs ?? Infinity
+  );
+  if (!backoff.jitter) return delay;
+  return Math.round(delay / 2 + random() * (delay / 2));
+}
+
+/**
+ * Wait for `ms` milliseconds, rejecting early if the signal is aborted
+ */
+export function sleep(ms: number, signal?: AbortSignal): Promise<void> {
+  return new Promise((resolve, reject) => {
+    if (signal?.aborted) {
+      reject(signal.reason);
+      return;
+    }
+    if (ms <= 0) {
+      resolve();
+      return;
+    }
+
+    const onAbort = () => {
+      clearTimeout(timer);
+      reject(signal?.reason);
+    };
+    const timer = setTimeout(() => {
+      signal?.removeEventListener('abort', onAbort);
+      resolve();
+    }, ms);
+    signal?.addEventListener('abort', onAbort, { once: true });
+  });
+}
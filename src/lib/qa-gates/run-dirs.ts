@@ -0,0 +1,41 @@
+import path from 'path';
+
+// Run options naming directories a run writes into
+const RUN_DIRS = ['artifactsDir', 'historyDir'] as const;
+
+export type RunDirs = Partial<Record<(typeof RUN_DIRS)[number], string>>;
+
+/**
+ * A run request's `artifactsDir` and `historyDir` query parameters
+ */
+export function parseRunDirs(searchParams: URLSearchParams): RunDirs {
+  return {
+    artifactsDir: searchParams.get('artifactsDir') || undefined,
+    historyDir: searchParams.get('historyDir') || undefined,
+  };
+}
+
+/**
+ * Whether `dir`, resolved against `root`, is `root` or a directory under it
+ */
+export function isInsideRoot(dir: string, root: string): boolean {
+  const relative = path.relative(root, path.resolve(root, dir));
+  return (
+    relative !== '..' &&
+    !relative.startsWith(`..${path.sep}`) &&
+    !path.isAbsolute(relative)
+  );
+}
+
+/**
+ * Why a run's `artifactsDir` or `historyDir` can't be used: it resolves
+ * outside the repository checked out at `root`. null when both are inside
+ * or unset.
+ */
+export function runDirsError(dirs: RunDirs, root: string): string | null {
+  const outside = RUN_DIRS.find((name) => {
+    const dir = dirs[name];
+    return dir !== undefined && !isInsideRoot(dir, root);
+  });
+  return outside ? `${outside} must be inside the repository` : null;
+}
@@ -0,0 +1,62 @@
+import type { GateSettings, QAGateConfig, RunHook } from './config-loader';
+
+/**
+ * A run's `beforeAll` and `afterAll` hooks as gates, so they run, stream
+ * their output and are recorded like any other gate
+ */
+export interface RunHookGates {
+  before?: QAGateConfig;
+  after?: QAGateConfig;
+}
+
+function hookGate(
+  name: 'beforeAll' | 'afterAll',
+  hook: RunHook,
+  order: number,
+  settings: GateSettings
+): QAGateConfig {
+  const { command, timeout, failOnError } =
+    typeof hook === 'string' || Array.isArray(hook) ? { command: hook } : hook;
+  return {
+    name,
+    enabled: true,
+    command,
+    order,
+    timeout: timeout ?? settings.defaultTimeout ?? 0,
+    failOnError: failOnError ?? name === 'beforeAll',
+  };
+}
+
+/**
+ * The configured hooks as gates, ordered before and after every gate so
+ * reports list them first and last
+ */
+export function runHookGates(
+  gates: QAGateConfig[],
+  settings: GateSettings = {}
+): RunHookGates {
+  const orders = gates.map((gate) => gate.order || 0);
+  const { beforeAll, afterAll } = settings;
+  return {
+    before:
+      beforeAll &&
+      hookGate('beforeAll', beforeAll, Math.min(0, ...orders) - 1, settings),
+    after:
+      afterAll &&
+      hookGate('afterAll', afterAll, Math.max(0, ...orders) + 1, settings),
+  };
+}
+
+/**
+ * `gates` with the configured hooks around them, e.g. to look up both in
+ * a report
+ */
+export function withRunHooks(
+  gates: QAGateConfig[],
+  settings?: GateSettings
+): QAGateConfig[] {
+  const { before, after } = runHookGates(gates, settings);
+  return [before, ...gates, after].filter(
+    (gate): gate is QAGateConfig => gate !== undefined
+  );
+}
@@ -1,63 +1,503 @@
-import { db } from '@/db';
-import { qaRuns } from '@/db/schema';
-import { eq } from 'drizzle-orm';
-import type { QAGateConfig } from './config-loader';
-import { executeGate } from './gate-executor';
+import type { GateSettings, QAGateConfig } from './config-loader';
+import { getContainerPath, type OutputWriters } from './command-executor';
+import {
+  listChangedFiles,
+  listTrackedFiles,
+  unchangedSkipReason,
+  usesChangedFiles,
+} from './changed-files';
+import { conditionSkipReason } from './conditions';
+import {
+  emptyFilesetSkipReason,
+  usesFilesets,
+  type FileLists,
+} from './filesets';
+import { executeGate, skipGate } from './gate-executor';
+import { streamsOutput } from './gate-resolver';
+import { appendRunHistory } from './history';
+import { writeJUnitReport } from './junit-report';
+import { writeMetrics } from './metrics';
+import { sendNotification } from './notify';
+import {
+  buildRunResult,
+  toGateRunResult,
+  writeRunResult,
+  type GateRunResult,
+  type RunResult,
+} from './run-report';
+import {
+  runHookGates,
+  withRunHooks,
+  type RunHookGates,
+} from './run-hooks';
+import { databaseRunStore, type RunStore } from './run-store';
+import { gateSeverity, isFailure, promoteWarnings } from './severity';
+import {
+  StatusBoard,
+  resolveTerminalOptions,
+  type LogLevel,
+} from './status-board';
+import {
+  groupByOrder,
+  hasBlockingFailure,
+  hasDependencies,
+  mapWithConcurrency,
+  runDependencyGraph,
+} from './scheduler';
+import { formatTimingProfile } from './timing';
 
-interface OrchestrateParams {
+export interface GateHooks {
+  /** Called as a gate starts executing */
+  onGateStart?: (gate: QAGateConfig) => void;
+  /** Called once a gate has an outcome, including skipped gates */
+  onGateFinish?: (result: GateRunResult) => void;
+}
+
+export interface OrchestrateParams {
   runId: string;
   repoPath: string;
   gates: QAGateConfig[];
+  settings?: GateSettings;
+  /**
+   * Base ref for `changedFilesGlob` and `filesFromDiff`; defaults to the
+   * merge-base
+   */
+  since?: string;
+  /** Treat warning-severity gates as errors */
+  strict?: boolean;
+  /** Overrides the config's `continueOnFailure` */
+  continueOnFailure?: boolean;
+  /** Run `cacheInputs` gates even when their cached result is current */
+  noCache?: boolean;
+  /** false skips the `notify` webhook for this run */
+  notify?: boolean;
+  /** Cache directory, relative to the repository root */
+  cacheDir?: string;
+  /** Where gates' `artifacts` are copied, relative to the repository root */
+  artifactsDir?: string;
+  /** Where the finished run is appended, relative to the repository root */
+  historyDir?: string;
+  /** Aborting cancels running gates and skips the rest */
+  signal?: AbortSignal;
+  /** Status lines and streamed output; defaults to Forge's own streams */
+  output?: OutputWriters;
+  /** Overrides the config's `logLevel` */
+  logLevel?: LogLevel;
+  /** Print a timing profile after the summary */
+  profile?: boolean;
+  /** Defaults to the database */
+  store?: RunStore;
+  hooks?: GateHooks;
+}
+
+interface RunParams
+  extends Omit<
+    OrchestrateParams,
+    'since' | 'strict' | 'notify' | 'store' | 'logLevel'
+  > {
+  store: RunStore;
+  files: FileLists;
+  board: StatusBoard;
+  /**
+   * Aborted along with `signal`, and by a blocking failure in a run
+   * without `continueOnFailure`; cancels the gates still running
+   */
+  stop: AbortController;
+  /** Set once a blocking `beforeAll` failure leaves every gate skipped */
+  setupFailed?: boolean;
+}
+
+/**
+ * Call a hook, logging rather than propagating its errors so embedding
+ * code can't break a run
+ */
+function callHook<T>(hook: ((arg: T) => void) | undefined, arg: T) {
+  try {
+    hook?.(arg);
+  } catch (error) {
+    console.error('Error in QA gate hook:', error);
+  }
+}
+
+/**
+ * Why a gate is not executed: its `enabledWhen` doesn't hold, its
+ * `changedFilesGlob` matches no changed file, one of its `${files:...}`
+ * lists is empty, `beforeAll` failed, a failure already decided the run,
+ * or the run was cancelled
+ */
+function skipReason(
+  { files, signal, stop, setupFailed }: RunParams,
+  gate: QAGateConfig
+): string | null {
+  if (signal?.aborted) return 'Skipped because the run was cancelled';
+  if (stop.signal.aborted) return 'Skipped because a gate failed';
+  if (setupFailed) return 'Skipped because beforeAll failed';
+  return (
+    conditionSkipReason(gate) ??
+    unchangedSkipReason(gate, files.changed) ??
+    emptyFilesetSkipReason(gate, files)
+  );
+}
+
+/**
+ * Execute a gate or record it as skipped, reporting it to the hooks
+ */
+async function runGate(params: RunParams, gate: QAGateConfig) {
+  const { runId, store, board, hooks } = params;
+  const reason = skipReason(params, gate);
+  if (!reason) callHook(hooks?.onGateStart, gate);
+  const result = await board.run(gate.name, () =>
+    reason
+      ? skipGate({ runId, gate, reason, store })
+      : executeGate({
+          runId,
+          gate,
+          repoPath: params.repoPath,
+          settings: params.settings,
+          files: params.files,
+          noCache: params.noCache,
+          cacheDir: params.cacheDir,
+          artifactsDir: params.artifactsDir,
+          signal: params.stop.signal,
+          output: params.output,
+          debug: params.settings?.logLevel === 'debug' || undefined,
+          store,
+        })
+  );
+
+  if (hooks?.onGateFinish) {
+    const execution = await store.getExecution(result.id);
+    if (execution) {
+      const finished = toGateRunResult(execution, gateSeverity(gate));
+      callHook(hooks.onGateFinish, finished);
+    }
+  }
+  return result;
 }
 
 /**
- * Update QA run status to completed
+ * The finished run with its gates as a RunResult
  */
-async function updateRunComplete(
-  runId: string,
+async function finishedRunResult(
+  { runId, settings, gates, store, signal }: RunParams,
   status: 'passed' | 'failed',
-  duration: number
-) {
-  await db
-    .update(qaRuns)
-    .set({
+  startTime: number
+): Promise<RunResult> {
+  const executions = await store.listExecutions(runId);
+  return buildRunResult(
+    {
+      id: runId,
       status,
-      duration,
+      startedAt: new Date(startTime),
       completedAt: new Date(),
-    })
-    .where(eq(qaRuns.id, runId));
+      duration: Date.now() - startTime,
+      interrupted: signal?.aborted,
+    },
+    executions,
+    withRunHooks(gates, settings)
+  );
 }
 
 /**
- * Execute all QA gates in order and update run status
- * Returns immediately - execution happens asynchronously
+ * Write the finished run to the `reportJson`, `metricsOut` and `junit`
+ * files and the history, as far as they are asked for
  */
-export async function orchestrateQAGates({
-  runId,
-  repoPath,
-  gates,
-}: OrchestrateParams): Promise<void> {
-  const startTime = Date.now();
-  let runStatus: 'passed' | 'failed' = 'passed';
+async function writeRunFiles(
+  { repoPath, settings, historyDir }: RunParams,
+  result: RunResult
+) {
+  const root = getContainerPath(repoPath);
+  if (settings?.reportJson) {
+    await writeRunResult(settings.reportJson, root, result);
+  }
+  if (settings?.metricsOut) {
+    await writeMetrics(settings.metricsOut, root, result);
+  }
+  if (settings?.junit) await writeJUnitReport(settings.junit, root, result);
+  if (historyDir) await appendRunHistory(historyDir, root, result);
+}
+
+/**
+ * Call the `notify` webhook, then write the JSON run report, Prometheus
+ * metrics and JUnit report when `reportJson`, `metricsOut` or `junit` is
+ * configured, and append the run to `historyDir`'s history when it is
+ * set. Runs after the run is marked complete, including failed runs; a
+ * failure to write or notify is logged rather than failing the run.
+ */
+async function writeReport(
+  params: RunParams & Pick<OrchestrateParams, 'notify'>,
+  status: 'passed' | 'failed',
+  startTime: number
+) {
+  const { settings, notify } = params;
+  const webhook = notify === false ? undefined : settings?.notify;
+  const outputs = [
+    settings?.reportJson,
+    settings?.metricsOut,
+    settings?.junit,
+    webhook,
+    params.historyDir,
+  ];
+  if (!outputs.some(Boolean)) return;
 
   try {
-    const enabledGates = gates.filter((g) => g.enabled);
+    const result = await finishedRunResult(params, status, startTime);
+    // Before the writes, so a failing one can't suppress the notification
+    if (webhook) await sendNotification(webhook, result, settings);
+    await writeRunFiles(params, result);
+  } catch (error) {
+    console.error('Error writing QA run report:', error);
+  }
+}
+
+/**
+ * Run gates stage by stage. Unless `continueOnFailure` is set, the first
+ * failed error-severity gate stops the run: the rest of its stage is
+ * cancelled, or skipped if it hasn't started, and later stages don't run.
+ */
+async function runStages(params: RunParams): Promise<'passed' | 'failed'> {
+  const keepGoing =
+    params.continueOnFailure ?? params.settings?.continueOnFailure;
+  let status: 'passed' | 'failed' = 'passed';
+
+  for (const stage of groupByOrder(params.gates)) {
+    const results = await mapWithConcurrency(
+      stage,
+      params.settings?.maxParallel,
+      async (gate) => {
+        const result = await runGate(params, gate);
+        if (!keepGoing && hasBlockingFailure([gate], [result])) {
+          params.stop.abort();
+        }
+        return result;
+      }
+    );
 
-    for (const gate of enabledGates) {
-      const result = await executeGate({ runId, gate, repoPath });
+    // If a gate failed and should fail on error, stop execution
+    if (!hasBlockingFailure(stage, results)) continue;
+    if (!keepGoing) return 'failed';
+    status = 'failed';
+  }
+  return status;
+}
 
-      // If gate failed and should fail on error, stop execution
-      if (result.status === 'failed' && gate.failOnError) {
-        runStatus = 'failed';
-        break;
+/**
+ * Run gates along their `dependsOn` graph; dependents of a failed
+ * error-severity gate are recorded as skipped. Unless `continueOnFailure`
+ * is set, such a failure also decides the run: gates still running on
+ * other branches are cancelled, and those not started yet skipped.
+ */
+async function runGraph(params: RunParams): Promise<'passed' | 'failed'> {
+  const { runId, gates, board, store } = params;
+  const keepGoing =
+    params.continueOnFailure ?? params.settings?.continueOnFailure;
+  const results = await runDependencyGraph(
+    gates,
+    params.settings?.maxParallel,
+    async (gate) => {
+      const result = await runGate(params, gate);
+      if (!keepGoing && hasBlockingFailure([gate], [result])) {
+        params.stop.abort();
       }
+      return result;
+    },
+    (gate) =>
+      board.run(gate.name, () =>
+        skipGate({
+          runId,
+          gate,
+          reason: 'Skipped because a dependency failed',
+          store,
+        })
+      )
+  );
+  return hasBlockingFailure(gates, results) ? 'failed' : 'passed';
+}
+
+/**
+ * Run a hook gate and tell whether its outcome fails the run
+ */
+async function runHookGate(params: RunParams, gate: QAGateConfig) {
+  const result = await runGate(params, gate);
+  return hasBlockingFailure([gate], [result]);
+}
+
+/**
+ * Abort `stop` along with `signal`. Returns a function removing the
+ * listener, so a signal outliving the run doesn't hold on to it.
+ */
+function followSignal(
+  signal: AbortSignal | undefined,
+  stop: AbortController
+): () => void {
+  const abort = () => stop.abort();
+  if (signal?.aborted) abort();
+  signal?.addEventListener('abort', abort, { once: true });
+  return () => signal?.removeEventListener('abort', abort);
+}
+
+/**
+ * Run the gates between the `beforeAll` and `afterAll` hooks. A blocking
+ * beforeAll failure skips every gate. afterAll runs whatever happened,
+ * like a `finally`, and is neither stopped by cancelling the run nor by
+ * a failure; its failure only fails the run when it sets `failOnError`.
+ */
+async function runWithHooks(
+  params: RunParams,
+  { before, after }: RunHookGates
+): Promise<'passed' | 'failed'> {
+  let status: 'passed' | 'failed' = 'failed';
+  const unfollow = followSignal(params.signal, params.stop);
+  try {
+    const setupFailed = before ? await runHookGate(params, before) : false;
+    const run = hasDependencies(params.gates) ? runGraph : runStages;
+    const gatesStatus = await run({ ...params, setupFailed });
+    status = setupFailed ? 'failed' : gatesStatus;
+  } finally {
+    unfollow();
+    const teardown = {
+      ...params,
+      signal: undefined,
+      stop: new AbortController(),
+    };
+    if (after && (await runHookGate(teardown, after))) status = 'failed';
+  }
+  return status;
+}
+
+/**
+ * Status lines for the run's gates, hook gates included; none when quiet
+ */
+function createBoard(
+  gates: QAGateConfig[],
+  settings: GateSettings | undefined,
+  output: OutputWriters | undefined
+) {
+  const boardGates = withRunHooks(gates, settings);
+  return new StatusBoard(
+    boardGates.map((gate) => gate.name),
+    {
+      ...resolveTerminalOptions(process.env, output ? false : undefined),
+      quiet: settings?.logLevel === 'quiet',
+      streamsOutput: streamsOutput(boardGates, settings),
+    },
+    output?.stdout
+  );
+}
+
+/**
+ * After a quiet run's summary, show the tail of each failed gate's output
+ */
+async function printFailureTails({ runId, store, board, settings }: RunParams) {
+  if (settings?.logLevel !== 'quiet') return;
+  try {
+    for (const execution of await store.listExecutions(runId)) {
+      if (!isFailure(execution.status)) continue;
+      const output = [execution.output, execution.error].filter(Boolean);
+      board.printFailureTail(execution.gateName, output.join('\n'));
     }
+  } catch (error) {
+    console.error('Error printing failed QA gate output:', error);
+  }
+}
 
-    const duration = Date.now() - startTime;
-    await updateRunComplete(runId, runStatus, duration);
+/**
+ * With `profile`, show where the run's time went after its summary
+ */
+async function printProfile(
+  params: RunParams,
+  status: 'passed' | 'failed',
+  startTime: number
+) {
+  if (!params.profile) return;
+  try {
+    const result = await finishedRunResult(params, status, startTime);
+    if (!result.timing) return;
+    params.board.printProfile(
+      formatTimingProfile(result.timing, result.durationMs)
+    );
+  } catch (error) {
+    console.error('Error printing QA run profile:', error);
+  }
+}
+
+/**
+ * List the files `changedFilesGlob`, `filesFromDiff` and `${files:...}`
+ * need, when any gate does
+ */
+async function listRunFiles(
+  gates: QAGateConfig[],
+  repoPath: string,
+  since?: string
+): Promise<FileLists> {
+  return {
+    tracked: usesFilesets(gates) ? await listTrackedFiles(repoPath) : null,
+    changed: usesChangedFiles(gates)
+      ? await listChangedFiles(repoPath, since)
+      : null,
+  };
+}
+
+/**
+ * Execute all QA gates in order and update run status.
+ * Gates sharing an `order` value run in parallel (bounded by maxParallel);
+ * a failed error-severity gate stops and fails the run, cancelling the
+ * rest of its stage, or with `continueOnFailure` only fails it at the end;
+ * warning and info failures don't. When any gate declares
+ * `dependsOn`, gates run as a dependency graph instead. `beforeAll` and
+ * `afterAll` run once around the gates.
+ * Resolves with the run's status once every gate has finished; callers
+ * that respond before then don't await it.
+ */
+export async function orchestrateQAGates({
+  gates,
+  since,
+  strict,
+  notify,
+  store = databaseRunStore,
+  output,
+  logLevel,
+  ...params
+}: OrchestrateParams): Promise<'passed' | 'failed'> {
+  const startTime = Date.now();
+  const enabled = gates.filter((g) => g.enabled);
+  const enabledGates = strict ? promoteWarnings(enabled) : enabled;
+  const settings = logLevel
+    ? { ...params.settings, logLevel }
+    : params.settings;
+  const board = createBoard(enabledGates, settings, output);
+  const runParams: RunParams = {
+    ...params,
+    settings,
+    gates: enabledGates,
+    store,
+    output,
+    files: { tracked: null, changed: null },
+    board,
+    stop: new AbortController(),
+  };
+  // Stays 'failed' if execution throws
+  let runStatus: 'passed' | 'failed' = 'failed';
+
+  try {
+    runParams.files = await listRunFiles(enabledGates, params.repoPath, since);
+    const hooks = runHookGates(enabledGates, params.settings);
+    const status = await runWithHooks(runParams, hooks);
+    // A cancelled run fails even if the gates it got to passed
+    runStatus = params.signal?.aborted ? 'failed' : status;
   } catch (error) {
     console.error('Error executing QA gates:', error);
-    const duration = Date.now() - startTime;
-    await updateRunComplete(runId, 'failed', duration);
+    runStatus = 'failed';
+  } finally {
+    board.close();
+    board.printSummary();
   }
+
+  await printFailureTails(runParams);
+  await printProfile(runParams, runStatus, startTime);
+  // Stored as cancelled, so a report read back later still says so
+  const stored = params.signal?.aborted ? 'cancelled' : runStatus;
+  await store.completeRun(params.runId, stored, Date.now() - startTime);
+  await writeReport({ ...runParams, notify }, runStatus, startTime);
+  return runStatus;
 }
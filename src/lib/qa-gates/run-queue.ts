@@ -0,0 +1,99 @@
+/**
+ * Repository runs allowed at once when FORGE_MAX_CONCURRENT_RUNS is unset;
+ * further runs wait their turn
+ */
+export const DEFAULT_MAX_CONCURRENT_RUNS = 1;
+
+interface RunQueueState {
+  running: number;
+  waiting: (() => void)[];
+  // Resolved each time the queue empties
+  idle: (() => void)[];
+  closed: boolean;
+}
+
+// Force true singleton using global to survive hot-reloads
+const globalForRunQueue = global as typeof globalThis & {
+  forgeRunQueue?: RunQueueState;
+};
+
+const state = (globalForRunQueue.forgeRunQueue ??= {
+  running: 0,
+  waiting: [],
+  idle: [],
+  closed: false,
+});
+
+/**
+ * FORGE_MAX_CONCURRENT_RUNS as a positive integer, else the default
+ */
+export function maxConcurrentRuns(env: NodeJS.ProcessEnv = process.env) {
+  const limit = Number(env.FORGE_MAX_CONCURRENT_RUNS);
+  return Number.isInteger(limit) && limit > 0
+    ? limit
+    : DEFAULT_MAX_CONCURRENT_RUNS;
+}
+
+/**
+ * Running and waiting runs, for health checks
+ */
+export function runQueueStatus(): {
+  running: number;
+  queued: number;
+  accepting: boolean;
+} {
+  return {
+    running: state.running,
+    queued: state.waiting.length,
+    accepting: !state.closed,
+  };
+}
+
+// Hand the finished run's slot to the next waiting run, if any
+function release() {
+  const next = state.waiting.shift();
+  if (next) return next();
+  state.running--;
+  if (state.running === 0) {
+    state.idle.splice(0).forEach((resolve) => resolve());
+  }
+}
+
+/**
+ * Run `task` once fewer than FORGE_MAX_CONCURRENT_RUNS runs are in flight,
+ * in the order runs were enqueued. Throws without running it once the
+ * queue has been closed for shutdown.
+ */
+export async function enqueueRun<T>(task: () => Promise<T>): Promise<T> {
+  if (state.closed) {
+    throw new Error('Forge is shutting down and not accepting runs');
+  }
+  if (state.running < maxConcurrentRuns()) {
+    state.running++;
+  } else {
+    await new Promise<void>((resolve) => state.waiting.push(resolve));
+  }
+  try {
+    return await task();
+  } finally {
+    release();
+  }
+}
+
+/**
+ * Stop accepting runs and wait for the running and queued ones to finish
+ */
+export function drainRuns(): Promise<void> {
+  state.closed = true;
+  if (state.running === 0 && state.waiting.length === 0) {
+    return Promise.resolve();
+  }
+  return new Promise((resolve) => state.idle.push(resolve));
+}
+
+/**
+ * Accept runs again; only tests reopen a drained queue
+ */
+export function reopenRunQueue(): void {
+  state.closed = false;
+}
@@ -0,0 +1,231 @@
+import fs from 'fs/promises';
+import path from 'path';
+import type {
+  GateArtifact,
+  GateAttempt,
+  QARunStatus,
+  qaGateExecutions,
+} from '@/db/schema';
+import type { QAGateConfig } from './config-loader';
+import { runExitCode, type ExitCode } from './exit-codes';
+import { buildRunTiming, type RunTiming } from './timing';
+import {
+  gateSeverity,
+  summarizeBySeverity,
+  type Severity,
+  type SeveritySummary,
+} from './severity';
+
+/**
+ * Bumped whenever a field is removed or changes meaning; adding fields
+ * keeps the version
+ */
+export const RUN_RESULT_SCHEMA_VERSION = 1;
+
+export type GateOutcome =
+  | 'passed'
+  | 'failed'
+  | 'skipped'
+  | 'timedout'
+  // Stopped while running, as the run was cancelled or failed
+  | 'cancelled'
+  // Passed by replaying the result stored for unchanged `cacheInputs`
+  | 'cached'
+  // Dry-run plans only: the gate would run
+  | 'planned';
+
+export interface GateRunResult {
+  name: string;
+  command: string;
+  status: GateOutcome;
+  /** null when the gate is no longer in the config */
+  severity: Severity | null;
+  exitCode: number | null;
+  durationMs: number;
+  /** The gate's timeout; null when it has none */
+  timeoutMs: number | null;
+  attempts: number;
+  /** Every attempt, oldest first; the last one is the outcome above */
+  attemptDetails: GateAttempt[];
+  /** Captured output, tail only when it exceeded `maxOutputBytes` */
+  stdout: string | null;
+  stderr: string | null;
+  /** Why a skipped gate did not run, e.g. a failed dependency */
+  skipReason?: string;
+  /** Files matching the gate's `artifacts`; absent when it declares none */
+  artifacts?: GateArtifact[];
+}
+
+export interface RunResult {
+  schemaVersion: number;
+  runId: string;
+  status: QARunStatus | 'planned';
+  /** RFC 3339 */
+  startedAt: string;
+  /** RFC 3339; null while the run is still going */
+  finishedAt: string | null;
+  durationMs: number;
+  /** What a wrapping script should exit with; null while running */
+  exitCode: ExitCode | null;
+  totals: Record<GateOutcome, number> & { gates: number };
+  /** Gate counts and failed gate names per severity */
+  severities: SeveritySummary;
+  gates: GateRunResult[];
+  /** Gates by duration and the run's critical path; absent from plans */
+  timing?: RunTiming;
+}
+
+interface RunSummary {
+  id: string;
+  status: QARunStatus;
+  startedAt: Date;
+  completedAt?: Date | null;
+  duration?: number | null;
+  /** The run was cancelled before it finished */
+  interrupted?: boolean;
+}
+
+type GateExecution = typeof qaGateExecutions.$inferSelect;
+
+function toOutcome(status: GateExecution['status']): GateOutcome {
+  if (
+    status === 'passed' ||
+    status === 'failed' ||
+    status === 'cached' ||
+    status === 'timedout' ||
+    status === 'cancelled'
+  ) {
+    return status;
+  }
+  // Gates that never finished did not run to an outcome
+  return 'skipped';
+}
+
+type SeverityGate = Pick<QAGateConfig, 'name' | 'failOnError' | 'severity'> &
+  Partial<Pick<QAGateConfig, 'order' | 'dependsOn'>>;
+
+/**
+ * One gate execution as reported in a RunResult
+ */
+export function toGateRunResult(
+  execution: GateExecution,
+  severity: Severity | null
+): GateRunResult {
+  const previous = execution.previousAttempts ?? [];
+  const durationMs = execution.duration ?? 0;
+  // The row's duration spans every attempt, and any backoff between them
+  const earlier = previous.reduce(
+    (sum, a) => sum + a.durationMs + (a.backoffMs ?? 0),
+    0
+  );
+  const final: GateAttempt = {
+    attempt: previous.length + 1,
+    exitCode: execution.exitCode ?? null,
+    durationMs: Math.max(0, durationMs - earlier),
+    stdout: execution.output || null,
+    stderr: execution.error || null,
+    ...(execution.status === 'timedout' && { timedOut: true }),
+  };
+  return {
+    name: execution.gateName,
+    command: execution.command,
+    status: toOutcome(execution.status),
+    severity,
+    exitCode: final.exitCode,
+    durationMs,
+    timeoutMs: execution.timeout ?? null,
+    attempts: final.attempt,
+    attemptDetails: [...previous, final],
+    stdout: final.stdout,
+    stderr: final.stderr,
+    skipReason:
+      execution.status === 'skipped' ? execution.output || undefined : undefined,
+    artifacts: execution.artifacts ?? undefined,
+  };
+}
+
+/**
+ * Executions by `order`, then as listed in `configGates`. Stores return
+ * gates sharing an `order` in the order they started, which varies between
+ * runs of concurrent gates; this keeps reports diffable. Gates missing
+ * from `configGates` keep the store's order, after those listed.
+ */
+function sortByConfig(
+  executions: GateExecution[],
+  configGates: SeverityGate[]
+): GateExecution[] {
+  const rank = (name: string) => {
+    const index = configGates.findIndex((gate) => gate.name === name);
+    return index === -1 ? configGates.length : index;
+  };
+  return [...executions].sort(
+    (a, b) => a.order - b.order || rank(a.gateName) - rank(b.gateName)
+  );
+}
+
+/**
+ * A run as read back from the database. Cancelled runs are stored as
+ * `cancelled` but reported like the live run was: failed and interrupted.
+ */
+export function storedRunSummary<T extends RunSummary>(run: T): T {
+  if (run.status !== 'cancelled') return run;
+  return { ...run, status: 'failed', interrupted: true };
+}
+
+/**
+ * Aggregate a run and its gate executions into the versioned RunResult
+ * shape consumed by dashboards and other tooling. Severities, and the
+ * stages or dependencies the timing's critical path follows, come from
+ * `configGates`, the gates as configured for the run.
+ */
+export function buildRunResult(
+  run: RunSummary,
+  executions: GateExecution[],
+  configGates: SeverityGate[] = []
+): RunResult {
+  const severityOf = (name: string) => {
+    const gate = configGates.find((g) => g.name === name);
+    return gate ? gateSeverity(gate) : null;
+  };
+  const gates = sortByConfig(executions, configGates).map((execution) =>
+    toGateRunResult(execution, severityOf(execution.gateName))
+  );
+  const count = (status: GateOutcome) =>
+    gates.filter((gate) => gate.status === status).length;
+
+  return {
+    schemaVersion: RUN_RESULT_SCHEMA_VERSION,
+    runId: run.id,
+    status: run.status,
+    startedAt: run.startedAt.toISOString(),
+    finishedAt: run.completedAt?.toISOString() ?? null,
+    durationMs: run.duration ?? 0,
+    exitCode: runExitCode(run.status, run.interrupted),
+    totals: {
+      gates: gates.length,
+      passed: count('passed'),
+      failed: count('failed'),
+      skipped: count('skipped'),
+      timedout: count('timedout'),
+      cancelled: count('cancelled'),
+      cached: count('cached'),
+      planned: count('planned'),
+    },
+    severities: summarizeBySeverity(gates, configGates),
+    gates,
+    timing: buildRunTiming(gates, configGates),
+  };
+}
+
+/**
+ * Write a run result as JSON. Relative paths resolve against `root`.
+ */
+export async function writeRunResult(
+  reportPath: string,
+  root: string,
+  result: RunResult
+): Promise<void> {
+  const target = path.resolve(root, reportPath);
+  await fs.mkdir(path.dirname(target), { recursive: true });
+  await fs.writeFile(target, JSON.stringify(result, null, 2) + '\n', 'utf-8');
+}
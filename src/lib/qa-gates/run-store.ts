@@ -0,0 +1,109 @@
+import { db } from '@/db';
+import { qaGateExecutions, qaRuns } from '@/db/schema';
+import { eq } from 'drizzle-orm';
+import { getGateExecutions } from './status-service';
+
+export type GateExecution = typeof qaGateExecutions.$inferSelect;
+export type NewGateExecution = typeof qaGateExecutions.$inferInsert;
+
+/**
+ * Where a repository run records its gate executions and outcome. Runs
+ * started through the API use the database; embedded runs default to
+ * memory.
+ */
+export interface RunStore {
+  insertExecution(values: NewGateExecution): Promise<GateExecution | undefined>;
+  updateExecution(id: string, values: Partial<NewGateExecution>): Promise<void>;
+  getExecution(id: string): Promise<GateExecution | undefined>;
+  /** A run's executions in gate order */
+  listExecutions(runId: string): Promise<GateExecution[]>;
+  completeRun(
+    runId: string,
+    status: 'passed' | 'failed' | 'cancelled',
+    duration: number
+  ): Promise<void>;
+}
+
+export const databaseRunStore: RunStore = {
+  async insertExecution(values) {
+    return (await db.insert(qaGateExecutions).values(values).returning())[0];
+  },
+
+  async updateExecution(id, values) {
+    await db
+      .update(qaGateExecutions)
+      .set(values)
+      .where(eq(qaGateExecutions.id, id));
+  },
+
+  async getExecution(id) {
+    return (
+      await db
+        .select()
+        .from(qaGateExecutions)
+        .where(eq(qaGateExecutions.id, id))
+        .limit(1)
+    )[0];
+  },
+
+  listExecutions: (runId) => getGateExecutions(runId),
+
+  async completeRun(runId, status, duration) {
+    await db
+      .update(qaRuns)
+      .set({ status, duration, completedAt: new Date() })
+      .where(eq(qaRuns.id, runId));
+  },
+};
+
+/**
+ * A store keeping executions in memory for the lifetime of the object,
+ * for runs embedded in other tooling that needs no database
+ */
+export function createMemoryRunStore(): RunStore {
+  const executions = new Map<string, GateExecution>();
+
+  return {
+    async insertExecution(values) {
+      const execution: GateExecution = {
+        id: values.id ?? crypto.randomUUID(),
+        runId: values.runId,
+        gateName: values.gateName,
+        command: values.command,
+        status: values.status,
+        output: values.output ?? null,
+        error: values.error ?? null,
+        exitCode: values.exitCode ?? null,
+        duration: values.duration ?? null,
+        timeout: values.timeout ?? null,
+        startedAt: values.startedAt ?? new Date(),
+        completedAt: values.completedAt ?? null,
+        order: values.order,
+        previousAttempts: values.previousAttempts ?? null,
+        artifacts: values.artifacts ?? null,
+      };
+      executions.set(execution.id, execution);
+      return execution;
+    },
+
+    async updateExecution(id, values) {
+      const execution = executions.get(id);
+      if (!execution) return;
+      executions.set(id, { ...execution, ...values } as GateExecution);
+    },
+
+    async getExecution(id) {
+      return executions.get(id);
+    },
+
+    async listExecutions(runId) {
+      return [...executions.values()]
+        .filter((execution) => execution.runId === runId)
+        .sort((a, b) => a.order - b.order);
+    },
+
+    async completeRun() {
+      // The runner reports the outcome itself
+    },
+  };
+}
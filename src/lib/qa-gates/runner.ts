@@ -2,8 +2,41 @@ import { db } from '@/db';
 import { qaGateResults, tasks } from '@/db/schema';
 import type { QAGateStatus } from '@/db/schema/qa-gates';
 import { eq } from 'drizzle-orm';
-import { loadRepositoryConfig, type QAGateConfig } from './config-loader';
-import { execAsync, getContainerPath } from './command-executor';
+import {
+  loadRepositoryConfig,
+  type ForgeConfig,
+  type GateSettings,
+  type QAGateConfig,
+} from './config-loader';
+import { getContainerPath } from './command-executor';
+import {
+  fixGate,
+  resolveGate,
+  resolveOutputOptions,
+  streamsOutput,
+} from './gate-resolver';
+import { execGateCommand } from './output-rules';
+import { hasErrorFailure, isFailure } from './severity';
+import { StatusBoard, resolveTerminalOptions } from './status-board';
+import { conditionSkipReason } from './conditions';
+import {
+  listChangedFiles,
+  listTrackedFiles,
+  unchangedSkipReason,
+  usesChangedFiles,
+} from './changed-files';
+import {
+  emptyFilesetSkipReason,
+  usesFilesets,
+  type FileLists,
+} from './filesets';
+import {
+  groupByOrder,
+  hasBlockingFailure,
+  hasDependencies,
+  mapWithConcurrency,
+  runDependencyGraph,
+} from './scheduler';
 
 interface GateResult {
   gateName: string;
@@ -44,7 +77,7 @@ async function handleRetryFeedback(
   attempt: number,
   maxRetries: number
 ) {
-  const failedGates = results.filter((r) => r.status === 'failed');
+  const failedGates = results.filter((r) => isFailure(r.status));
   const errorFeedback = formatErrorFeedback(failedGates);
 
   // NOTE: Claude re-invocation would happen here in a real implementation
@@ -53,9 +86,40 @@ async function handleRetryFeedback(
   console.log(errorFeedback);
 }
 
+/**
+ * Run the `onFailure` fix command of every failed gate that has one, as a
+ * plain command gate, storing each fix as its own result. Returns a
+ * message for the first fix that failed, or null when all fixes succeeded.
+ */
+export async function runFailureFixes(
+  taskId: string,
+  results: GateResult[],
+  config: ForgeConfig,
+  repoPath: string
+): Promise<string | null> {
+  for (const result of results) {
+    const gate = config.qaGates.find((g) => g.name === result.gateName);
+    const fix = gate && fixGate(gate);
+    if (!isFailure(result.status) || !fix) continue;
+
+    const fixed = await runSingleGate(fix, repoPath, config);
+    await storeGateResult(taskId, {
+      ...fixed,
+      gateName: `${fix.name} (onFailure)`,
+    });
+
+    if (fixed.status === 'failed') {
+      const reason = fixed.errors?.join('\n') || fixed.output;
+      return `onFailure command for gate "${fix.name}" failed: ${reason}`;
+    }
+  }
+  return null;
+}
+
 /**
  * Run QA gates with automatic retry logic.
- * If gates fail, Claude is re-invoked with error feedback up to 3 times.
+ * If error-severity gates fail, Claude is re-invoked with error feedback up
+ * to 3 times; warning and info failures alone count as a pass.
  */
 export async function runQAGatesWithRetry(
   taskId: string,
@@ -71,11 +135,8 @@ export async function runQAGatesWithRetry(
     await updateTaskAttempt(taskId, attempt);
 
     const results = await runQAGates(taskId, repoPath);
-    const allPassed = results.every(
-      (r) => r.status === 'passed' || r.status === 'skipped'
-    );
 
-    if (allPassed) {
+    if (!hasErrorFailure(results, config.qaGates)) {
       await updateTaskSuccess(taskId);
       return { passed: true, attempt };
     }
@@ -110,13 +171,7 @@ async function handleSkippedGate(taskId: string, gate: QAGateConfig) {
   return skipResult;
 }
 
-async function executeAndStoreGate(
-  taskId: string,
-  gate: QAGateConfig,
-  repoPath: string
-) {
-  const result = await runSingleGate(gate, repoPath);
-
+async function storeGateResult(taskId: string, result: GateResult) {
   await createGateResult({
     taskId,
     gateName: result.gateName,
@@ -125,36 +180,199 @@ async function executeAndStoreGate(
     duration: result.duration,
     errors: result.errors,
   });
+}
+
+interface RunStageParams {
+  taskId: string;
+  stage: QAGateConfig[];
+  repoPath: string;
+  settings: GateSettings;
+  passedGates?: ReadonlyMap<string, GateResult>;
+  files: FileLists;
+  board: StatusBoard;
+}
+
+interface RunGraphParams extends Omit<RunStageParams, 'stage'> {
+  gates: QAGateConfig[];
+}
+
+/**
+ * Reuse a gate's earlier pass, skip it when its `enabledWhen` doesn't
+ * hold, its `changedFilesGlob` matches no changed file or one of its
+ * `${files:...}` lists is empty, or run it
+ */
+async function runOrSkipGate(
+  gate: QAGateConfig,
+  params: Omit<RunStageParams, 'stage'>
+): Promise<GateResult> {
+  const passed = params.passedGates?.get(gate.name);
+  const reason =
+    conditionSkipReason(gate) ??
+    unchangedSkipReason(gate, params.files.changed) ??
+    emptyFilesetSkipReason(gate, params.files);
+  return params.board.run<GateResult>(gate.name, async () => {
+    if (passed) return passed;
+    if (reason) {
+      return {
+        gateName: gate.name,
+        status: 'skipped',
+        output: reason,
+        duration: 0,
+      };
+    }
+    return runSingleGate(gate, params.repoPath, params.settings, params.files);
+  });
+}
+
+/**
+ * Run one stage of gates concurrently, bounded by maxParallel.
+ * Results are stored in config order once the whole stage has finished, so
+ * output from concurrent gates never interleaves.
+ */
+async function runStage(params: RunStageParams): Promise<GateResult[]> {
+  const { taskId, stage } = params;
+  const results = await mapWithConcurrency(
+    stage,
+    params.settings.maxParallel,
+    (gate) => runOrSkipGate(gate, params)
+  );
+
+  for (const result of results) {
+    await storeGateResult(taskId, result);
+  }
+
+  return results;
+}
+
+async function skipStage({
+  taskId,
+  stage,
+  passedGates,
+  board,
+}: RunStageParams): Promise<GateResult[]> {
+  const results: GateResult[] = [];
+  for (const gate of stage) {
+    results.push(
+      await board.run(
+        gate.name,
+        async () =>
+          passedGates?.get(gate.name) ??
+          (await handleSkippedGate(taskId, gate))
+      )
+    );
+  }
+  return results;
+}
+
+/**
+ * Run gates along their `dependsOn` graph. Only the dependents of a failed
+ * error-severity gate are skipped; independent branches keep running.
+ */
+async function runGraph(params: RunGraphParams): Promise<GateResult[]> {
+  const { taskId, gates, passedGates, board } = params;
+  const results = await runDependencyGraph(
+    gates,
+    params.settings.maxParallel,
+    (gate) => runOrSkipGate(gate, params),
+    (gate) =>
+      board.run(
+        gate.name,
+        async () => passedGates?.get(gate.name) ?? dependencySkipped(gate)
+      )
+  );
+
+  for (const result of results) {
+    await storeGateResult(taskId, result);
+  }
 
-  return result;
+  return results;
+}
+
+function dependencySkipped(gate: QAGateConfig): GateResult {
+  return {
+    gateName: gate.name,
+    status: 'skipped',
+    output: 'Skipped because a dependency failed',
+    duration: 0,
+  };
 }
 
 /**
- * Run all enabled QA gates sequentially
+ * Run all enabled QA gates. Gates sharing an `order` value run in parallel
+ * as one stage; distinct orders run sequentially. When an error-severity
+ * gate fails, the run stops once its stage completes, unless the config
+ * sets `continueOnFailure`. If any gate declares
+ * `dependsOn`, the gates run as a dependency graph instead.
+ * Gates found in `passedGates` are not executed again; their earlier
+ * result is reused. Gates whose `enabledWhen` doesn't hold, or whose
+ * `changedFilesGlob` matches nothing changed since the merge-base, are
+ * skipped, as are gates with an empty `${files:...}` list.
  */
 export async function runQAGates(
   taskId: string,
-  repoPath: string
+  repoPath: string,
+  passedGates?: ReadonlyMap<string, GateResult>
 ): Promise<GateResult[]> {
   const config = await loadRepositoryConfig(repoPath);
   const gates = config.qaGates.filter((gate) => gate.enabled);
+  const files = {
+    tracked: usesFilesets(gates) ? await listTrackedFiles(repoPath) : null,
+    changed: usesChangedFiles(gates) ? await listChangedFiles(repoPath) : null,
+  };
+  const board = new StatusBoard(gates.map((gate) => gate.name), {
+    ...resolveTerminalOptions(),
+    quiet: config.logLevel === 'quiet',
+    streamsOutput: streamsOutput(gates, config),
+  });
+  const params = {
+    taskId,
+    repoPath,
+    settings: config,
+    passedGates,
+    files,
+    board,
+  };
+
+  let results: GateResult[] = [];
+  try {
+    const run = hasDependencies(gates) ? runGraph : runStages;
+    results = await run({ ...params, gates });
+    return results;
+  } finally {
+    board.close();
+    board.printSummary();
+    printFailureTails(board, results);
+  }
+}
 
+/**
+ * After a quiet run's summary, show the tail of each failed gate's output
+ */
+function printFailureTails(board: StatusBoard, results: GateResult[]) {
+  for (const result of results) {
+    if (!isFailure(result.status)) continue;
+    const output = [result.output, ...(result.errors ?? [])].filter(Boolean);
+    board.printFailureTail(result.gateName, output.join('\n'));
+  }
+}
+
+async function runStages(params: RunGraphParams): Promise<GateResult[]> {
   const results: GateResult[] = [];
   let shouldStop = false;
 
-  for (const gate of gates) {
+  for (const stage of groupByOrder(params.gates)) {
+    const stageParams = { ...params, stage };
     if (shouldStop) {
-      const skipResult = await handleSkippedGate(taskId, gate);
-      results.push(skipResult);
+      results.push(...(await skipStage(stageParams)));
       continue;
     }
 
-    const result = await executeAndStoreGate(taskId, gate, repoPath);
-    results.push(result);
+    const stageResults = await runStage(stageParams);
+    results.push(...stageResults);
 
-    if (result.status === 'failed' && gate.failOnError) {
-      shouldStop = true;
-    }
+    shouldStop =
+      !params.settings.continueOnFailure &&
+      hasBlockingFailure(stage, stageResults);
   }
 
   return results;
@@ -165,7 +383,9 @@ export async function runQAGates(
  */
 async function runSingleGate(
   gate: QAGateConfig,
-  repoPath: string
+  repoPath: string,
+  settings?: GateSettings,
+  files?: FileLists
 ): Promise<GateResult> {
   const startTime = Date.now();
 
@@ -173,9 +393,17 @@ async function runSingleGate(
   const containerPath = getContainerPath(repoPath);
 
   try {
-    const { stdout } = await execAsync(gate.command, {
-      cwd: containerPath,
-      timeout: gate.timeout || 60000,
+    const { command, ...resolved } = resolveGate({
+      gate,
+      root: containerPath,
+      settings,
+      files,
+    });
+    const { stdout } = await execGateCommand(gate, command, {
+      ...resolved,
+      timeout: gate.timeout,
+      killGraceMs: settings?.shutdownGraceMs,
+      ...resolveOutputOptions(gate, settings),
     });
 
     const duration = Date.now() - startTime;
@@ -0,0 +1,292 @@
+import { blocksRun, isFailure, type Severity } from './severity';
+
+/**
+ * Split gates (already sorted by order) into sequential stages.
+ * Consecutive gates sharing the same `order` form one stage and may run in
+ * parallel; gates without an order run on their own, except for the
+ * instances of one matrix gate, which share a stage.
+ */
+export function groupByOrder<
+  T extends { order?: number; matrixOf?: string }
+>(gates: T[]): T[][] {
+  const stages: T[][] = [];
+  let previous: T | undefined;
+
+  for (const gate of gates) {
+    const current = stages[stages.length - 1];
+    const sameOrder =
+      gate.order !== undefined && gate.order === previous?.order;
+    const sameMatrix =
+      gate.matrixOf !== undefined && gate.matrixOf === previous?.matrixOf;
+    if (current && (sameOrder || sameMatrix)) {
+      current.push(gate);
+    } else {
+      stages.push([gate]);
+    }
+    previous = gate;
+  }
+
+  return stages;
+}
+
+/**
+ * Map items through an async function with at most `limit` calls in flight.
+ * Results keep the order of `items` regardless of completion order. An
+ * undefined limit runs everything at once.
+ */
+export async function mapWithConcurrency<T, R>(
+  items: T[],
+  limit: number | undefined,
+  fn: (item: T, index: number) => Promise<R>
+): Promise<R[]> {
+  const results: R[] = new Array(items.length);
+  const workerCount = Math.min(
+    items.length,
+    Math.max(1, limit ?? items.length)
+  );
+  let next = 0;
+
+  async function worker() {
+    while (next < items.length) {
+      const index = next++;
+      results[index] = await fn(items[index]!, index);
+    }
+  }
+
+  await Promise.all(Array.from({ length: workerCount }, worker));
+  return results;
+}
+
+/**
+ * Whether any error-severity gate in a finished stage failed
+ */
+export function hasBlockingFailure(
+  stage: { failOnError: boolean; severity?: Severity }[],
+  results: { status: string }[]
+): boolean {
+  return results.some((result, index) => {
+    const gate = stage[index];
+    return isFailure(result.status) && gate !== undefined && blocksRun(gate);
+  });
+}
+
+interface GraphGate {
+  name: string;
+  order?: number;
+  failOnError: boolean;
+  severity?: Severity;
+  dependsOn?: string[];
+}
+
+/**
+ * Whether any gate declares dependencies, switching the run from
+ * order-based stages to dependency-graph scheduling
+ */
+export function hasDependencies(gates: { dependsOn?: string[] }[]): boolean {
+  return gates.some((gate) => (gate.dependsOn?.length ?? 0) > 0);
+}
+
+/**
+ * Find a dependency cycle among gates. Returns the gate names along the
+ * cycle with the first one repeated at the end, or null when there is none.
+ * Dependencies on unknown gates are ignored.
+ */
+export function findDependencyCycle(
+  gates: { name: string; dependsOn?: string[] }[]
+): string[] | null {
+  const deps = new Map(gates.map((gate) => [gate.name, gate.dependsOn ?? []]));
+  const done = new Set<string>();
+  const path: string[] = [];
+
+  function visit(name: string): string[] | null {
+    if (done.has(name) || !deps.has(name)) return null;
+    if (path.includes(name)) {
+      return [...path.slice(path.indexOf(name)), name];
+    }
+    path.push(name);
+    for (const dep of deps.get(name)!) {
+      const cycle = visit(dep);
+      if (cycle) return cycle;
+    }
+    path.pop();
+    done.add(name);
+    return null;
+  }
+
+  for (const name of deps.keys()) {
+    const cycle = visit(name);
+    if (cycle) return cycle;
+  }
+  return null;
+}
+
+/**
+ * Split a dependency graph into levels for display: each gate sits one
+ * level after its deepest dependency, so gates in the same level may run
+ * in parallel. Within a level gates keep `order`. Expects an acyclic graph.
+ */
+export function groupByDependencies<
+  T extends { name: string; order?: number; dependsOn?: string[] },
+>(gates: T[]): T[][] {
+  const byName = new Map(gates.map((gate) => [gate.name, gate]));
+  const depth = new Map<string, number>();
+
+  function levelOf(gate: T): number {
+    const known = depth.get(gate.name);
+    if (known !== undefined) return known;
+    const deps = (gate.dependsOn ?? []).flatMap((name) => {
+      const dep = byName.get(name);
+      return dep ? [levelOf(dep)] : [];
+    });
+    const level = deps.length > 0 ? Math.max(...deps) + 1 : 0;
+    depth.set(gate.name, level);
+    return level;
+  }
+
+  const levels: T[][] = [];
+  for (const gate of byOrder(gates)) {
+    (levels[levelOf(gate)] ??= []).push(gate);
+  }
+  return levels.filter(Boolean);
+}
+
+function byOrder<T extends { order?: number }>(gates: T[]): T[] {
+  const rank = (gate: T) => gate.order ?? Number.MAX_SAFE_INTEGER;
+  return [...gates].sort((a, b) => rank(a) - rank(b));
+}
+
+/**
+ * How many gates depend on each gate, directly or transitively. Gates
+ * with more dependents sit on longer paths through the graph.
+ */
+export function countDependents(
+  gates: { name: string; dependsOn?: string[] }[]
+): Map<string, number> {
+  function collect(name: string, into: Set<string>): Set<string> {
+    for (const gate of gates) {
+      if (!gate.dependsOn?.includes(name) || into.has(gate.name)) continue;
+      into.add(gate.name);
+      collect(gate.name, into);
+    }
+    return into;
+  }
+
+  return new Map(
+    gates.map((gate) => [gate.name, collect(gate.name, new Set()).size])
+  );
+}
+
+/**
+ * Gates in the order they should start when not all can: most dependents
+ * first, to get the critical path going, then lowest `order`
+ */
+function byPriority<T extends GraphGate>(gates: T[]): T[] {
+  const dependents = countDependents(gates);
+  const count = (gate: T) => dependents.get(gate.name) ?? 0;
+  return byOrder(gates).sort((a, b) => count(b) - count(a));
+}
+
+/**
+ * Whether a settled gate's dependents must be skipped: it failed at error
+ * severity, or was cancelled before it could finish
+ */
+function blocksDependents(gate: GraphGate, result: { status: string }) {
+  if (result.status === 'cancelled') return true;
+  return isFailure(result.status) && blocksRun(gate);
+}
+
+/**
+ * Book-keeping for a single dependency-graph run
+ */
+class GraphRun<T extends GraphGate, R extends { status: string }> {
+  private readonly gates: T[];
+  private readonly names: Set<string>;
+  private readonly results = new Map<T, R>();
+  private readonly settled = new Set<string>();
+  private readonly blocked = new Set<string>();
+  readonly running = new Set<Promise<void>>();
+  readonly waiting: T[];
+
+  constructor(gates: T[]) {
+    this.gates = gates;
+    this.names = new Set(gates.map((gate) => gate.name));
+    this.waiting = byPriority(gates);
+  }
+
+  private depsOf(gate: T): string[] {
+    return (gate.dependsOn ?? []).filter((name) => this.names.has(name));
+  }
+
+  ready(): T[] {
+    return this.waiting.filter((gate) =>
+      this.depsOf(gate).every((name) => this.settled.has(name))
+    );
+  }
+
+  isBlocked(gate: T): boolean {
+    return this.depsOf(gate).some((name) => this.blocked.has(name));
+  }
+
+  take(gate: T): T {
+    this.waiting.splice(this.waiting.indexOf(gate), 1);
+    return gate;
+  }
+
+  settle(gate: T, result: R, isBlocking: boolean) {
+    this.results.set(gate, result);
+    this.settled.add(gate.name);
+    if (isBlocking) this.blocked.add(gate.name);
+  }
+
+  start(gate: T, run: (gate: T) => Promise<R>) {
+    const task: Promise<void> = run(this.take(gate)).then((result) => {
+      this.settle(gate, result, blocksDependents(gate, result));
+      this.running.delete(task);
+    });
+    this.running.add(task);
+  }
+
+  ordered(): R[] {
+    return this.gates.map((gate) => this.results.get(gate)!);
+  }
+}
+
+/**
+ * Run gates as a dependency graph. A gate starts once everything it depends
+ * on has settled, with at most `limit` running at once; among ready gates
+ * the one with the most dependents starts first, then the lowest `order`.
+ * A gate whose dependency failed at error severity or was cancelled, or
+ * was skipped for that reason, goes through `skip` instead.
+ * Dependencies on gates outside `gates` count as satisfied. Results keep
+ * the order of `gates`.
+ */
+export async function runDependencyGraph<
+  T extends GraphGate,
+  R extends { status: string },
+>(
+  gates: T[],
+  limit: number | undefined,
+  run: (gate: T) => Promise<R>,
+  skip: (gate: T) => Promise<R>
+): Promise<R[]> {
+  const graph = new GraphRun<T, R>(gates);
+  const maxRunning = Math.max(1, limit ?? gates.length);
+
+  while (graph.waiting.length > 0 || graph.running.size > 0) {
+    const ready = graph.ready();
+    const blocked = ready.find((gate) => graph.isBlocked(gate));
+
+    if (blocked) {
+      graph.settle(graph.take(blocked), await skip(blocked), true);
+    } else if (ready[0] && graph.running.size < maxRunning) {
+      graph.start(ready[0], run);
+    } else if (graph.running.size > 0) {
+      await Promise.race(graph.running);
+    } else {
+      const stuck = graph.waiting.map((gate) => gate.name).join(', ');
+      throw new Error(`Unresolvable gate dependencies: ${stuck}`);
+    }
+  }
+
+  return graph.ordered();
+}
@@ -0,0 +1,85 @@
+import type { QAGateConfig } from './config-loader';
+
+/**
+ * How much a gate's failure matters: `error` fails the run, `warning` is
+ * reported prominently but doesn't, and `info` is only recorded
+ */
+export const SEVERITIES = ['error', 'warning', 'info'] as const;
+export type Severity = (typeof SEVERITIES)[number];
+
+type SeverityFields = Pick<QAGateConfig, 'failOnError'> &
+  Partial<Pick<QAGateConfig, 'severity'>>;
+
+/**
+ * A gate's severity: its explicit `severity`, else `error` for failOnError
+ * gates and `warning` otherwise. `strict` promotes warnings to errors.
+ */
+export function gateSeverity(gate: SeverityFields, strict = false): Severity {
+  const severity = gate.severity ?? (gate.failOnError ? 'error' : 'warning');
+  return strict && severity === 'warning' ? 'error' : severity;
+}
+
+/**
+ * Whether a failure of this gate fails the run and stops later stages
+ */
+export function blocksRun(gate: SeverityFields): boolean {
+  return gateSeverity(gate) === 'error';
+}
+
+/**
+ * Whether a gate's outcome is a failure: it failed, or its timeout
+ * stopped it
+ */
+export function isFailure(status: string): boolean {
+  return status === 'failed' || status === 'timedout';
+}
+
+/**
+ * Whether an error-severity gate failed. Results are matched to `gates` by
+ * name; a result whose gate isn't found counts as an error. Warning and
+ * info failures are recorded but don't fail the run.
+ */
+export function hasErrorFailure(
+  results: { gateName: string; status: string }[],
+  gates: (SeverityFields & { name: string })[]
+): boolean {
+  return results.some((result) => {
+    const gate = gates.find((g) => g.name === result.gateName);
+    return isFailure(result.status) && (!gate || blocksRun(gate));
+  });
+}
+
+/**
+ * Resolve every gate's severity for a strict run, in which warnings are
+ * errors
+ */
+export function promoteWarnings<T extends SeverityFields>(gates: T[]): T[] {
+  return gates.map((gate) => ({ ...gate, severity: gateSeverity(gate, true) }));
+}
+
+export type SeveritySummary = Record<
+  Severity,
+  { gates: number; failed: string[] }
+>;
+
+/**
+ * Count gates and name failures per severity. Results for gates missing
+ * from `gates` are left out.
+ */
+export function summarizeBySeverity(
+  results: { name: string; status: string }[],
+  gates: (SeverityFields & { name: string })[]
+): SeveritySummary {
+  const summary = Object.fromEntries(
+    SEVERITIES.map((severity) => [severity, { gates: 0, failed: [] }])
+  ) as unknown as SeveritySummary;
+
+  for (const result of results) {
+    const gate = gates.find((g) => g.name === result.name);
+    if (!gate) continue;
+    const entry = summary[gateSeverity(gate)];
+    entry.gates++;
+    if (isFailure(result.status)) entry.failed.push(result.name);
+  }
+  return summary;
+}
@@ -0,0 +1,138 @@
+import { accessSync, constants, statSync } from 'fs';
+import path from 'path';
+
+/**
+ * Shells a string command can run under. `none` splits the string into
+ * arguments and spawns them directly.
+ */
+export const SHELLS = ['sh', 'bash', 'pwsh', 'cmd', 'none'] as const;
+export type ShellName = (typeof SHELLS)[number];
+
+export interface ShellInvocation {
+  file: string;
+  args: string[];
+  /** cmd.exe parses its own command line, so Node must not quote it */
+  verbatim?: boolean;
+}
+
+function isExecutable(file: string): boolean {
+  try {
+    if (!statSync(file).isFile()) return false;
+    if (process.platform !== 'win32') accessSync(file, constants.X_OK);
+    return true;
+  } catch {
+    return false;
+  }
+}
+
+/**
+ * Find an executable on PATH, trying PATHEXT extensions on Windows.
+ * Returns its full path, or null when it isn't installed.
+ */
+export function findOnPath(
+  name: string,
+  env: NodeJS.ProcessEnv = process.env
+): string | null {
+  const extensions =
+    process.platform === 'win32'
+      ? ['', ...(env.PATHEXT ?? '.COM;.EXE;.BAT;.CMD').split(';')]
+      : [''];
+  const dirs = (env.PATH ?? env.Path ?? '').split(path.delimiter);
+
+  for (const dir of dirs.filter(Boolean)) {
+    for (const extension of extensions) {
+      const candidate = path.join(dir, name + extension);
+      if (isExecutable(candidate)) return candidate;
+    }
+  }
+  return null;
+}
+
+/**
+ * The shell used when neither the gate nor the config sets one: `cmd` on
+ * Windows, else undefined, meaning bash where installed and sh otherwise
+ */
+export function defaultShell(
+  platform: NodeJS.Platform = process.platform
+): ShellName | undefined {
+  return platform === 'win32' ? 'cmd' : undefined;
+}
+
+// A double-quoted run, a single-quoted run, an escaped character,
+// whitespace, a plain run, or a stray quote
+const TOKEN = /"((?:\\[^]|[^"\\])*)"|'([^']*)'|\\([^])|(\s+)|([^\s"'\\]+)|(.)/g;
+
+/**
+ * Split a command line into arguments the way a POSIX shell would for
+ * plain words: whitespace separates, quotes group, and a backslash escapes
+ * the next character outside single quotes. Nothing is expanded.
+ */
+export function splitCommandLine(text: string): string[] {
+  const args: string[] = [];
+  let current: string | null = null;
+
+  for (const [, double, single, escaped, space, plain, stray] of text.matchAll(
+    TOKEN
+  )) {
+    if (stray !== undefined) {
+      throw new Error(`Unterminated ${stray} quote in command`);
+    }
+    if (space !== undefined) {
+      if (current !== null) args.push(current);
+      current = null;
+      continue;
+    }
+    const word =
+      double?.replace(/\\([^])/g, '$1') ?? single ?? escaped ?? plain ?? '';
+    current = (current ?? '') + word;
+  }
+
+  if (current !== null) args.push(current);
+  return args;
+}
+
+/**
+ * How to run a string command under `shell`. `file` is a bare name to be
+ * looked up on PATH.
+ */
+export function shellInvocation(
+  shell: Exclude<ShellName, 'none'>,
+  command: string
+): ShellInvocation {
+  switch (shell) {
+    case 'sh':
+    case 'bash':
+      return { file: shell, args: ['-c', command] };
+    case 'pwsh':
+      return {
+        file: 'pwsh',
+        args: ['-NoProfile', '-NonInteractive', '-Command', command],
+      };
+    case 'cmd':
+      return {
+        file: 'cmd',
+        args: ['/d', '/s', '/c', `"${command}"`],
+        verbatim: true,
+      };
+  }
+}
+
+// Arguments made only of these reach every shell unchanged unquoted
+const PLAIN_ARGUMENT = /^[A-Za-z0-9_@%+=:,./-]+$/;
+
+/**
+ * Quote an argument for a string command run under `shell`, so it reaches
+ * the command as one word, unchanged. POSIX quoting covers sh, bash and
+ * `none`, which splits the way they do.
+ */
+export function quoteArgument(arg: string, shell: ShellName): string {
+  if (PLAIN_ARGUMENT.test(arg)) return arg;
+  switch (shell) {
+    case 'pwsh':
+      return `'${arg.replace(/'/g, "''")}'`;
+    case 'cmd':
+      return `"${arg.replace(/"/g, '""')}"`;
+    default:
+      return `'${arg.replace(/'/g, `'\\''`)}'`;
+  }
+}
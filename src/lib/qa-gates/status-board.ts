@@ -0,0 +1,343 @@
+import type { QAGateStatus } from '@/db/schema';
+
+// Task gates report a QAGateStatus; repository gates may also be cached,
+// time out or be cancelled
+type BoardStatus = QAGateStatus | 'cached' | 'timedout' | 'cancelled';
+
+/**
+ * How much a run prints: `quiet` only the summary and failed gates'
+ * output, `normal` status lines, `verbose` also streams command output and
+ * `debug` also logs each gate's resolved command, env and timings
+ */
+export const LOG_LEVELS = ['quiet', 'normal', 'verbose', 'debug'] as const;
+export type LogLevel = (typeof LOG_LEVELS)[number];
+
+export interface TerminalOptions {
+  /** ANSI colors in gate status lines */
+  color: boolean;
+  /** Redraw a status block in place instead of printing a line per gate */
+  live: boolean;
+  /** Log every command and its outcome instead of status lines */
+  verbose: boolean;
+  /** No status lines; only the summary is printed */
+  quiet?: boolean;
+  /** Gate output is streamed to the same terminal, so `live` can't hold */
+  streamsOutput?: boolean;
+}
+
+const enabled = (value: string | undefined) =>
+  value !== undefined && value !== '' && value !== '0' && value !== 'false';
+
+/**
+ * How gate progress is written to Forge's own stdout. Color and in-place
+ * rendering need a TTY and are off in CI. `NO_COLOR` or `FORGE_NO_COLOR`
+ * disable color; `FORGE_VERBOSE` restores the per-command log.
+ */
+export function resolveTerminalOptions(
+  env: NodeJS.ProcessEnv = process.env,
+  isTTY = Boolean(process.stdout.isTTY)
+): TerminalOptions {
+  const fancy = isTTY && !enabled(env.CI);
+  return {
+    color: fancy && !env.NO_COLOR && !enabled(env.FORGE_NO_COLOR),
+    live: fancy,
+    verbose: enabled(env.FORGE_VERBOSE),
+  };
+}
+
+/**
+ * A log level named by a request parameter; unknown values are ignored
+ */
+export function parseLogLevel(value: string | null): LogLevel | undefined {
+  return LOG_LEVELS.find((level) => level === value);
+}
+
+/**
+ * Whether per-command logging is on (`FORGE_VERBOSE`)
+ */
+export function isVerbose(): boolean {
+  return resolveTerminalOptions().verbose;
+}
+
+const SPINNER = ['⠋', '⠙', '⠹', '⠸', '⠼', '⠴', '⠦', '⠧', '⠇', '⠏'];
+const SPINNER_INTERVAL_MS = 80;
+// Lines of a failed gate's output shown by a quiet run
+const FAILURE_TAIL_LINES = 20;
+
+const COLORS = { green: 32, red: 31, yellow: 33, gray: 90, cyan: 36 } as const;
+
+// Force true singleton using global to survive hot-reloads
+const globalForBoards = global as typeof globalThis & {
+  forgeLiveBoards?: Set<StatusBoard>;
+};
+
+// Live boards drawing right now, across concurrent runs and watchers
+const liveBoards = (globalForBoards.forgeLiveBoards ??= new Set());
+
+/**
+ * Elapsed time for status lines: "850ms", "3.1s", "2m05s"
+ */
+export function formatElapsed(ms: number): string {
+  if (ms < 1000) return `${Math.round(ms)}ms`;
+  if (ms < 60000) return `${(ms / 1000).toFixed(1)}s`;
+  const seconds = Math.floor(ms / 1000);
+  const rest = String(seconds % 60).padStart(2, '0');
+  return `${Math.floor(seconds / 60)}m${rest}s`;
+}
+
+interface GateLine {
+  status: BoardStatus;
+  startedAt: number;
+  duration: number;
+  /** Shown for skipped gates, e.g. "Skipped because a dependency failed" */
+  reason?: string;
+  /** Noted as "(passed on attempt 2/3)" once a gate needed a retry */
+  attempt?: number;
+  maxAttempts?: number;
+}
+
+type FinishedGate = Omit<GateLine, 'startedAt'>;
+
+const PENDING: GateLine = { status: 'pending', startedAt: 0, duration: 0 };
+
+const SUMMARY_HEADER = ['Gate', 'Status', 'Duration', 'Attempts'];
+
+/**
+ * Lay out rows as columns two spaces apart, as wide as the first row
+ */
+export function formatColumns(rows: string[][]): string {
+  const widths = rows[0]!.map((_, column) =>
+    Math.max(...rows.map((row) => row[column]!.length))
+  );
+  return rows
+    .map((row) =>
+      row
+        .map((cell, column) => cell.padEnd(widths[column]!))
+        .join('  ')
+        .trimEnd()
+    )
+    .join('\n');
+}
+
+/**
+ * Per-gate status lines for one run: a spinner while a gate runs, then
+ * ✓, ✗, ⏱ (timed out), ⊘ (cancelled) or ↷ (skipped, with the reason) with
+ * its elapsed time, or "cached". On a TTY the lines form a block redrawn
+ * in place, so parallel gates don't scroll, as long as nothing else writes
+ * to the terminal: streamed gate output or another run's board turn it
+ * back into one plain line per gate as it finishes, as printed elsewhere.
+ * Silent under verbose output, which logs every command instead, and when
+ * quiet. printSummary() ends a run with a table of every gate.
+ */
+export class StatusBoard {
+  private readonly lines = new Map<string, GateLine>();
+  private readonly width: number;
+  private readonly options: TerminalOptions;
+  private readonly write: (text: string) => void;
+  private readonly startedAt = Date.now();
+  private drawn = 0;
+  private frame = 0;
+  private timer: NodeJS.Timeout | null = null;
+  private appendOnly = false;
+
+  constructor(
+    gateNames: string[],
+    options: TerminalOptions = resolveTerminalOptions(),
+    write: (text: string) => void = (text) => process.stdout.write(text)
+  ) {
+    this.options = options;
+    this.write = write;
+    this.width = Math.max(0, ...gateNames.map((name) => name.length));
+    for (const name of gateNames) {
+      this.lines.set(name, PENDING);
+    }
+  }
+
+  /**
+   * Show `name` as running until `task` settles, then with the status,
+   * duration, skip reason and attempt it returns
+   */
+  async run<T extends FinishedGate>(
+    name: string,
+    task: () => Promise<T>
+  ): Promise<T> {
+    this.update(name, { status: 'running', startedAt: Date.now() });
+    this.startSpinner();
+    try {
+      const result = await task();
+      this.finish(name, result);
+      return result;
+    } catch (error) {
+      const duration = Date.now() - this.lineFor(name).startedAt;
+      this.finish(name, { status: 'failed', duration });
+      throw error;
+    }
+  }
+
+  /**
+   * Stop redrawing; call once the run is over
+   */
+  close(): void {
+    if (this.timer) clearInterval(this.timer);
+    this.timer = null;
+    if (this.inPlace && !this.silent) this.redraw();
+    liveBoards.delete(this);
+  }
+
+  /**
+   * Write the end-of-run table: each gate's status, duration and attempts,
+   * then the totals. Printed under verbose output too.
+   */
+  printSummary(): void {
+    const rows = [...this.lines].map(([name, line]) => {
+      const ran = ['passed', 'failed', 'timedout', 'cancelled'].includes(
+        line.status
+      );
+      return [
+        name,
+        line.status,
+        ran ? formatElapsed(line.duration) : '-',
+        ran ? String(line.attempt ?? 1) : '-',
+      ];
+    });
+    const table = formatColumns([SUMMARY_HEADER, ...rows]);
+    this.write(`\n${table}\n${this.totalsLine()}\n`);
+  }
+
+  /**
+   * When quiet, write the last lines of a failed gate's output, so a quiet
+   * run's failures aren't silent. Call after printSummary().
+   */
+  printFailureTail(name: string, output: string): void {
+    if (!this.options.quiet) return;
+    const lines = output.trimEnd().split('\n');
+    const tail = lines.slice(-FAILURE_TAIL_LINES).join('\n');
+    const count = Math.min(lines.length, FAILURE_TAIL_LINES);
+    this.write(`\n--- ${name} (last ${count} lines) ---\n${tail}\n`);
+  }
+
+  /**
+   * Write a `profile` breakdown of the run's timing, whatever the log
+   * level. Call after printSummary().
+   */
+  printProfile(profile: string): void {
+    this.write(`\n${profile}\n`);
+  }
+
+  // "Total: 3 gates in 4.2s (2 passed, 1 failed)"
+  private totalsLine(): string {
+    const counts = new Map<BoardStatus, number>();
+    for (const line of this.lines.values()) {
+      counts.set(line.status, (counts.get(line.status) ?? 0) + 1);
+    }
+    const totals = [...counts].map(([status, n]) => `${n} ${status}`);
+    const gates = `${this.lines.size} gate${this.lines.size === 1 ? '' : 's'}`;
+    const elapsed = formatElapsed(Date.now() - this.startedAt);
+    const detail = totals.length > 0 ? ` (${totals.join(', ')})` : '';
+    return `Total: ${gates} in ${elapsed}${detail}`;
+  }
+
+  // No status lines when quiet, or under verbose output, which logs every
+  // command instead
+  private get silent(): boolean {
+    return this.options.verbose || Boolean(this.options.quiet);
+  }
+
+  // Whether the block is redrawn in place: live, with no streamed output
+  // or other live board writing between redraws. Once off it stays off,
+  // since the block may have scrolled away meanwhile.
+  private get inPlace(): boolean {
+    if (this.appendOnly || !this.options.live) return false;
+    const others = [...liveBoards].some((board) => board !== this);
+    this.appendOnly = Boolean(this.options.streamsOutput) || others;
+    return !this.appendOnly;
+  }
+
+  private finish(name: string, result: FinishedGate) {
+    const { status, duration, reason, attempt, maxAttempts } = result;
+    this.update(name, { status, duration, reason, attempt, maxAttempts });
+    if (this.silent) return;
+    if (this.inPlace) this.redraw();
+    else this.write(`${this.format(name)}\n`);
+  }
+
+  private lineFor(name: string): GateLine {
+    return this.lines.get(name) ?? PENDING;
+  }
+
+  private update(name: string, change: Partial<GateLine>) {
+    this.lines.set(name, { ...this.lineFor(name), ...change });
+  }
+
+  private startSpinner() {
+    if (!this.options.live || this.silent) return;
+    liveBoards.add(this);
+    if (!this.inPlace) return;
+    this.redraw();
+    if (this.timer) return;
+    this.timer = setInterval(() => {
+      this.frame = (this.frame + 1) % SPINNER.length;
+      if (this.inPlace) this.redraw();
+    }, SPINNER_INTERVAL_MS);
+    this.timer.unref();
+  }
+
+  private paint(color: keyof typeof COLORS, text: string) {
+    return this.options.color ? `\x1b[${COLORS[color]}m${text}\x1b[0m` : text;
+  }
+
+  private symbol(status: BoardStatus): string {
+    switch (status) {
+      case 'passed':
+      case 'cached':
+        return this.paint('green', '✓');
+      case 'failed':
+        return this.paint('red', '✗');
+      case 'timedout':
+        return this.paint('yellow', '⏱');
+      case 'cancelled':
+        return this.paint('gray', '⊘');
+      case 'skipped':
+        return this.paint('gray', '↷');
+      case 'running':
+        return this.paint('cyan', SPINNER[this.frame]!);
+      default:
+        return this.paint('gray', '·');
+    }
+  }
+
+  private attemptNote({ status, attempt = 1, maxAttempts }: GateLine) {
+    if (attempt < 2) return '';
+    return ` (${status} on attempt ${attempt}/${maxAttempts ?? attempt})`;
+  }
+
+  private format(name: string): string {
+    const line = this.lineFor(name);
+    let elapsed = '';
+    if (line.status === 'running') {
+      elapsed = formatElapsed(Date.now() - line.startedAt);
+    } else if (line.status === 'passed' || line.status === 'failed') {
+      elapsed = formatElapsed(line.duration) + this.attemptNote(line);
+    } else if (line.status === 'timedout') {
+      elapsed = `${formatElapsed(line.duration)} timed out`;
+    } else if (line.status === 'cancelled') {
+      elapsed = `${formatElapsed(line.duration)} cancelled`;
+    } else if (line.status === 'cached') {
+      elapsed = 'cached';
+    } else if (line.status === 'skipped') {
+      elapsed = line.reason ?? '';
+    }
+    const label = name.padEnd(this.width);
+    return `${this.symbol(line.status)} ${label}  ${elapsed}`.trimEnd();
+  }
+
+  private redraw() {
+    // Move back to the top of the block and rewrite every line
+    let text = this.drawn > 0 ? `\x1b[${this.drawn}A` : '';
+    for (const name of this.lines.keys()) {
+      text += `\x1b[2K${this.format(name)}\n`;
+    }
+    this.drawn = this.lines.size;
+    this.write(text);
+  }
+}
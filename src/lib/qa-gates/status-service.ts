@@ -35,6 +35,15 @@ export async function getLatestQARun(repositoryId: string) {
   )[0];
 }
 
+/**
+ * Get a QA run by ID
+ */
+export async function getQARun(runId: string) {
+  return (
+    await db.select().from(qaRuns).where(eq(qaRuns.id, runId)).limit(1)
+  )[0];
+}
+
 /**
  * Get gate executions for a run
  */
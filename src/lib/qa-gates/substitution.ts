@@ -0,0 +1,36 @@
+/**
+ * Matches `$$` (an escaped dollar sign) or a `${NAME}` placeholder
+ */
+const PLACEHOLDER_PATTERN = /\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}/g;
+
+export type VariableLookup = Record<string, string | undefined>;
+
+export interface SubstitutionOptions {
+  /** Throw on unknown variables instead of expanding them to '' */
+  strict: boolean;
+}
+
+/**
+ * Expand `${NAME}` placeholders against the given variables.
+ * `$$` escapes to a single `$`; a bare `$NAME` is left for the shell.
+ */
+export function substituteVariables(
+  input: string,
+  vars: VariableLookup,
+  options: SubstitutionOptions
+): string {
+  return input.replace(PLACEHOLDER_PATTERN, (match, name?: string) => {
+    if (match === '$$' || name === undefined) {
+      return '$';
+    }
+
+    const value = vars[name];
+    if (value === undefined) {
+      if (options.strict) {
+        throw new Error(`Unknown variable \${${name}}`);
+      }
+      return '';
+    }
+    return value;
+  });
+}
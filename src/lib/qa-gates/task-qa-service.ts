@@ -3,11 +3,13 @@ import { tasks, qaGateResults, planTasks, plans } from '@/db/schema';
 import type { QAGateStatus } from '@/db/schema/qa-gates';
 import { eq } from 'drizzle-orm';
 import { runQAGates } from '@/lib/qa-gates/runner';
+import { loadRepositoryConfig } from '@/lib/qa-gates/config-loader';
+import { hasErrorFailure } from '@/lib/qa-gates/severity';
 import { taskEvents } from '@/lib/events/task-events';
 import { generateCommitMessage } from '@/lib/claude/commit-message';
 import { commitTaskChanges } from '@/lib/git/commit';
 
-interface GateResult {
+export interface GateResult {
   gateName: string;
   status: QAGateStatus;
   output: string;
@@ -267,9 +269,13 @@ export async function updateTaskStatus(
 }
 
 /**
- * Run QA gates for a task and update status
+ * Run QA gates for a task and update status. Gates in `passedGates` reuse
+ * their earlier result instead of running again.
  */
-export async function runTaskQAGates(taskId: string) {
+export async function runTaskQAGates(
+  taskId: string,
+  passedGates?: ReadonlyMap<string, GateResult>
+) {
   const task = await getTaskWithRepo(taskId);
 
   if (!task) {
@@ -290,7 +296,11 @@ export async function runTaskQAGates(taskId: string) {
   });
 
   // Run gates
-  const results: GateResult[] = await runQAGates(taskId, repoPath);
+  const results: GateResult[] = await runQAGates(
+    taskId,
+    repoPath,
+    passedGates
+  );
 
   // Emit individual gate results
   for (const result of results) {
@@ -304,10 +314,10 @@ export async function runTaskQAGates(taskId: string) {
     });
   }
 
-  // Update task status based on results
-  const allPassed = results.every(
-    (r) => r.status === 'passed' || r.status === 'skipped'
-  );
+  // Update task status based on results; only error-severity failures
+  // fail the task
+  const { qaGates } = await loadRepositoryConfig(repoPath);
+  const allPassed = !hasErrorFailure(results, qaGates);
 
   await updateTaskStatus(taskId, allPassed, sessionId);
 
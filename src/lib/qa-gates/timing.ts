@@ -0,0 +1,176 @@
+import type { QAGateConfig } from './config-loader';
+import type { GateOutcome, GateRunResult } from './run-report';
+import { groupByOrder, hasDependencies } from './scheduler';
+import { formatColumns, formatElapsed } from './status-board';
+
+export interface GateTiming {
+  name: string;
+  status: GateOutcome;
+  /** Every attempt and the backoff between them */
+  durationMs: number;
+  /** Spent in attempts after the first */
+  retryMs: number;
+  /** Spent waiting out `retryBackoff` between attempts */
+  backoffMs: number;
+}
+
+/**
+ * Where a run's time went, as reported under `timing`
+ */
+export interface RunTiming {
+  /** Slowest first */
+  gates: GateTiming[];
+  /** Gate durations added up: the run's length with nothing in parallel */
+  totalGateMs: number;
+  /** The chain of gates, each waiting for the one before, bounding the run */
+  criticalPath: { gates: string[]; durationMs: number };
+  /**
+   * The run's length with unbounded `maxParallel`: every gate starting as
+   * soon as what it waits for is done, which is the critical path
+   */
+  unboundedWallClockMs: number;
+}
+
+type TimingGate = Pick<QAGateConfig, 'name'> &
+  Partial<Pick<QAGateConfig, 'order' | 'dependsOn'>>;
+
+// Named by run-hooks; they run around the dependency graph, not in it
+const HOOK_NAMES = ['beforeAll', 'afterAll'];
+
+function gateTiming(gate: GateRunResult): GateTiming {
+  const [, ...retries] = gate.attemptDetails;
+  return {
+    name: gate.name,
+    status: gate.status,
+    durationMs: gate.durationMs,
+    retryMs: retries.reduce((sum, a) => sum + a.durationMs, 0),
+    backoffMs: gate.attemptDetails.reduce(
+      (sum, a) => sum + (a.backoffMs ?? 0),
+      0
+    ),
+  };
+}
+
+/**
+ * The gates each gate waits for: in stages, every gate of the stage
+ * before; in a dependency graph, its `dependsOn`, with `beforeAll` first
+ * and `afterAll` after everything
+ */
+function predecessors(gates: TimingGate[]): Map<string, string[]> {
+  const body = gates.filter((gate) => !HOOK_NAMES.includes(gate.name));
+  if (!hasDependencies(body)) {
+    const stages = groupByOrder(gates);
+    return new Map(
+      stages.flatMap((stage, index) =>
+        stage.map((gate) => [
+          gate.name,
+          (stages[index - 1] ?? []).map((before) => before.name),
+        ])
+      )
+    );
+  }
+
+  const names = body.map((gate) => gate.name);
+  const has = (name: string) => gates.some((gate) => gate.name === name);
+  const before = has('beforeAll') ? ['beforeAll'] : [];
+  const preds = new Map(
+    body.map((gate) => [
+      gate.name,
+      [...before, ...(gate.dependsOn ?? []).filter((n) => names.includes(n))],
+    ])
+  );
+  if (has('beforeAll')) preds.set('beforeAll', []);
+  if (has('afterAll')) preds.set('afterAll', [...before, ...names]);
+  return preds;
+}
+
+type Path = { gates: string[]; durationMs: number };
+
+const longer = (a: Path, b: Path) => (b.durationMs > a.durationMs ? b : a);
+
+/**
+ * The longest chain through `preds`, weighted by gate durations
+ */
+function longestPath(
+  durations: Map<string, number>,
+  preds: Map<string, string[]>
+): Path {
+  const finish = new Map<string, Path>();
+
+  function visit(name: string): Path {
+    const known = finish.get(name);
+    if (known) return known;
+    const before = (preds.get(name) ?? [])
+      .map(visit)
+      .reduce(longer, { gates: [], durationMs: 0 });
+    const duration = durations.get(name) ?? 0;
+    // Skipped gates take no time and hold nothing up
+    const path =
+      duration > 0
+        ? {
+            gates: [...before.gates, name],
+            durationMs: before.durationMs + duration,
+          }
+        : before;
+    finish.set(name, path);
+    return path;
+  }
+
+  return [...durations.keys()]
+    .map(visit)
+    .reduce(longer, { gates: [], durationMs: 0 });
+}
+
+/**
+ * Break a run's time down by gate, and find its critical path through the
+ * stages or dependency graph of `configGates`, the gates as configured.
+ * Gates that didn't report are left out, as the run left them out;
+ * reported gates missing from `configGates` wait for nothing.
+ */
+export function buildRunTiming(
+  gates: GateRunResult[],
+  configGates: TimingGate[]
+): RunTiming {
+  const durations = new Map(gates.map((gate) => [gate.name, gate.durationMs]));
+  const criticalPath = longestPath(
+    durations,
+    predecessors(configGates.filter((gate) => durations.has(gate.name)))
+  );
+  return {
+    gates: gates.map(gateTiming).sort((a, b) => b.durationMs - a.durationMs),
+    totalGateMs: gates.reduce((sum, gate) => sum + gate.durationMs, 0),
+    criticalPath,
+    unboundedWallClockMs: criticalPath.durationMs,
+  };
+}
+
+const elapsedOrDash = (ms: number) => (ms > 0 ? formatElapsed(ms) : '-');
+
+/**
+ * The profile printed after a run's summary: gates slowest first with
+ * their retry and backoff time, then the totals and critical path
+ */
+export function formatTimingProfile(
+  timing: RunTiming,
+  wallClockMs: number
+): string {
+  const rows = timing.gates.map((gate) => [
+    gate.name,
+    formatElapsed(gate.durationMs),
+    elapsedOrDash(gate.retryMs),
+    elapsedOrDash(gate.backoffMs),
+  ]);
+  const { gates, durationMs } = timing.criticalPath;
+  const path = gates.length > 0 ? gates.join(' -> ') : '-';
+  return [
+    'Timing profile',
+    formatColumns([['Gate', 'Duration', 'Retries', 'Backoff'], ...rows]),
+    '',
+    `Gate time: ${formatElapsed(timing.totalGateMs)} total, ` +
+      `${formatElapsed(wallClockMs)} wall clock`,
+    `Critical path: ${path} (${formatElapsed(durationMs)})`,
+    `Wall clock with unbounded maxParallel: ${formatElapsed(
+      timing.unboundedWallClockMs
+    )}`,
+  ].join('\n');
+}
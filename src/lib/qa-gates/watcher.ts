@@ -0,0 +1,314 @@
+import { watch, type FSWatcher } from 'fs';
+import { readFile } from 'fs/promises';
+import path from 'path';
+import { db } from '@/db';
+import { debounce } from '@/shared/lib/utils';
+import { qaRuns, type qaGateExecutions } from '@/db/schema';
+import { readRepositoryConfig, type QAGateConfig } from './config-loader';
+import { getContainerPath } from './command-executor';
+import { filterGates, type GateFilter } from './gate-filter';
+import { CACHE_DIR } from './gate-cache';
+import { createIgnoreMatcher } from './gitignore';
+import { orchestrateQAGates } from './run-orchestrator';
+import { enqueueRun } from './run-queue';
+import {
+  gateSeverity,
+  isFailure,
+  promoteWarnings,
+  type Severity,
+} from './severity';
+import { getGateExecutions } from './status-service';
+
+const DEFAULT_DEBOUNCE_MS = 300;
+
+export interface WatchOptions {
+  repositoryId: string;
+  repoPath: string;
+  /** Which gates to run; every enabled gate by default */
+  filter?: GateFilter;
+  /** Treat warning-severity gates as errors */
+  strict?: boolean;
+  /** false skips the `notify` webhook for every cycle */
+  notify?: boolean;
+  /** Every cycle copies gates' `artifacts` here, relative to the root */
+  artifactsDir?: string;
+  /** Every cycle is appended to the history here, relative to the root */
+  historyDir?: string;
+  debounceMs?: number;
+}
+
+export interface WatchStatus {
+  repositoryId: string;
+  filter: GateFilter;
+  running: boolean;
+  cycles: number;
+  lastRunId: string | null;
+}
+
+/**
+ * Wrap an async task so overlapping triggers never run it concurrently:
+ * triggers during a run queue exactly one follow-up run
+ */
+export function coalesce(task: () => Promise<void>) {
+  let running = false;
+  let queued = false;
+
+  const start = async () => {
+    running = true;
+    try {
+      do {
+        queued = false;
+        await task();
+      } while (queued);
+    } finally {
+      running = false;
+    }
+  };
+
+  return {
+    trigger() {
+      if (running) queued = true;
+      else void start();
+    },
+    isRunning: () => running,
+  };
+}
+
+type GateExecution = typeof qaGateExecutions.$inferSelect;
+
+/**
+ * "2 failed (Lint, Tests)", or "0 failed" when nothing is named
+ */
+function countOf(label: string, names: string[]) {
+  const list = names.length > 0 ? ` (${names.join(', ')})` : '';
+  return `${names.length} ${label}${list}`;
+}
+
+/**
+ * One-line result of a watch cycle, e.g.
+ * "2 passed, 1 failed (Lint), 1 warning (Docs), 0 skipped in 3.1s".
+ * Timed-out gates count as failed. Failures of warning and info gates are
+ * counted separately from errors, as are cached passes and cancelled
+ * gates; those counts are left out when zero.
+ */
+export function formatWatchSummary(
+  executions: Pick<GateExecution, 'gateName' | 'status'>[],
+  durationMs: number,
+  gates: QAGateConfig[] = []
+): string {
+  const named = (status: string) =>
+    executions.filter((e) => e.status === status).map((e) => e.gateName);
+  const failed = (severity: Severity) =>
+    executions
+      .filter((e) => isFailure(e.status))
+      .map((e) => e.gateName)
+      .filter((name) => {
+        const gate = gates.find((g) => g.name === name);
+        return (gate ? gateSeverity(gate) : 'error') === severity;
+      });
+
+  const warnings = failed('warning');
+  const info = failed('info');
+  const cached = named('cached').length;
+  const cancelled = named('cancelled').length;
+  const parts = [
+    `${named('passed').length} passed`,
+    ...(cached > 0 ? [`${cached} cached`] : []),
+    countOf('failed', failed('error')),
+    ...(warnings.length > 0
+      ? [countOf(warnings.length === 1 ? 'warning' : 'warnings', warnings)]
+      : []),
+    ...(info.length > 0 ? [countOf('info', info)] : []),
+    ...(cancelled > 0 ? [`${cancelled} cancelled`] : []),
+    `${named('skipped').length} skipped`,
+  ];
+  const seconds = (durationMs / 1000).toFixed(1);
+  return `${parts.join(', ')} in ${seconds}s`;
+}
+
+async function readIgnoreMatcher(root: string) {
+  try {
+    return createIgnoreMatcher(
+      await readFile(path.join(root, '.gitignore'), 'utf-8')
+    );
+  } catch {
+    return createIgnoreMatcher('');
+  }
+}
+
+// A path as the watcher compares it: `/`-separated
+function toWatchPath(file: string): string {
+  return file.split(path.sep).join('/');
+}
+
+class RepositoryWatcher {
+  private readonly options: WatchOptions;
+  private readonly runner = coalesce(() => this.runCycle());
+  private readonly onChange: () => void;
+  private readonly debounceMs: number;
+  private watcher: FSWatcher | null = null;
+  private stopped = false;
+  // Files each run writes, relative to the root
+  private outputPaths: string[] = [];
+  // Directories each run writes into, relative to the root
+  private outputDirs: string[] = [];
+  // When the last cycle finished
+  private settledAt = 0;
+  cycles = 0;
+  lastRunId: string | null = null;
+
+  constructor(options: WatchOptions) {
+    this.options = options;
+    this.debounceMs = options.debounceMs ?? DEFAULT_DEBOUNCE_MS;
+    this.onChange = debounce(() => {
+      if (!this.stopped) this.runner.trigger();
+    }, this.debounceMs);
+  }
+
+  /**
+   * Whether a change may be the run's own write, such as an `onFailure`
+   * fix, a coverage file or an artifact: it arrived while a cycle was
+   * running, or within the debounce delay after it finished
+   */
+  private duringRun(): boolean {
+    return (
+      this.runner.isRunning() || Date.now() - this.settledAt < this.debounceMs
+    );
+  }
+
+  async start() {
+    const root = getContainerPath(this.options.repoPath);
+    const isIgnored = await readIgnoreMatcher(root);
+    const { artifactsDir, historyDir } = this.options;
+    this.outputDirs = [artifactsDir, historyDir].flatMap((dir) =>
+      dir ? [toWatchPath(path.relative(root, path.resolve(root, dir)))] : []
+    );
+
+    this.watcher = watch(root, { recursive: true }, (_event, filename) => {
+      if (!filename || this.duringRun()) return;
+      const relative = toWatchPath(filename.toString());
+      if (isIgnored(relative) || this.isOutput(relative)) return;
+      this.onChange();
+    });
+    this.runner.trigger();
+  }
+
+  /**
+   * The run's own reports, metrics (and the metrics temp file), artifact
+   * copies, history and cache entries must not trigger another run
+   */
+  private isOutput(relative: string): boolean {
+    return (
+      relative.split('/')[0] === CACHE_DIR ||
+      this.outputPaths.some(
+        (output) => relative === output || relative.startsWith(`${output}.`)
+      ) ||
+      this.outputDirs.some(
+        (dir) => relative === dir || relative.startsWith(`${dir}/`)
+      )
+    );
+  }
+
+  stop() {
+    this.stopped = true;
+    this.watcher?.close();
+  }
+
+  status(): WatchStatus {
+    return {
+      repositoryId: this.options.repositoryId,
+      filter: this.options.filter ?? {},
+      running: this.runner.isRunning(),
+      cycles: this.cycles,
+      lastRunId: this.lastRunId,
+    };
+  }
+
+  private async runCycle() {
+    const { repositoryId, repoPath } = this.options;
+    const startTime = Date.now();
+    try {
+      const config = await readRepositoryConfig(repoPath);
+      const outputs = [config.reportJson, config.metricsOut, config.junit];
+      this.outputPaths = outputs.flatMap((output) =>
+        output ? [toWatchPath(path.normalize(output))] : []
+      );
+      const gates = filterGates(config.qaGates, this.options.filter);
+      const run = (
+        await db
+          .insert(qaRuns)
+          .values({ repositoryId, status: 'running' })
+          .returning()
+      )[0];
+      if (!run) throw new Error('Failed to create QA run');
+
+      this.lastRunId = run.id;
+      await enqueueRun(() =>
+        orchestrateQAGates({
+          runId: run.id,
+          repoPath,
+          gates,
+          settings: config,
+          strict: this.options.strict,
+          notify: this.options.notify,
+          artifactsDir: this.options.artifactsDir,
+          historyDir: this.options.historyDir,
+        })
+      );
+      const summary = formatWatchSummary(
+        await getGateExecutions(run.id),
+        Date.now() - startTime,
+        this.options.strict ? promoteWarnings(gates) : gates
+      );
+      console.log(`[watch] ${repoPath}: ${summary}`);
+    } catch (error) {
+      // Keep watching; the next change gets another chance
+      console.error(`[watch] ${repoPath}: cycle failed:`, error);
+    } finally {
+      this.cycles++;
+      this.settledAt = Date.now();
+    }
+  }
+}
+
+// Force true singleton using global to survive hot-reloads
+const globalForWatchers = global as typeof globalThis & {
+  qaGateWatchers?: Map<string, RepositoryWatcher>;
+};
+
+const watchers = (globalForWatchers.qaGateWatchers ??= new Map());
+
+/**
+ * Watch a repository and re-run its gates whenever a file not excluded by
+ * `.gitignore` changes. Changes are debounced; changes during a run are
+ * ignored, so the files gates write never start another run. Replaces any
+ * existing watcher for the repository.
+ */
+export async function startWatching(
+  options: WatchOptions
+): Promise<WatchStatus> {
+  // Reject a bad filter up front rather than on every cycle
+  const config = await readRepositoryConfig(options.repoPath);
+  filterGates(config.qaGates, options.filter);
+
+  stopWatching(options.repositoryId);
+  const watcher = new RepositoryWatcher(options);
+  await watcher.start();
+  watchers.set(options.repositoryId, watcher);
+  return watcher.status();
+}
+
+/**
+ * Stop watching a repository; returns false if it was not being watched
+ */
+export function stopWatching(repositoryId: string): boolean {
+  const watcher = watchers.get(repositoryId);
+  if (!watcher) return false;
+  watcher.stop();
+  watchers.delete(repositoryId);
+  return true;
+}
+
+export function getWatchStatus(repositoryId: string): WatchStatus | null {
+  return watchers.get(repositoryId)?.status() ?? null;
+}
@@ -23,6 +23,9 @@ const mockClaudeWrapper = {
 const mockRunPreFlightChecks = vi.fn();
 const mockCaptureDiff = vi.fn();
 const mockRunTaskQAGates = vi.fn();
+const mockLoadRepositoryConfig = vi.fn();
+const mockSleep = vi.fn();
+const mockRunFailureFixes = vi.fn();
 
 vi.mock('@/db', () => ({
   db: mockDb,
@@ -64,6 +67,19 @@ vi.mock('@/lib/qa-gates/command-executor', () => ({
   getContainerPath: vi.fn((path: string) => path),
 }));
 
+vi.mock('@/lib/qa-gates/config-loader', () => ({
+  loadRepositoryConfig: mockLoadRepositoryConfig,
+}));
+
+vi.mock('@/lib/qa-gates/runner', () => ({
+  runFailureFixes: mockRunFailureFixes,
+}));
+
+vi.mock('@/lib/qa-gates/retry-backoff', () => ({
+  computeBackoffDelay: vi.fn(() => 1000),
+  sleep: mockSleep,
+}));
+
 describe('Task Orchestrator', () => {
   let executeTask: (taskId: string) => Promise<void>;
 
@@ -106,6 +122,8 @@ describe('Task Orchestrator', () => {
     });
 
     mockRunTaskQAGates.mockResolvedValue({ results: [], passed: true });
+    mockLoadRepositoryConfig.mockResolvedValue({ qaGates: [] });
+    mockRunFailureFixes.mockResolvedValue(null);
   });
 
   describe('Module Structure', () => {
@@ -125,7 +143,10 @@ describe('Task Orchestrator', () => {
       expect(mockRunPreFlightChecks).toHaveBeenCalledWith('/test/repo');
       expect(mockClaudeWrapper.executeTask).toHaveBeenCalled();
       expect(mockCaptureDiff).toHaveBeenCalled();
-      expect(mockRunTaskQAGates).toHaveBeenCalledWith('test-task-123');
+      expect(mockRunTaskQAGates).toHaveBeenCalledWith(
+        'test-task-123',
+        undefined
+      );
     });
 
     it('should update task status to pre_flight', async () => {
@@ -360,6 +381,113 @@ describe('Task Orchestrator', () => {
     });
   });
 
+  describe('QA Retries', () => {
+    const formatResult = {
+      gateName: 'Format',
+      status: 'passed',
+      output: '',
+      duration: 10,
+    };
+
+    // Fails the Test gate on the first attempt and passes it on the second,
+    // recording the gates each attempt was told to reuse
+    async function runWithRetryMode(retryMode: 'all' | 'failed') {
+      mockLoadRepositoryConfig.mockResolvedValue({
+        retryMode,
+        retryBackoff: { strategy: 'fixed', baseDelayMs: 1000 },
+        qaGates: [],
+      });
+      const reused: (string[] | undefined)[] = [];
+      mockRunTaskQAGates.mockImplementation(
+        async (_taskId: string, passedGates?: Map<string, unknown>) => {
+          reused.push(passedGates && [...passedGates.keys()]);
+          const passed = reused.length > 1;
+          const testResult = {
+            gateName: 'Test',
+            status: passed ? 'passed' : 'failed',
+            output: passed ? 'ok' : 'FAIL',
+            duration: 10,
+          };
+          return { results: [formatResult, testResult], passed };
+        }
+      );
+
+      await executeTask('test-task-123');
+      return reused;
+    }
+
+    it('should reuse gates passed ahead of a failure with retryMode failed', async () => {
+      expect(await runWithRetryMode('failed')).toEqual([[], ['Format']]);
+    });
+
+    it('should re-run every gate on each attempt with retryMode all', async () => {
+      expect(await runWithRetryMode('all')).toEqual([undefined, undefined]);
+    });
+
+    it('should wait out retryBackoff before the gates run again', async () => {
+      const { computeBackoffDelay } = await import(
+        '@/lib/qa-gates/retry-backoff'
+      );
+
+      await runWithRetryMode('all');
+
+      expect(computeBackoffDelay).toHaveBeenCalledWith(
+        { strategy: 'fixed', baseDelayMs: 1000 },
+        1
+      );
+      expect(mockSleep).toHaveBeenCalledTimes(1);
+      expect(mockSleep).toHaveBeenCalledWith(1000);
+    });
+
+    it("should run the failed gates' fixes before invoking Claude", async () => {
+      await runWithRetryMode('all');
+
+      expect(mockRunFailureFixes).toHaveBeenCalledTimes(1);
+      expect(mockRunFailureFixes).toHaveBeenCalledWith(
+        'test-task-123',
+        [formatResult, expect.objectContaining({ status: 'failed' })],
+        expect.objectContaining({ retryMode: 'all' }),
+        '/test/repo'
+      );
+      expect(mockClaudeWrapper.executeTask).toHaveBeenCalledTimes(2);
+    });
+
+    it('should abort the retries when a fix fails', async () => {
+      const fixError = 'onFailure command for gate "Test" failed: denied';
+      mockRunFailureFixes.mockResolvedValue(fixError);
+
+      await runWithRetryMode('all');
+
+      expect(mockRunTaskQAGates).toHaveBeenCalledTimes(1);
+      expect(mockClaudeWrapper.executeTask).toHaveBeenCalledTimes(1);
+      expect(mockSleep).not.toHaveBeenCalled();
+    });
+
+    it('should feed timed-out gates back to Claude like failed ones', async () => {
+      mockRunTaskQAGates
+        .mockResolvedValueOnce({
+          results: [
+            formatResult,
+            { gateName: 'Test', status: 'timedout', output: 'hung' },
+          ],
+          passed: false,
+        })
+        .mockResolvedValueOnce({ results: [], passed: true });
+
+      await executeTask('test-task-123');
+
+      const retry = mockClaudeWrapper.executeTask.mock.calls[1]?.[0];
+      expect(retry?.prompt).toContain('### Test Failed:');
+      expect(retry?.prompt).not.toContain('### Format Failed:');
+    });
+
+    it('should not wait when the gates pass first try', async () => {
+      await executeTask('test-task-123');
+
+      expect(mockSleep).not.toHaveBeenCalled();
+    });
+  });
+
   describe('Task Data Integration', () => {
     it('should handle tasks with complex repository structures', async () => {
       mockDb.query.tasks.findFirst.mockResolvedValue({
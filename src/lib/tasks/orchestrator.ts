@@ -1,8 +1,15 @@
 import { claudeWrapper } from '@/lib/claude/wrapper';
 import { runPreFlightChecks } from '@/lib/git/pre-flight';
 import { captureDiff } from '@/lib/git/diff';
-import { runTaskQAGates } from '@/lib/qa-gates/task-qa-service';
+import {
+  runTaskQAGates,
+  type GateResult,
+} from '@/lib/qa-gates/task-qa-service';
 import { getContainerPath } from '@/lib/qa-gates/command-executor';
+import { loadRepositoryConfig } from '@/lib/qa-gates/config-loader';
+import { computeBackoffDelay, sleep } from '@/lib/qa-gates/retry-backoff';
+import { runFailureFixes } from '@/lib/qa-gates/runner';
+import { isFailure } from '@/lib/qa-gates/severity';
 import { db } from '@/db';
 import { tasks } from '@/db/schema/tasks';
 import { eq } from 'drizzle-orm';
@@ -70,10 +77,29 @@ async function invokeClaudeForRetry(
   }
 }
 
+/**
+ * Remember the gates that passed ahead of the first failure so a
+ * retryMode 'failed' retry can resume from the failing gate
+ */
+function rememberPassedGates(
+  passedGates: Map<string, GateResult>,
+  results: GateResult[]
+) {
+  for (const result of results) {
+    if (result.status !== 'passed') break;
+    passedGates.set(result.gateName, result);
+  }
+}
+
 /* eslint-disable max-lines-per-function */
 /**
  * Run QA gates with automatic retry on failure
- * If QA gates fail, invoke Claude with error details to fix issues
+ * If QA gates fail, invoke Claude with error details to fix issues.
+ * With retryMode 'failed', gates that passed ahead of the first failure
+ * keep their result on later attempts instead of running again. Before
+ * Claude is invoked, failed gates' `onFailure` fix commands run; a failing
+ * fix aborts the remaining retries. Each retry waits out `retryBackoff`
+ * before the gates run again.
  */
 async function runQAGatesWithRetry(
   taskId: string,
@@ -82,6 +108,9 @@ async function runQAGatesWithRetry(
   sessionId: string,
   originalPrompt: string
 ): Promise<void> {
+  const config = await loadRepositoryConfig(repoPath);
+  // Reset on every invocation so a new run never reuses stale passes
+  const passedGates = new Map<string, GateResult>();
   let attempt = 1;
 
   while (attempt <= MAX_QA_ATTEMPTS) {
@@ -96,12 +125,16 @@ async function runQAGatesWithRetry(
     await emitAndAppendOutput(taskId, sessionId, qaStartMessage);
 
     // Run QA gates
-    const { results, passed } = await runTaskQAGates(taskId);
+    const { results, passed } = await runTaskQAGates(
+      taskId,
+      config.retryMode === 'failed' ? passedGates : undefined
+    );
+    rememberPassedGates(passedGates, results);
 
     const qaResultMessage = passed
       ? `\n✅ QA gates PASSED on attempt ${attempt}\n`
       : `\n❌ QA gates FAILED on attempt ${attempt}\n${results
-          .filter((r) => r.status === 'failed')
+          .filter((r) => isFailure(r.status))
           .map((r) => `  • ${r.gateName}: ${r.errors?.join(', ') || r.output}`)
           .join('\n')}\n`;
 
@@ -126,8 +159,16 @@ async function runQAGatesWithRetry(
       return;
     }
 
+    const fixError = await runFailureFixes(taskId, results, config, repoPath);
+    if (fixError) {
+      const abortMessage = `\n🚫 ${fixError}\nQA retries aborted. Task failed.\n`;
+      await emitAndAppendOutput(taskId, sessionId, abortMessage);
+      console.log(`[Task ${taskId}] QA retries aborted: ${fixError}`);
+      return;
+    }
+
     // Build retry prompt with failure details
-    const failedGates = results.filter((r) => r.status === 'failed');
+    const failedGates = results.filter((r) => isFailure(r.status));
     const retryPrompt = buildRetryPrompt(originalPrompt, failedGates, attempt);
 
     const retryStartMessage = `\n\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n🔄 RETRY ATTEMPT ${attempt + 1}/${MAX_QA_ATTEMPTS}\nInvoking Claude to fix QA failures...\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n`;
@@ -171,6 +212,8 @@ async function runQAGatesWithRetry(
 
     taskEvents.emit('task:update', { sessionId, taskId, status: 'waiting_qa' });
 
+    await sleep(computeBackoffDelay(config.retryBackoff, attempt));
+
     // Increment attempt and loop
     attempt++;
   }
@@ -251,7 +294,7 @@ export async function manualQARetry(taskId: string): Promise<void> {
   console.log(`[Task ${taskId}] QA gates failed, invoking Claude to fix`);
 
   // Build retry prompt with failure details
-  const failedGates = results.filter((r) => r.status === 'failed');
+  const failedGates = results.filter((r) => isFailure(r.status));
   const currentAttempt = task.currentQAAttempt || 1;
   const retryPrompt = buildRetryPrompt(
     task.prompt,
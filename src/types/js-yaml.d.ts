@@ -0,0 +1,21 @@
+// Type declarations for js-yaml (only the parts Forge uses)
+declare module 'js-yaml' {
+  export interface LoadOptions {
+    filename?: string;
+    json?: boolean;
+  }
+
+  export interface DumpOptions {
+    indent?: number;
+    lineWidth?: number;
+    noRefs?: boolean;
+    sortKeys?: boolean;
+  }
+
+  export class YAMLException extends Error {
+    reason: string;
+  }
+
+  export function load(input: string, options?: LoadOptions): unknown;
+  export function dump(value: unknown, options?: DumpOptions): string;
+}